@@ -16,10 +16,12 @@ package ovs
 
 import (
 	"encoding"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net"
 	"strconv"
+	"strings"
 )
 
 var (
@@ -42,6 +44,30 @@ var (
 	// for a valid VLAN PCP.
 	errInvalidVLANPCP = errors.New("VLAN PCP must be between 0 and 7")
 
+	// errInvalidVLANEtherType is returned when PushVLAN is called with an
+	// EtherType other than 0x8100 or 0x88a8.
+	errInvalidVLANEtherType = errors.New("VLAN EtherType must be 0x8100 or 0x88a8")
+
+	// errInvalidNetworkTOS is returned when ModNetworkTOS is called with a
+	// value which is out of range or which sets the ECN bits.
+	errInvalidNetworkTOS = errors.New("IP ToS must be between 0 and 255 with the two ECN bits unset")
+
+	// errInvalidNetworkECN is returned when ModNetworkECN is called with a
+	// value which is out of range.
+	errInvalidNetworkECN = errors.New("IP ECN must be between 0 and 3")
+
+	// errInvalidNetworkTTL is returned when ModNetworkTTL is called with a
+	// value which is out of range.
+	errInvalidNetworkTTL = errors.New("IP TTL must be between 0 and 255")
+
+	// errInvalidDecTTLID is returned when DecTTL is called with a
+	// controller ID which is out of range.
+	errInvalidDecTTLID = errors.New("dec_ttl controller ID must be between 0 and 65535")
+
+	// errEnqueuePort is returned when Enqueue is called with a port which
+	// is neither positive nor PortLOCAL.
+	errEnqueuePort = errors.New("enqueue port must be positive or PortLOCAL")
+
 	// errOutputNegativePort is returned when Output is called with a
 	// negative integer port.
 	errOutputNegativePort = errors.New("output port number must not be negative")
@@ -68,8 +94,72 @@ var (
 	// errOutputFieldEmpty is returned when OutputField is called with field set to the empty string.
 	errOutputFieldEmpty = errors.New("field for action output (output:field syntax) is empty")
 
+	// errGroupNegative is returned when OutputGroup is called with a negative
+	// group ID.
+	errGroupNegative = errors.New("group ID must not be negative")
+
+	// errHashFieldsInvalid is returned when Multipath, Bundle or BundleLoad
+	// is used with HashFields which are not known to Open vSwitch.
+	errHashFieldsInvalid = errors.New("invalid hash fields")
+
+	// errMultipathAlgorithm is returned when Multipath is used with an
+	// algorithm which is not known to Open vSwitch.
+	errMultipathAlgorithm = errors.New("invalid multipath algorithm")
+
+	// errMultipathBasis is returned when Multipath is called with a basis
+	// which is out of range.
+	errMultipathBasis = errors.New("multipath basis must be between 0 and 65535")
+
+	// errMultipathLinks is returned when Multipath is called with a number
+	// of links which is out of range.
+	errMultipathLinks = errors.New("multipath n_links must be between 1 and 65536")
+
+	// errMultipathDstEmpty is returned when Multipath is called with dst set
+	// to the empty string.
+	errMultipathDstEmpty = errors.New("dst field for action multipath is empty")
+
 	// errLearnedNil is returned when Learn is called with a nil *LearnedFlow.
 	errLearnedNil = errors.New("learned flow for action learn is nil")
+
+	// errInvalidMPLSLabel is returned when an input MPLS label is out of
+	// range.  It should only use the first 20 bits of the 32 bit field.
+	errInvalidMPLSLabel = errors.New("MPLS label must only use 20 bits")
+
+	// errInvalidMPLSEtherType is returned when PushMPLS is called with an
+	// EtherType which is not an MPLS EtherType.
+	errInvalidMPLSEtherType = errors.New("MPLS EtherType must be 0x8847 or 0x8848")
+
+	// errInvalidNSHSPI is returned when an input NSH service path identifier
+	// is out of range.  It should only use the first 24 bits of the 32 bit
+	// field.
+	errInvalidNSHSPI = errors.New("NSH service path identifier must only use 24 bits")
+
+	// errInvalidNSHContext is returned when an NSH context header other than
+	// 1-4 is specified.
+	errInvalidNSHContext = errors.New("NSH context header must be between 1 and 4")
+
+	// errInvalidTunnelMetadata is returned when a tun_metadata field other
+	// than 0-63 is specified.
+	errInvalidTunnelMetadata = errors.New("tunnel metadata field must be between 0 and 63")
+
+	// errInvalidTunnelMetadataValue is returned when a tun_metadata value is
+	// empty or too long.
+	errInvalidTunnelMetadataValue = errors.New("tunnel metadata value must be between 1 and 124 bytes")
+
+	// errInvalidTunnelMetadataMask is returned when a tun_metadata mask is
+	// empty or too long.
+	errInvalidTunnelMetadataMask = errors.New("tunnel metadata mask must be between 1 and 124 bytes")
+
+	// errCheckPktLargerDst is returned when CheckPktLarger is called with
+	// an empty destination field.
+	errCheckPktLargerDst = errors.New("destination field for action check_pkt_larger is empty")
+
+	// errNoteEmpty is returned when Note is called with no data.
+	errNoteEmpty = errors.New("note must contain at least one byte")
+
+	// errRawActionEmpty is returned when RawAction is called with an empty
+	// action string.
+	errRawActionEmpty = errors.New("raw action is empty")
 )
 
 // Action strings in lower case, as those are compared to the lower case letters
@@ -82,6 +172,10 @@ const (
 	actionLocal     = "local"
 	actionNormal    = "normal"
 	actionStripVLAN = "strip_vlan"
+	actionDecap     = "decap()"
+	actionPopVLAN   = "pop_vlan"
+	actionPopQueue  = "pop_queue"
+	actionDecTTL    = "dec_ttl"
 )
 
 // An Action is a type which can be marshaled into an OpenFlow action. Actions can be
@@ -93,6 +187,23 @@ type Action interface {
 	fmt.GoStringer
 }
 
+// A CompositeAction is an Action which contains a nested list of Actions,
+// such as Clone, WriteActions, or CT with Exec actions.  The nested Actions
+// are marshaled, parsed, and generated as Go syntax along with the
+// CompositeAction which contains them.
+type CompositeAction interface {
+	Action
+
+	// Actions returns the nested Actions of the CompositeAction.
+	Actions() []Action
+}
+
+var (
+	_ CompositeAction = &cloneAction{}
+	_ CompositeAction = &ctSpecAction{}
+	_ CompositeAction = &writeActionsAction{}
+)
+
 // A textAction is an Action which is referred to by a name only, with no arguments.
 type textAction struct {
 	action string
@@ -120,6 +231,14 @@ func (a *textAction) GoString() string {
 		return "ovs.Normal()"
 	case actionStripVLAN:
 		return "ovs.StripVLAN()"
+	case actionDecap:
+		return "ovs.Decap()"
+	case actionPopVLAN:
+		return "ovs.PopVLAN()"
+	case actionPopQueue:
+		return "ovs.PopQueue()"
+	case actionClearActions:
+		return "ovs.ClearActions()"
 	default:
 		return fmt.Sprintf("// BUG(mdlayher): unimplemented OVS text action: %q", a.action)
 	}
@@ -178,11 +297,41 @@ func StripVLAN() Action {
 	}
 }
 
+// RawAction passes an action string to Open vSwitch verbatim.  It is
+// used when parsing actions which are not otherwise supported by this
+// package, so that they are preserved when the Flow is marshaled again.
+func RawAction(action string) Action {
+	return &rawAction{
+		action: action,
+	}
+}
+
+// A rawAction is an Action which is used by RawAction.
+type rawAction struct {
+	action string
+}
+
+// MarshalText implements Action.
+func (a *rawAction) MarshalText() ([]byte, error) {
+	if a.action == "" {
+		return nil, errRawActionEmpty
+	}
+
+	return []byte(a.action), nil
+}
+
+// GoString implements Action.
+func (a *rawAction) GoString() string {
+	return fmt.Sprintf("ovs.RawAction(%q)", a.action)
+}
+
 // printf-style patterns for marshaling and unmarshaling actions.
 const (
 	patConnectionTracking          = "ct(%s)"
 	patMultipath                   = "multipath(%s,%d,%s,%d,%d,%s)"
 	patConjunction                 = "conjunction(%d,%d/%d)"
+	patGroup                       = "group:%d"
+	patMeter                       = "meter:%d"
 	patModDataLinkDestination      = "mod_dl_dst:%s"
 	patModDataLinkSource           = "mod_dl_src:%s"
 	patModNetworkDestination       = "mod_nw_dst:%s"
@@ -190,14 +339,37 @@ const (
 	patModTransportDestinationPort = "mod_tp_dst:%d"
 	patModTransportSourcePort      = "mod_tp_src:%d"
 	patModVLANVID                  = "mod_vlan_vid:%d"
+	patModVLANPCP                  = "mod_vlan_pcp:%d"
+	patPushVLAN                    = "push_vlan:0x%04x"
+	patSetQueue                    = "set_queue:%d"
+	patEnqueue                     = "enqueue:%s:%d"
 	patOutput                      = "output:%d"
 	patOutputField                 = "output:%s"
 	patResubmitPort                = "resubmit:%s"
 	patResubmitPortTable           = "resubmit(%s,%s)"
 	patLearn                       = "learn(%s)"
+	patPushMPLS                    = "push_mpls:0x%04x"
+	patPopMPLS                     = "pop_mpls:0x%04x"
+	patSetMPLSLabel                = "set_mpls_label(%d)"
+	patEncapNSH                    = "encap(nsh(md_type=%d))"
+	patClone                       = "clone(%s)"
+	patCheckPktLarger              = "check_pkt_larger(%d)->%s"
+)
+
+// MPLS EtherTypes for use with PushMPLS.
+const (
+	etherTypeMPLSUnicast   = 0x8847
+	etherTypeMPLSMulticast = 0x8848
+)
+
+// VLAN EtherTypes for use with PushVLAN.
+const (
+	etherTypeVLAN = 0x8100
+	etherTypeQinQ = 0x88a8
 )
 
-// ConnectionTracking sends a packet through the host's connection tracker.
+// ConnectionTracking sends a packet through the host's connection tracker,
+// using a raw argument string.  Use CT to specify structured arguments.
 func ConnectionTracking(args string) Action {
 	return &ctAction{
 		args: args,
@@ -384,6 +556,253 @@ func (a *modVLANVIDAction) GoString() string {
 	return fmt.Sprintf("ovs.ModVLANVID(%d)", a.vid)
 }
 
+// ModVLANPCP modifies the VLAN priority code point (PCP) on a packet.  It
+// adds a VLAN tag if one is not already present.  pcp must be a valid VLAN
+// PCP, within the range of 0 to 7.
+func ModVLANPCP(pcp int) Action {
+	return &modVLANPCPAction{
+		pcp: pcp,
+	}
+}
+
+// A modVLANPCPAction is an Action which is used by ModVLANPCP.
+type modVLANPCPAction struct {
+	pcp int
+}
+
+// MarshalText implements Action.
+func (a *modVLANPCPAction) MarshalText() ([]byte, error) {
+	if !validVLANPCP(a.pcp) {
+		return nil, errInvalidVLANPCP
+	}
+
+	return bprintf(patModVLANPCP, a.pcp), nil
+}
+
+// GoString implements Action.
+func (a *modVLANPCPAction) GoString() string {
+	return fmt.Sprintf("ovs.ModVLANPCP(%d)", a.pcp)
+}
+
+// PushVLAN pushes a new VLAN tag with the specified EtherType on to the
+// packet.  etherType must be 0x8100 for 802.1Q, or 0x88a8 for 802.1ad.
+func PushVLAN(etherType uint16) Action {
+	return &pushVLANAction{
+		etherType: etherType,
+	}
+}
+
+// A pushVLANAction is an Action which is used by PushVLAN.
+type pushVLANAction struct {
+	etherType uint16
+}
+
+// MarshalText implements Action.
+func (a *pushVLANAction) MarshalText() ([]byte, error) {
+	if a.etherType != etherTypeVLAN && a.etherType != etherTypeQinQ {
+		return nil, errInvalidVLANEtherType
+	}
+
+	return bprintf(patPushVLAN, a.etherType), nil
+}
+
+// GoString implements Action.
+func (a *pushVLANAction) GoString() string {
+	return fmt.Sprintf("ovs.PushVLAN(%#04x)", a.etherType)
+}
+
+// PopVLAN removes the outermost VLAN tag from a packet.  It is equivalent
+// to StripVLAN.
+func PopVLAN() Action {
+	return &textAction{
+		action: actionPopVLAN,
+	}
+}
+
+// ModNetworkTOS modifies the IPv4 ToS or IPv6 traffic class field of a
+// packet.  tos must be between 0 and 255, and must not set the two low
+// order bits used for ECN; for example, DSCP 46 is a tos of 184.
+func ModNetworkTOS(tos int) Action {
+	return &modNetworkFieldAction{
+		field: modNetworkTOS,
+		value: tos,
+	}
+}
+
+// ModNetworkECN modifies the ECN bits of the IPv4 ToS or IPv6 traffic
+// class field of a packet.  ecn must be between 0 and 3.
+func ModNetworkECN(ecn int) Action {
+	return &modNetworkFieldAction{
+		field: modNetworkECN,
+		value: ecn,
+	}
+}
+
+// ModNetworkTTL modifies the IPv4 TTL or IPv6 hop limit of a packet.  ttl
+// must be between 0 and 255.
+func ModNetworkTTL(ttl int) Action {
+	return &modNetworkFieldAction{
+		field: modNetworkTTL,
+		value: ttl,
+	}
+}
+
+// Network fields which can be modified by a modNetworkFieldAction.
+const (
+	modNetworkTOS = "mod_nw_tos"
+	modNetworkECN = "mod_nw_ecn"
+	modNetworkTTL = "mod_nw_ttl"
+)
+
+// A modNetworkFieldAction is an Action which is used by ModNetworkTOS,
+// ModNetworkECN, and ModNetworkTTL.
+type modNetworkFieldAction struct {
+	field string
+	value int
+}
+
+// MarshalText implements Action.
+func (a *modNetworkFieldAction) MarshalText() ([]byte, error) {
+	switch a.field {
+	case modNetworkTOS:
+		if a.value < 0 || a.value > 0xff || a.value&0x03 != 0 {
+			return nil, errInvalidNetworkTOS
+		}
+	case modNetworkECN:
+		if a.value < 0 || a.value > 0x03 {
+			return nil, errInvalidNetworkECN
+		}
+	case modNetworkTTL:
+		if a.value < 0 || a.value > 0xff {
+			return nil, errInvalidNetworkTTL
+		}
+	}
+
+	return bprintf("%s:%d", a.field, a.value), nil
+}
+
+// GoString implements Action.
+func (a *modNetworkFieldAction) GoString() string {
+	switch a.field {
+	case modNetworkTOS:
+		return fmt.Sprintf("ovs.ModNetworkTOS(%d)", a.value)
+	case modNetworkECN:
+		return fmt.Sprintf("ovs.ModNetworkECN(%d)", a.value)
+	default:
+		return fmt.Sprintf("ovs.ModNetworkTTL(%d)", a.value)
+	}
+}
+
+// DecTTL decrements the IPv4 TTL or IPv6 hop limit of a packet.  If the TTL
+// reaches zero, the packet is dropped and a packet-in message is sent to
+// each controller with one of the specified IDs, or to controllers with
+// ID 0 if no IDs are specified.  Each ID must be between 0 and 65535.
+func DecTTL(ids ...int) Action {
+	return &decTTLAction{
+		ids: ids,
+	}
+}
+
+// A decTTLAction is an Action which is used by DecTTL.
+type decTTLAction struct {
+	ids []int
+}
+
+// MarshalText implements Action.
+func (a *decTTLAction) MarshalText() ([]byte, error) {
+	if len(a.ids) == 0 {
+		return []byte(actionDecTTL), nil
+	}
+
+	ids := make([]string, 0, len(a.ids))
+	for _, id := range a.ids {
+		if id < 0 || id > 0xffff {
+			return nil, errInvalidDecTTLID
+		}
+
+		ids = append(ids, strconv.Itoa(id))
+	}
+
+	return []byte(actionDecTTL + "(" + strings.Join(ids, ",") + ")"), nil
+}
+
+// GoString implements Action.
+func (a *decTTLAction) GoString() string {
+	ids := make([]string, 0, len(a.ids))
+	for _, id := range a.ids {
+		ids = append(ids, strconv.Itoa(id))
+	}
+
+	return "ovs.DecTTL(" + strings.Join(ids, ", ") + ")"
+}
+
+// SetQueue sets the queue which will be used when the packet is output to a
+// port, for use with QoS.
+func SetQueue(queue uint32) Action {
+	return &setQueueAction{
+		queue: queue,
+	}
+}
+
+// A setQueueAction is an Action which is used by SetQueue.
+type setQueueAction struct {
+	queue uint32
+}
+
+// MarshalText implements Action.
+func (a *setQueueAction) MarshalText() ([]byte, error) {
+	return bprintf(patSetQueue, a.queue), nil
+}
+
+// GoString implements Action.
+func (a *setQueueAction) GoString() string {
+	return fmt.Sprintf("ovs.SetQueue(%d)", a.queue)
+}
+
+// PopQueue restores the queue of the packet to the value it had before any
+// SetQueue actions were applied.
+func PopQueue() Action {
+	return &textAction{
+		action: actionPopQueue,
+	}
+}
+
+// Enqueue outputs the packet to the specified queue of the specified port.
+// port must be a positive integer or PortLOCAL.
+func Enqueue(port int, queue uint32) Action {
+	return &enqueueAction{
+		port:  port,
+		queue: queue,
+	}
+}
+
+// An enqueueAction is an Action which is used by Enqueue.
+type enqueueAction struct {
+	port  int
+	queue uint32
+}
+
+// MarshalText implements Action.
+func (a *enqueueAction) MarshalText() ([]byte, error) {
+	switch {
+	case a.port == PortLOCAL:
+		return bprintf(patEnqueue, portLOCAL, a.queue), nil
+	case a.port <= 0:
+		return nil, errEnqueuePort
+	}
+
+	return bprintf(patEnqueue, strconv.Itoa(a.port), a.queue), nil
+}
+
+// GoString implements Action.
+func (a *enqueueAction) GoString() string {
+	if a.port == PortLOCAL {
+		return fmt.Sprintf("ovs.Enqueue(ovs.PortLOCAL, %d)", a.queue)
+	}
+
+	return fmt.Sprintf("ovs.Enqueue(%d, %d)", a.port, a.queue)
+}
+
 // Output outputs the packet to the specified switch port.  Use
 // InPortLocal to output the packet to the LOCAL port.  port must either
 // be a non-negative integer.
@@ -440,6 +859,55 @@ func (a *outputFieldAction) GoString() string {
 	return fmt.Sprintf("ovs.OutputField(%q)", a.field)
 }
 
+// HashFields are the fields of a packet which are hashed to select an output
+// link by Multipath, Bundle and BundleLoad.
+type HashFields string
+
+// HashFields constants which are known to Open vSwitch.
+const (
+	HashFieldsEthSrc             HashFields = "eth_src"
+	HashFieldsSymmetricL4        HashFields = "symmetric_l4"
+	HashFieldsSymmetricL3L4      HashFields = "symmetric_l3l4"
+	HashFieldsSymmetricL3L4UDP   HashFields = "symmetric_l3l4+udp"
+	HashFieldsNetworkSource      HashFields = "nw_src"
+	HashFieldsNetworkDestination HashFields = "nw_dst"
+	HashFieldsSymmetricL3        HashFields = "symmetric_l3"
+)
+
+// validHashFields determines if HashFields are known to Open vSwitch.
+func validHashFields(f HashFields) bool {
+	switch f {
+	case HashFieldsEthSrc, HashFieldsSymmetricL4, HashFieldsSymmetricL3L4,
+		HashFieldsSymmetricL3L4UDP, HashFieldsNetworkSource,
+		HashFieldsNetworkDestination, HashFieldsSymmetricL3:
+		return true
+	}
+
+	return false
+}
+
+// A MultipathAlgorithm is a link selection algorithm used by Multipath.
+type MultipathAlgorithm string
+
+// MultipathAlgorithm constants which are known to Open vSwitch.
+const (
+	MultipathModuloN       MultipathAlgorithm = "modulo_n"
+	MultipathHashThreshold MultipathAlgorithm = "hash_threshold"
+	MultipathHRW           MultipathAlgorithm = "hrw"
+	MultipathIterHash      MultipathAlgorithm = "iter_hash"
+)
+
+// validMultipathAlgorithm determines if a MultipathAlgorithm is known to
+// Open vSwitch.
+func validMultipathAlgorithm(a MultipathAlgorithm) bool {
+	switch a {
+	case MultipathModuloN, MultipathHashThreshold, MultipathHRW, MultipathIterHash:
+		return true
+	}
+
+	return false
+}
+
 // Multipath returns a MultipathAction instance.
 //
 // Hashes fields using `basis` as a universal hash parameter, then
@@ -449,7 +917,7 @@ func (a *outputFieldAction) GoString() string {
 // subfield in the syntax described under ``Field Specifications’’
 // above.
 // https://www.openvswitch.org/support/dist-docs/ovs-actions.7.txt
-func Multipath(fields string, basis int, algorithm string, nlinks int, arg int, dst string) Action {
+func Multipath(fields HashFields, basis int, algorithm MultipathAlgorithm, nlinks int, arg int, dst string) Action {
 	return &multipathAction{
 		fields:    fields,
 		basis:     basis,
@@ -462,14 +930,30 @@ func Multipath(fields string, basis int, algorithm string, nlinks int, arg int,
 
 // MarshalText converts the Bucket to its string representation
 func (me *multipathAction) MarshalText() ([]byte, error) {
+	if !validHashFields(me.fields) {
+		return nil, errHashFieldsInvalid
+	}
+	if !validMultipathAlgorithm(me.algorithm) {
+		return nil, errMultipathAlgorithm
+	}
+	if me.basis < 0 || me.basis > 0xffff {
+		return nil, errMultipathBasis
+	}
+	if me.nlinks < 1 || me.nlinks > 0x10000 {
+		return nil, errMultipathLinks
+	}
+	if me.dst == "" {
+		return nil, errMultipathDstEmpty
+	}
+
 	return bprintf(patMultipath, me.fields, me.basis, me.algorithm, me.nlinks, me.arg, me.dst), nil
 }
 
 // MultipathAction represents a multipath hashing rule
 type multipathAction struct {
-	fields    string
+	fields    HashFields
 	basis     int
-	algorithm string
+	algorithm MultipathAlgorithm
 	nlinks    int
 	arg       int
 	dst       string
@@ -477,7 +961,7 @@ type multipathAction struct {
 
 // GoString implements Action.
 func (me *multipathAction) GoString() string {
-	return fmt.Sprintf("ovs.Multipath(%q, %q, %q, %q, %q, %q)", me.fields, me.basis, me.algorithm, me.nlinks, me.arg, me.dst)
+	return fmt.Sprintf("ovs.Multipath(%q, %d, %q, %d, %d, %q)", me.fields, me.basis, me.algorithm, me.nlinks, me.arg, me.dst)
 }
 
 // Conjunction associates a flow with a certain conjunction ID to match on more than
@@ -511,6 +995,65 @@ func (a *conjunctionAction) GoString() string {
 	return fmt.Sprintf("ovs.Conjunction(%d, %d, %d)", a.id, a.dimensionNumber, a.dimensionSize)
 }
 
+// OutputGroup outputs the packet to the OpenFlow group with the specified ID.
+// id must be a non-negative integer.
+func OutputGroup(id int) Action {
+	return &groupAction{
+		id: id,
+	}
+}
+
+// A groupAction is an Action which is used by OutputGroup.
+type groupAction struct {
+	id int
+}
+
+// MarshalText implements Action.
+func (a *groupAction) MarshalText() ([]byte, error) {
+	if a.id < 0 {
+		return nil, errGroupNegative
+	}
+
+	return bprintf(patGroup, a.id), nil
+}
+
+// GoString implements Action.
+func (a *groupAction) GoString() string {
+	return fmt.Sprintf("ovs.OutputGroup(%d)", a.id)
+}
+
+// ApplyMeter applies the OpenFlow meter with the specified ID to the packet,
+// which may drop the packet if the meter's rate is exceeded.  id must be a
+// non-negative integer.
+//
+// Prior to OpenFlow 1.5, meters are applied using the meter instruction, so
+// ApplyMeter must be the first action in a Flow, and may not be used within
+// WriteActions.
+func ApplyMeter(id int) Action {
+	return &meterAction{
+		id: id,
+	}
+}
+
+// A meterAction is an Action which is used by ApplyMeter.
+type meterAction struct {
+	id int
+}
+
+// MarshalText implements Action.
+func (a *meterAction) MarshalText() ([]byte, error) {
+	if a.id < 0 {
+		return nil, errMeterNegativeID
+	}
+
+	return bprintf(patMeter, a.id), nil
+}
+
+// GoString implements Action.
+func (a *meterAction) GoString() string {
+	return fmt.Sprintf("ovs.ApplyMeter(%d)", a.id)
+}
+
 // Resubmit resubmits a packet for further processing by matching
 // flows with the specified port and table.  If port or table are zero,
 // they are set to empty in the output Action.  If both are zero, an
@@ -719,6 +1262,239 @@ func (a *learnAction) MarshalText() ([]byte, error) {
 	return bprintf(patLearn, l), nil
 }
 
+// PushMPLS pushes a new MPLS label stack entry onto the packet, changing
+// its EtherType to etherType, which must be 0x8847 (unicast) or 0x8848
+// (multicast).
+func PushMPLS(etherType uint16) Action {
+	return &mplsAction{
+		push:      true,
+		etherType: etherType,
+	}
+}
+
+// PopMPLS pops the outermost MPLS label stack entry from the packet,
+// changing its EtherType to etherType.
+func PopMPLS(etherType uint16) Action {
+	return &mplsAction{
+		etherType: etherType,
+	}
+}
+
+// An mplsAction is an Action which is used by PushMPLS and PopMPLS.
+type mplsAction struct {
+	push      bool
+	etherType uint16
+}
+
+// MarshalText implements Action.
+func (a *mplsAction) MarshalText() ([]byte, error) {
+	if !a.push {
+		return bprintf(patPopMPLS, a.etherType), nil
+	}
+
+	if a.etherType != etherTypeMPLSUnicast && a.etherType != etherTypeMPLSMulticast {
+		return nil, errInvalidMPLSEtherType
+	}
+
+	return bprintf(patPushMPLS, a.etherType), nil
+}
+
+// GoString implements Action.
+func (a *mplsAction) GoString() string {
+	if a.push {
+		return fmt.Sprintf("ovs.PushMPLS(0x%04x)", a.etherType)
+	}
+
+	return fmt.Sprintf("ovs.PopMPLS(0x%04x)", a.etherType)
+}
+
+// SetMPLSLabel sets the label of the outermost MPLS label stack entry to
+// label, which must only use the first 20 bits.
+func SetMPLSLabel(label uint32) Action {
+	return &setMPLSLabelAction{
+		label: label,
+	}
+}
+
+// A setMPLSLabelAction is an Action which is used by SetMPLSLabel.
+type setMPLSLabelAction struct {
+	label uint32
+}
+
+// MarshalText implements Action.
+func (a *setMPLSLabelAction) MarshalText() ([]byte, error) {
+	if a.label > mplsLabelMax {
+		return nil, errInvalidMPLSLabel
+	}
+
+	return bprintf(patSetMPLSLabel, a.label), nil
+}
+
+// GoString implements Action.
+func (a *setMPLSLabelAction) GoString() string {
+	return fmt.Sprintf("ovs.SetMPLSLabel(%d)", a.label)
+}
+
+// EncapNSH encapsulates the packet in a Network Service Header of the
+// specified metadata type.  If mdType is zero, the Open vSwitch default
+// is used.
+func EncapNSH(mdType uint8) Action {
+	return &encapNSHAction{
+		mdType: mdType,
+	}
+}
+
+// An encapNSHAction is an Action which is used by EncapNSH.
+type encapNSHAction struct {
+	mdType uint8
+}
+
+// MarshalText implements Action.
+func (a *encapNSHAction) MarshalText() ([]byte, error) {
+	if a.mdType == 0 {
+		return []byte("encap(nsh)"), nil
+	}
+
+	return bprintf(patEncapNSH, a.mdType), nil
+}
+
+// GoString implements Action.
+func (a *encapNSHAction) GoString() string {
+	return fmt.Sprintf("ovs.EncapNSH(%d)", a.mdType)
+}
+
+// Decap removes the outermost encapsulation header from the packet, such
+// as one added by EncapNSH.
+func Decap() Action {
+	return &textAction{
+		action: actionDecap,
+	}
+}
+
+// Clone executes the specified actions on a copy of the packet, so that any
+// modifications made by the actions do not affect the original packet or
+// the actions which follow Clone.
+func Clone(actions ...Action) Action {
+	return &cloneAction{
+		actions: actions,
+	}
+}
+
+// A cloneAction is an Action which is used by Clone.
+type cloneAction struct {
+	actions []Action
+}
+
+// Actions implements CompositeAction.
+func (a *cloneAction) Actions() []Action {
+	return a.actions
+}
+
+// MarshalText implements Action.
+func (a *cloneAction) MarshalText() ([]byte, error) {
+	actions, err := marshalActions(a.actions)
+	if err != nil {
+		return nil, err
+	}
+
+	return bprintf(patClone, strings.Join(actions, ",")), nil
+}
+
+// GoString implements Action.
+func (a *cloneAction) GoString() string {
+	return "ovs.Clone(" + goStringActions(a.actions) + ")"
+}
+
+// CheckPktLarger checks if the packet, including its L2 header, is larger
+// than pktLen bytes, and stores the result of the check in the 1 bit
+// field dst, such as "NXM_NX_REG0[0]".
+func CheckPktLarger(pktLen uint16, dst string) Action {
+	return &checkPktLargerAction{
+		pktLen: pktLen,
+		dst:    dst,
+	}
+}
+
+// A checkPktLargerAction is an Action which is used by CheckPktLarger.
+type checkPktLargerAction struct {
+	pktLen uint16
+	dst    string
+}
+
+// MarshalText implements Action.
+func (a *checkPktLargerAction) MarshalText() ([]byte, error) {
+	if a.dst == "" {
+		return nil, errCheckPktLargerDst
+	}
+
+	return bprintf(patCheckPktLarger, a.pktLen, a.dst), nil
+}
+
+// GoString implements Action.
+func (a *checkPktLargerAction) GoString() string {
+	return fmt.Sprintf("ovs.CheckPktLarger(%d, %q)", a.pktLen, a.dst)
+}
+
+// goStringActions returns the Go syntax representation of a list of
+// Actions, as used by CompositeActions.
+func goStringActions(actions []Action) string {
+	ss := make([]string, 0, len(actions))
+	for _, a := range actions {
+		ss = append(ss, a.GoString())
+	}
+
+	return strings.Join(ss, ", ")
+}
+
+// Note does nothing to the packet, but attaches arbitrary data to a flow
+// so that it is visible in the output of 'ovs-ofctl dump-flows'.
+func Note(data []byte) Action {
+	return &noteAction{
+		data: data,
+	}
+}
+
+// A noteAction is an Action which is used by Note.
+type noteAction struct {
+	data []byte
+}
+
+// MarshalText implements Action.
+func (a *noteAction) MarshalText() ([]byte, error) {
+	if len(a.data) == 0 {
+		return nil, errNoteEmpty
+	}
+
+	return []byte("note:" + hexDotted(a.data)), nil
+}
+
+// GoString implements Action.
+func (a *noteAction) GoString() string {
+	return fmt.Sprintf("ovs.Note(%#v)", a.data)
+}
+
+// hexDotted formats b as pairs of hexadecimal digits separated by periods,
+// as used by Open vSwitch for the note action and controller userdata.
+func hexDotted(b []byte) string {
+	ss := make([]string, 0, len(b))
+	for _, c := range b {
+		ss = append(ss, fmt.Sprintf("%02x", c))
+	}
+
+	return strings.Join(ss, ".")
+}
+
+// parseHexDotted parses pairs of hexadecimal digits which are optionally
+// separated by periods, such as "00.01.02" or "000102".
+func parseHexDotted(s string) ([]byte, error) {
+	b, err := hex.DecodeString(strings.Replace(s, ".", "", -1))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hexadecimal data %q: %v", s, err)
+	}
+
+	return b, nil
+}
+
 // validARPOP indicates if an ARP OP is out of range. It should be in the range
 // 1-4.
 func validARPOP(op uint16) bool {
@@ -52,6 +52,10 @@ func TestActionConstants(t *testing.T) {
 			a:   StripVLAN(),
 			out: "strip_vlan",
 		},
+		{
+			a:   Decap(),
+			out: "decap()",
+		},
 	}
 
 	for _, tt := range tests {
@@ -547,6 +551,36 @@ func TestMultipath(t *testing.T) {
 			a:      Multipath("symmetric_l3l4+udp", 1024, "hrw", 2, 0, "reg0"),
 			action: "multipath(symmetric_l3l4+udp,1024,hrw,2,0,reg0)",
 		},
+		{
+			desc:   "multipath constants",
+			a:      Multipath(HashFieldsEthSrc, 0, MultipathIterHash, 4, 2, "NXM_NX_REG0[0..1]"),
+			action: "multipath(eth_src,0,iter_hash,4,2,NXM_NX_REG0[0..1])",
+		},
+		{
+			desc: "unknown fields",
+			a:    Multipath("foo", 1024, MultipathHRW, 2, 0, "reg0"),
+			err:  errHashFieldsInvalid,
+		},
+		{
+			desc: "unknown algorithm",
+			a:    Multipath(HashFieldsSymmetricL4, 1024, "foo", 2, 0, "reg0"),
+			err:  errMultipathAlgorithm,
+		},
+		{
+			desc: "basis out of range",
+			a:    Multipath(HashFieldsSymmetricL4, 65536, MultipathHRW, 2, 0, "reg0"),
+			err:  errMultipathBasis,
+		},
+		{
+			desc: "no links",
+			a:    Multipath(HashFieldsSymmetricL4, 1024, MultipathHRW, 0, 0, "reg0"),
+			err:  errMultipathLinks,
+		},
+		{
+			desc: "empty dst",
+			a:    Multipath(HashFieldsSymmetricL4, 1024, MultipathHRW, 2, 0, ""),
+			err:  errMultipathDstEmpty,
+		},
 	}
 
 	for _, tt := range tests {
@@ -613,6 +647,463 @@ func TestConjunction(t *testing.T) {
 	}
 }
 
+func TestOutputGroup(t *testing.T) {
+	var tests = []struct {
+		desc   string
+		a      Action
+		action string
+		err    error
+	}{
+		{
+			desc:   "group 0",
+			a:      OutputGroup(0),
+			action: "group:0",
+		},
+		{
+			desc:   "group 10",
+			a:      OutputGroup(10),
+			action: "group:10",
+		},
+		{
+			desc: "negative group",
+			a:    OutputGroup(-1),
+			err:  errGroupNegative,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			action, err := tt.a.MarshalText()
+
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if err != nil {
+				return
+			}
+
+			if want, got := tt.action, string(action); want != got {
+				t.Fatalf("unexpected Action:\n- want: %q\n-  got: %q",
+					want, got)
+			}
+		})
+	}
+}
+
+func TestNote(t *testing.T) {
+	var tests = []struct {
+		desc   string
+		a      Action
+		action string
+		err    error
+	}{
+		{
+			desc:   "one byte",
+			a:      Note([]byte{0xff}),
+			action: "note:ff",
+		},
+		{
+			desc:   "multiple bytes",
+			a:      Note([]byte("foo")),
+			action: "note:66.6f.6f",
+		},
+		{
+			desc: "empty",
+			a:    Note(nil),
+			err:  errNoteEmpty,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			action, err := tt.a.MarshalText()
+
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if err != nil {
+				return
+			}
+
+			if want, got := tt.action, string(action); want != got {
+				t.Fatalf("unexpected Action:\n- want: %q\n-  got: %q",
+					want, got)
+			}
+		})
+	}
+}
+
+func TestVLANQoSAndTTLActions(t *testing.T) {
+	var tests = []struct {
+		desc   string
+		a      Action
+		action string
+		err    error
+	}{
+		{
+			desc:   "push_vlan 802.1Q",
+			a:      PushVLAN(0x8100),
+			action: "push_vlan:0x8100",
+		},
+		{
+			desc:   "push_vlan 802.1ad",
+			a:      PushVLAN(0x88a8),
+			action: "push_vlan:0x88a8",
+		},
+		{
+			desc: "push_vlan invalid EtherType",
+			a:    PushVLAN(0x0800),
+			err:  errInvalidVLANEtherType,
+		},
+		{
+			desc:   "pop_vlan",
+			a:      PopVLAN(),
+			action: "pop_vlan",
+		},
+		{
+			desc:   "mod_vlan_pcp",
+			a:      ModVLANPCP(5),
+			action: "mod_vlan_pcp:5",
+		},
+		{
+			desc: "mod_vlan_pcp too large",
+			a:    ModVLANPCP(8),
+			err:  errInvalidVLANPCP,
+		},
+		{
+			desc: "mod_vlan_pcp negative",
+			a:    ModVLANPCP(-1),
+			err:  errInvalidVLANPCP,
+		},
+		{
+			desc:   "mod_nw_tos",
+			a:      ModNetworkTOS(184),
+			action: "mod_nw_tos:184",
+		},
+		{
+			desc: "mod_nw_tos with ECN bits",
+			a:    ModNetworkTOS(185),
+			err:  errInvalidNetworkTOS,
+		},
+		{
+			desc: "mod_nw_tos too large",
+			a:    ModNetworkTOS(256),
+			err:  errInvalidNetworkTOS,
+		},
+		{
+			desc:   "mod_nw_ecn",
+			a:      ModNetworkECN(2),
+			action: "mod_nw_ecn:2",
+		},
+		{
+			desc: "mod_nw_ecn too large",
+			a:    ModNetworkECN(4),
+			err:  errInvalidNetworkECN,
+		},
+		{
+			desc:   "mod_nw_ttl",
+			a:      ModNetworkTTL(255),
+			action: "mod_nw_ttl:255",
+		},
+		{
+			desc: "mod_nw_ttl too large",
+			a:    ModNetworkTTL(256),
+			err:  errInvalidNetworkTTL,
+		},
+		{
+			desc:   "dec_ttl",
+			a:      DecTTL(),
+			action: "dec_ttl",
+		},
+		{
+			desc:   "dec_ttl with IDs",
+			a:      DecTTL(0, 1, 2),
+			action: "dec_ttl(0,1,2)",
+		},
+		{
+			desc: "dec_ttl with invalid ID",
+			a:    DecTTL(1, 65536),
+			err:  errInvalidDecTTLID,
+		},
+		{
+			desc:   "set_queue",
+			a:      SetQueue(10),
+			action: "set_queue:10",
+		},
+		{
+			desc:   "pop_queue",
+			a:      PopQueue(),
+			action: "pop_queue",
+		},
+		{
+			desc:   "enqueue",
+			a:      Enqueue(1, 2),
+			action: "enqueue:1:2",
+		},
+		{
+			desc:   "enqueue LOCAL",
+			a:      Enqueue(PortLOCAL, 0),
+			action: "enqueue:LOCAL:0",
+		},
+		{
+			desc: "enqueue port zero",
+			a:    Enqueue(0, 1),
+			err:  errEnqueuePort,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			action, err := tt.a.MarshalText()
+
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if err != nil {
+				return
+			}
+
+			if want, got := tt.action, string(action); want != got {
+				t.Fatalf("unexpected Action:\n- want: %q\n-  got: %q",
+					want, got)
+			}
+		})
+	}
+}
+
+func TestNestedActions(t *testing.T) {
+	var tests = []struct {
+		desc   string
+		a      Action
+		action string
+		err    error
+	}{
+		{
+			desc:   "empty clone",
+			a:      Clone(),
+			action: "clone()",
+		},
+		{
+			desc:   "clone",
+			a:      Clone(PushVLAN(0x8100), ModVLANVID(10), Output(1)),
+			action: "clone(push_vlan:0x8100,mod_vlan_vid:10,output:1)",
+		},
+		{
+			desc:   "nested clone",
+			a:      Clone(Clone(Output(1)), Output(2)),
+			action: "clone(clone(output:1),output:2)",
+		},
+		{
+			desc: "clone with invalid action",
+			a:    Clone(ModVLANVID(4096)),
+			err:  errInvalidVLANVID,
+		},
+		{
+			desc:   "check_pkt_larger",
+			a:      CheckPktLarger(1500, RegField(0).Bits(0, 0).String()),
+			action: "check_pkt_larger(1500)->NXM_NX_REG0[0]",
+		},
+		{
+			desc: "check_pkt_larger without destination",
+			a:    CheckPktLarger(1500, ""),
+			err:  errCheckPktLargerDst,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			action, err := tt.a.MarshalText()
+
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if err != nil {
+				return
+			}
+
+			if want, got := tt.action, string(action); want != got {
+				t.Fatalf("unexpected Action:\n- want: %q\n-  got: %q",
+					want, got)
+			}
+		})
+	}
+}
+
+func TestCompositeActionActions(t *testing.T) {
+	exec := []Action{SetField("0x1", "ct_mark")}
+
+	var tests = []struct {
+		desc    string
+		a       Action
+		actions []Action
+	}{
+		{
+			desc:    "clone",
+			a:       Clone(Output(1), Output(2)),
+			actions: []Action{Output(1), Output(2)},
+		},
+		{
+			desc:    "write_actions",
+			a:       WriteActions(Output(1)),
+			actions: []Action{Output(1)},
+		},
+		{
+			desc:    "ct exec",
+			a:       CT(CTSpec{Commit: true, Exec: exec}),
+			actions: exec,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ca, ok := tt.a.(CompositeAction)
+			if !ok {
+				t.Fatalf("action %#v is not a CompositeAction", tt.a)
+			}
+
+			if want, got := goStringActions(tt.actions), goStringActions(ca.Actions()); want != got {
+				t.Fatalf("unexpected nested actions:\n- want: %s\n-  got: %s",
+					want, got)
+			}
+		})
+	}
+}
+
+func TestApplyMeter(t *testing.T) {
+	var tests = []struct {
+		desc   string
+		a      Action
+		action string
+		err    error
+	}{
+		{
+			desc:   "meter 1",
+			a:      ApplyMeter(1),
+			action: "meter:1",
+		},
+		{
+			desc: "negative meter",
+			a:    ApplyMeter(-1),
+			err:  errMeterNegativeID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			action, err := tt.a.MarshalText()
+
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if err != nil {
+				return
+			}
+
+			if want, got := tt.action, string(action); want != got {
+				t.Fatalf("unexpected Action:\n- want: %q\n-  got: %q",
+					want, got)
+			}
+		})
+	}
+}
+
+func TestMPLS(t *testing.T) {
+	var tests = []struct {
+		desc   string
+		a      Action
+		action string
+		err    error
+	}{
+		{
+			desc:   "push unicast",
+			a:      PushMPLS(0x8847),
+			action: "push_mpls:0x8847",
+		},
+		{
+			desc:   "push multicast",
+			a:      PushMPLS(0x8848),
+			action: "push_mpls:0x8848",
+		},
+		{
+			desc: "push IPv4",
+			a:    PushMPLS(0x0800),
+			err:  errInvalidMPLSEtherType,
+		},
+		{
+			desc:   "pop to IPv4",
+			a:      PopMPLS(0x0800),
+			action: "pop_mpls:0x0800",
+		},
+		{
+			desc:   "set label",
+			a:      SetMPLSLabel(100),
+			action: "set_mpls_label(100)",
+		},
+		{
+			desc: "set label too large",
+			a:    SetMPLSLabel(0x100000),
+			err:  errInvalidMPLSLabel,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			action, err := tt.a.MarshalText()
+
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if err != nil {
+				return
+			}
+
+			if want, got := tt.action, string(action); want != got {
+				t.Fatalf("unexpected Action:\n- want: %q\n-  got: %q",
+					want, got)
+			}
+		})
+	}
+}
+
+func TestEncapNSH(t *testing.T) {
+	var tests = []struct {
+		desc   string
+		a      Action
+		action string
+	}{
+		{
+			desc:   "default metadata type",
+			a:      EncapNSH(0),
+			action: "encap(nsh)",
+		},
+		{
+			desc:   "metadata type 2",
+			a:      EncapNSH(2),
+			action: "encap(nsh(md_type=2))",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			action, err := tt.a.MarshalText()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if want, got := tt.action, string(action); want != got {
+				t.Fatalf("unexpected Action:\n- want: %q\n-  got: %q",
+					want, got)
+			}
+		})
+	}
+}
+
 func TestMove(t *testing.T) {
 	var tests = []struct {
 		desc   string
@@ -840,6 +1331,144 @@ func TestActionGoString(t *testing.T) {
 			a: Conjunction(123, 1, 2),
 			s: `ovs.Conjunction(123, 1, 2)`,
 		},
+		{
+			a: OutputGroup(10),
+			s: `ovs.OutputGroup(10)`,
+		},
+		{
+			a: PushMPLS(0x8847),
+			s: `ovs.PushMPLS(0x8847)`,
+		},
+		{
+			a: PopMPLS(0x0800),
+			s: `ovs.PopMPLS(0x0800)`,
+		},
+		{
+			a: SetMPLSLabel(100),
+			s: `ovs.SetMPLSLabel(100)`,
+		},
+		{
+			a: EncapNSH(1),
+			s: `ovs.EncapNSH(1)`,
+		},
+		{
+			a: Decap(),
+			s: `ovs.Decap()`,
+		},
+		{
+			a: ApplyMeter(1),
+			s: `ovs.ApplyMeter(1)`,
+		},
+		{
+			a: GotoTable(1),
+			s: `ovs.GotoTable(1)`,
+		},
+		{
+			a: WriteMetadata(0x10, 0xff),
+			s: `ovs.WriteMetadata(0x10, 0xff)`,
+		},
+		{
+			a: WriteActions(Output(1), Drop()),
+			s: `ovs.WriteActions(ovs.Output(1), ovs.Drop())`,
+		},
+		{
+			a: ClearActions(),
+			s: `ovs.ClearActions()`,
+		},
+		{
+			a: Controller(ControllerSpec{}),
+			s: `ovs.Controller(ovs.ControllerSpec{})`,
+		},
+		{
+			a: Controller(ControllerSpec{
+				Reason:   ControllerReasonNoMatch,
+				MaxLen:   128,
+				ID:       1,
+				Userdata: []byte{0x1, 0x2},
+				Pause:    true,
+			}),
+			s: `ovs.Controller(ovs.ControllerSpec{Reason: "no_match", MaxLen: 128, ID: 1, Userdata: []byte{0x1, 0x2}, Pause: true})`,
+		},
+		{
+			a: Multipath(HashFieldsSymmetricL4, 1024, MultipathHRW, 2, 0, "reg0"),
+			s: `ovs.Multipath("symmetric_l4", 1024, "hrw", 2, 0, "reg0")`,
+		},
+		{
+			a: Bundle(BundleSpec{Fields: HashFieldsEthSrc, Algorithm: BundleActiveBackup, Members: []int{1, 2}}),
+			s: `ovs.Bundle(ovs.BundleSpec{Fields: "eth_src", Algorithm: "active_backup", Members: []int{1, 2}})`,
+		},
+		{
+			a: BundleLoad(BundleSpec{Fields: HashFieldsSymmetricL4, Basis: 50, Algorithm: BundleHRW, Members: []int{3}, Slaves: true}, "NXM_NX_REG0[]"),
+			s: `ovs.BundleLoad(ovs.BundleSpec{Fields: "symmetric_l4", Basis: 50, Algorithm: "hrw", Members: []int{3}, Slaves: true}, "NXM_NX_REG0[]")`,
+		},
+		{
+			a: Sample(SampleSpec{Probability: 100, CollectorSetID: 1, ObsDomainID: 2, ObsPointID: 3}),
+			s: `ovs.Sample(ovs.SampleSpec{Probability: 100, CollectorSetID: 1, ObsDomainID: 2, ObsPointID: 3})`,
+		},
+		{
+			a: Note([]byte{0x0, 0x1}),
+			s: `ovs.Note([]byte{0x0, 0x1})`,
+		},
+		{
+			a: PushVLAN(0x8100),
+			s: `ovs.PushVLAN(0x8100)`,
+		},
+		{
+			a: PopVLAN(),
+			s: `ovs.PopVLAN()`,
+		},
+		{
+			a: ModVLANPCP(3),
+			s: `ovs.ModVLANPCP(3)`,
+		},
+		{
+			a: ModNetworkTOS(184),
+			s: `ovs.ModNetworkTOS(184)`,
+		},
+		{
+			a: ModNetworkECN(1),
+			s: `ovs.ModNetworkECN(1)`,
+		},
+		{
+			a: ModNetworkTTL(64),
+			s: `ovs.ModNetworkTTL(64)`,
+		},
+		{
+			a: DecTTL(),
+			s: `ovs.DecTTL()`,
+		},
+		{
+			a: DecTTL(1, 2),
+			s: `ovs.DecTTL(1, 2)`,
+		},
+		{
+			a: SetQueue(1),
+			s: `ovs.SetQueue(1)`,
+		},
+		{
+			a: PopQueue(),
+			s: `ovs.PopQueue()`,
+		},
+		{
+			a: Clone(),
+			s: `ovs.Clone()`,
+		},
+		{
+			a: Clone(Output(1), Clone(Drop())),
+			s: `ovs.Clone(ovs.Output(1), ovs.Clone(ovs.Drop()))`,
+		},
+		{
+			a: CheckPktLarger(1500, "NXM_NX_REG0[0]"),
+			s: `ovs.CheckPktLarger(1500, "NXM_NX_REG0[0]")`,
+		},
+		{
+			a: Enqueue(1, 2),
+			s: `ovs.Enqueue(1, 2)`,
+		},
+		{
+			a: Enqueue(PortLOCAL, 2),
+			s: `ovs.Enqueue(ovs.PortLOCAL, 2)`,
+		},
 		{
 			a: Move("nw_src", "nw_dst"),
 			s: `ovs.Move("nw_src", "nw_dst")`,
@@ -857,7 +1486,7 @@ func TestActionGoString(t *testing.T) {
 				Matches:        []Match{DataLinkType(0x800)},
 				Actions:        []Action{OutputField("in_port")},
 			}),
-			s: `ovs.Learn(&ovs.LearnedFlow{Priority:0, InPort:0, Matches:[]ovs.Match{ovs.DataLinkType(0x0800)}, Table:0, IdleTimeout:0, Cookie:0x0, Actions:[]ovs.Action{ovs.OutputField("in_port")}, DeleteLearned:true, FinHardTimeout:10, HardTimeout:30, Limit:10})`,
+			s: `ovs.Learn(&ovs.LearnedFlow{Priority:0, InPort:0, Matches:[]ovs.Match{ovs.DataLinkType(0x0800)}, Table:0, IdleTimeout:0, Cookie:0x0, Actions:[]ovs.Action{ovs.OutputField("in_port")}, DeleteLearned:true, SendFlowRemoved:false, FinIdleTimeout:0, FinHardTimeout:10, HardTimeout:30, Limit:10, ResultDst:""})`,
 		},
 	}
 
@@ -15,379 +15,732 @@
 package ovs
 
 import (
-	"bufio"
-	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
-	"regexp"
 	"strconv"
 	"strings"
 )
 
-// An actionParser is a parser for OVS flow actions.
+var (
+	// errActionEmpty is returned when an action list contains an empty
+	// action, such as in "output:1,,output:2".
+	errActionEmpty = errors.New("empty action")
+
+	// errActionName is returned when an action does not begin with a
+	// valid action name.
+	errActionName = errors.New("expected action name")
+
+	// errActionUnexpected is returned when an unexpected character follows
+	// an action.
+	errActionUnexpected = errors.New("unexpected character after action")
+
+	// errActionUnmatchedParen is returned when an opening parenthesis is
+	// never closed.
+	errActionUnmatchedParen = errors.New("unmatched opening parenthesis")
+
+	// errActionUnexpectedParen is returned when a closing parenthesis has
+	// no matching opening parenthesis.
+	errActionUnexpectedParen = errors.New("unexpected closing parenthesis")
+
+	// errActionUnterminatedQuote is returned when a quoted value is never
+	// closed.
+	errActionUnterminatedQuote = errors.New("unterminated quoted string")
+
+	// errActionArrowMissing is returned when an action of the form
+	// "name:src->dst" is missing its "->".
+	errActionArrowMissing = errors.New("missing \"->\" in action arguments")
+)
+
+// An ActionError is an error encountered while parsing a list of actions.
+type ActionError struct {
+	// Str is the complete action list being parsed.
+	Str string
+	// Offset is the byte offset in Str at which the error was detected.
+	Offset int
+	// Err is the underlying error.
+	Err error
+}
+
+// Error returns the string representation of an ActionError.
+func (e *ActionError) Error() string {
+	return fmt.Sprintf("action error at offset %d in %q: %v", e.Offset, e.Str, e.Err)
+}
+
+// An actionParser is a recursive descent parser for OVS flow actions, using
+// the grammar described in ovs-actions(7):
+//
+//  actions = action { "," action }
+//  action  = name [ ":" value | "(" arguments ")" ]
+//
+// Values and arguments may contain nested parentheses and double quoted
+// strings, within which commas and parentheses have no special meaning.
 type actionParser struct {
-	r *bufio.Reader
-	s stack
+	s   string
+	off int
+	err error
 }
 
-// newActionParser creates a new actionParser which wraps the input
-// io.Reader.
+// newActionParser creates a new actionParser which parses the contents
+// of the input io.Reader.
 func newActionParser(r io.Reader) *actionParser {
+	b, err := ioutil.ReadAll(r)
 	return &actionParser{
-		r: bufio.NewReader(r),
-		s: make(stack, 0),
+		s:   string(b),
+		err: err,
 	}
 }
 
-// eof is a sentinel rune for end of file.
-var eof = rune(0)
-
-// read reads a single rune from the wrapped io.Reader.  It returns eof
-// if no more runes are present.
-func (p *actionParser) read() rune {
-	ch, _, err := p.r.ReadRune()
-	if err != nil {
-		return eof
+// Parse parses a slice of Actions from the input.  The raw action strings
+// are also returned for inspection if needed.  Actions which are not known
+// to this package are returned as RawActions.
+func (p *actionParser) Parse() ([]Action, []string, error) {
+	if p.err != nil {
+		return nil, nil, p.err
 	}
-	return ch
-}
 
-// Parse parses a slice of Actions using the wrapped io.Reader.  The raw
-// action strings are also returned for inspection if needed.
-func (p *actionParser) Parse() ([]Action, []string, error) {
 	var actions []Action
 	var raw []string
 
 	for {
+		p.skipSpace()
+		if p.eof() {
+			break
+		}
+
 		a, r, err := p.parseAction()
 		if err != nil {
-			// No more actions remain
-			if err == io.EOF {
-				break
-			}
-
 			return nil, nil, err
 		}
 
 		actions = append(actions, a)
 		raw = append(raw, r)
+
+		p.skipSpace()
+		if p.eof() {
+			break
+		}
+		if p.s[p.off] != ',' {
+			return nil, nil, p.errorf(p.off, errActionUnexpected)
+		}
+		p.off++
 	}
 
 	return actions, raw, nil
 }
 
-// parseAction parses a single Action and its raw text from the wrapped
-// io.Reader.
+// parseAction parses a single Action and its raw text.
 func (p *actionParser) parseAction() (Action, string, error) {
-	// Track runes encountered
-	var buf bytes.Buffer
+	start := p.off
+	name := p.name()
+	if name == "" {
+		switch p.s[p.off] {
+		case ',':
+			return nil, "", p.errorf(p.off, errActionEmpty)
+		case ')':
+			return nil, "", p.errorf(p.off, errActionUnexpectedParen)
+		default:
+			return nil, "", p.errorf(p.off, errActionName)
+		}
+	}
 
-	for {
-		ch := p.read()
+	var (
+		sep  byte
+		args string
+	)
 
-		// If comma encountered and no open parentheses, at end of this
-		// action string
-		if ch == ',' && p.s.len() == 0 {
-			break
-		}
+	if !p.eof() {
+		switch p.s[p.off] {
+		case ':':
+			sep = ':'
+			p.off++
 
-		// If EOF encountered, at end of string
-		if ch == eof {
-			// If no items in buffer, end of this action
-			if buf.Len() == 0 {
-				return nil, "", io.EOF
+			end, err := p.scan(false)
+			if err != nil {
+				return nil, "", err
 			}
+			args = strings.TrimSpace(p.s[p.off:end])
+			p.off = end
+		case '(':
+			sep = '('
+			p.off++
 
-			// Parse action from buffer
+			end, err := p.scan(true)
+			if err != nil {
+				return nil, "", err
+			}
+			args = p.s[p.off:end]
+
+			// Skip the closing parenthesis.
+			p.off = end + 1
+
+			// Some actions store their result in a field, as in:
+			//  check_pkt_larger(1500)->NXM_NX_REG0[0]
+			if strings.HasPrefix(p.s[p.off:], "->") {
+				p.off += len("->")
+
+				end, err := p.scan(false)
+				if err != nil {
+					return nil, "", err
+				}
+				p.off = end
+			}
+		}
+	}
+
+	raw := strings.TrimSpace(p.s[start:p.off])
+	a, err := newAction(strings.ToLower(name), sep, args, raw)
+	if err != nil {
+		return nil, "", p.errorf(start, err)
+	}
+
+	return a, raw, nil
+}
+
+// name parses an action name.
+func (p *actionParser) name() string {
+	start := p.off
+	for ; !p.eof(); p.off++ {
+		c := p.s[p.off]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_') {
 			break
 		}
+	}
 
-		// Track open and closing parentheses using a stack to ensure
-		// that they are appropriately matched
-		switch ch {
+	return p.s[start:p.off]
+}
+
+// scan scans the arguments of an action beginning at the current offset
+// without consuming them, and returns the offset at which they end.  If
+// paren is true, the arguments end at the parenthesis which closes an
+// already consumed opening parenthesis.  Otherwise, they end at the next
+// comma which is not nested within parentheses, or at the end of input.
+func (p *actionParser) scan(paren bool) (int, error) {
+	// Track the offsets of opening parentheses so that an unmatched one
+	// can be reported.
+	var open []int
+	if paren {
+		open = append(open, p.off-1)
+	}
+
+	for i := p.off; i < len(p.s); i++ {
+		switch p.s[i] {
+		case '"':
+			end := p.quoted(i)
+			if end < 0 {
+				return 0, p.errorf(i, errActionUnterminatedQuote)
+			}
+			i = end
 		case '(':
-			p.s.push()
+			open = append(open, i)
 		case ')':
-			p.s.pop()
+			if len(open) == 0 {
+				return 0, p.errorf(i, errActionUnexpectedParen)
+			}
+			open = open[:len(open)-1]
+
+			if paren && len(open) == 0 {
+				return i, nil
+			}
+		case ',':
+			if !paren && len(open) == 0 {
+				return i, nil
+			}
 		}
+	}
 
-		_, _ = buf.WriteRune(ch)
+	if len(open) > 0 {
+		return 0, p.errorf(open[len(open)-1], errActionUnmatchedParen)
 	}
 
-	// Found an unmatched set of parentheses
-	if p.s.len() > 0 {
-		return nil, "", fmt.Errorf("invalid action: %q", buf.String())
+	return len(p.s), nil
+}
+
+// quoted returns the offset of the double quote which closes the quoted
+// string beginning at offset i, or -1 if the string is never closed.
+func (p *actionParser) quoted(i int) int {
+	for j := i + 1; j < len(p.s); j++ {
+		switch p.s[j] {
+		case '\\':
+			j++
+		case '"':
+			return j
+		}
 	}
 
-	s := buf.String()
-	act, err := parseAction(s)
-	return act, s, err
+	return -1
 }
 
-// A stack is a basic stack with elements that have no value.
-type stack []struct{}
+// skipSpace advances past any whitespace at the current offset.
+func (p *actionParser) skipSpace() {
+	for !p.eof() && strings.IndexByte(" \t\r\n", p.s[p.off]) != -1 {
+		p.off++
+	}
+}
 
-// len returns the current length of the stack.
-func (s *stack) len() int {
-	return len(*s)
+// eof indicates if all of the input has been consumed.
+func (p *actionParser) eof() bool {
+	return p.off >= len(p.s)
 }
 
-// push adds an element to the stack.
-func (s *stack) push() {
-	*s = append(*s, struct{}{})
+// errorf creates an ActionError for an error at the specified offset.
+func (p *actionParser) errorf(off int, err error) error {
+	return &ActionError{
+		Str:    p.s,
+		Offset: off,
+		Err:    err,
+	}
 }
 
-// pop removes an element from the stack.
-func (s *stack) pop() {
-	*s = (*s)[:s.len()-1]
+// parseAction creates an Action from a single action string.
+func parseAction(s string) (Action, error) {
+	actions, _, err := newActionParser(strings.NewReader(s)).Parse()
+	if err != nil {
+		return nil, err
+	}
+	if len(actions) != 1 {
+		return nil, fmt.Errorf("expected exactly one action in %q", s)
+	}
+
+	return actions[0], nil
 }
 
-var (
-	// resubmitRe is the regex used to match the resubmit action
-	// with port and table specified
-	resubmitRe = regexp.MustCompile(`resubmit\((\d*),(\d*)\)`)
+// parseActionList parses a nested list of Actions, such as the arguments of
+// a CompositeAction.
+func parseActionList(s string) ([]Action, error) {
+	actions, _, err := newActionParser(strings.NewReader(s)).Parse()
+	if err != nil {
+		return nil, err
+	}
 
-	// resubmitPortRe is the regex used to match the resubmit action
-	// when only a port is specified
-	resubmitPortRe = regexp.MustCompile(`resubmit:(\d+)`)
+	return actions, nil
+}
 
-	// ctRe is the regex used to match the ct action with its
-	// parameter list.
-	ctRe = regexp.MustCompile(`ct\((\S+)\)`)
+// newAction creates an Action from its lower case name, the separator
+// between its name and arguments (':', '(', or zero if none), and its
+// arguments.  Actions which are not known to this package are returned
+// as a RawAction containing the raw action string.
+func newAction(name string, sep byte, args string, raw string) (Action, error) {
+	switch sep {
+	case 0:
+		switch name {
+		case actionAll:
+			return All(), nil
+		case actionDrop:
+			return Drop(), nil
+		case actionFlood:
+			return Flood(), nil
+		case actionInPort:
+			return InPort(), nil
+		case actionLocal:
+			return Local(), nil
+		case actionNormal:
+			return Normal(), nil
+		case actionStripVLAN:
+			return StripVLAN(), nil
+		case actionClearActions:
+			return ClearActions(), nil
+		case actionController:
+			return Controller(ControllerSpec{}), nil
+		case actionPopVLAN:
+			return PopVLAN(), nil
+		case actionPopQueue:
+			return PopQueue(), nil
+		case actionDecTTL:
+			return DecTTL(), nil
+		}
+	case ':':
+		switch name {
+		case "mod_dl_dst", "mod_dl_src":
+			mac, err := net.ParseMAC(args)
+			if err != nil {
+				return nil, err
+			}
 
-	// loadRe is the regex used to match the load action
-	// with its parameters.
-	loadRe = regexp.MustCompile(`load:(\S+)->(\S+)`)
+			if name == "mod_dl_dst" {
+				return ModDataLinkDestination(mac), nil
+			}
+			return ModDataLinkSource(mac), nil
+		case "mod_nw_dst", "mod_nw_src":
+			ip4 := net.ParseIP(args).To4()
+			if ip4 == nil {
+				return nil, fmt.Errorf("invalid IPv4 address: %s", args)
+			}
 
-	// moveRe is the regex used to match the move action
-	// with its parameters.
-	moveRe = regexp.MustCompile(`move:(\S+)->(\S+)`)
+			if name == "mod_nw_dst" {
+				return ModNetworkDestination(ip4), nil
+			}
+			return ModNetworkSource(ip4), nil
+		case "mod_tp_dst", "mod_tp_src":
+			port, err := strconv.ParseUint(args, 10, 16)
+			if err != nil {
+				return nil, err
+			}
 
-	// setFieldRe is the regex used to match the set_field action
-	// with its parameters.
-	setFieldRe = regexp.MustCompile(`set_field:(\S+)->(\S+)`)
-)
+			if name == "mod_tp_dst" {
+				return ModTransportDestinationPort(uint16(port)), nil
+			}
+			return ModTransportSourcePort(uint16(port)), nil
+		case "mod_vlan_vid":
+			vid, err := strconv.Atoi(args)
+			if err != nil {
+				return nil, err
+			}
 
-// TODO(mdlayher): replace parsing regex with arguments parsers
+			return ModVLANVID(vid), nil
+		case "mod_vlan_pcp":
+			pcp, err := strconv.Atoi(args)
+			if err != nil {
+				return nil, err
+			}
 
-// parseAction creates an Action function from the input string.
-func parseAction(s string) (Action, error) {
-	// Simple actions which match a basic string
-	switch strings.ToLower(s) {
-	case actionDrop:
-		return Drop(), nil
-	case actionFlood:
-		return Flood(), nil
-	case actionInPort:
-		return InPort(), nil
-	case actionLocal:
-		return Local(), nil
-	case actionNormal:
-		return Normal(), nil
-	case actionStripVLAN:
-		return StripVLAN(), nil
-	}
-
-	// ActionCT, with its arguments
-	if ss := ctRe.FindAllStringSubmatch(s, 1); len(ss) > 0 && len(ss[0]) == 2 {
-		// Results are:
-		//  - full string
-		//  - arguments list
-		return ConnectionTracking(ss[0][1]), nil
-	}
-
-	// ActionModDataLinkDestination, with its hardware address.
-	if strings.HasPrefix(s, patModDataLinkDestination[:len(patModDataLinkDestination)-2]) {
-		var addr string
-		n, err := fmt.Sscanf(s, patModDataLinkDestination, &addr)
-		if err != nil {
-			return nil, err
-		}
-		if n > 0 {
-			mac, err := net.ParseMAC(addr)
+			return ModVLANPCP(pcp), nil
+		case "push_vlan":
+			etherType, err := strconv.ParseUint(args, 0, 16)
 			if err != nil {
 				return nil, err
 			}
 
-			return ModDataLinkDestination(mac), nil
-		}
-	}
+			return PushVLAN(uint16(etherType)), nil
+		case modNetworkTOS, modNetworkECN, modNetworkTTL:
+			v, err := strconv.Atoi(args)
+			if err != nil {
+				return nil, err
+			}
 
-	// ActionModDataLinkSource, with its hardware address.
-	if strings.HasPrefix(s, patModDataLinkSource[:len(patModDataLinkSource)-2]) {
-		var addr string
-		n, err := fmt.Sscanf(s, patModDataLinkSource, &addr)
-		if err != nil {
-			return nil, err
-		}
-		if n > 0 {
-			mac, err := net.ParseMAC(addr)
+			return &modNetworkFieldAction{
+				field: name,
+				value: v,
+			}, nil
+		case "set_queue":
+			queue, err := strconv.ParseUint(args, 10, 32)
 			if err != nil {
 				return nil, err
 			}
 
-			return ModDataLinkSource(mac), nil
-		}
-	}
+			return SetQueue(uint32(queue)), nil
+		case "enqueue":
+			ss := strings.SplitN(args, ":", 2)
+			if len(ss) != 2 {
+				return nil, fmt.Errorf("invalid enqueue arguments: %q", args)
+			}
 
-	// ActionModNetworkDestination, with it hardware address
-	if strings.HasPrefix(s, patModNetworkDestination[:len(patModNetworkDestination)-2]) {
-		var ip string
-		n, err := fmt.Sscanf(s, patModNetworkDestination, &ip)
-		if err != nil {
-			return nil, err
-		}
-		if n > 0 {
-			ip4 := net.ParseIP(ip).To4()
-			if ip4 == nil {
-				return nil, fmt.Errorf("invalid IPv4 address: %s", ip)
+			return parseEnqueue(ss[0], ss[1], raw)
+		case "output":
+			// Output to a port number, or to the port stored in a field.
+			port, err := strconv.Atoi(args)
+			if err != nil {
+				return OutputField(args), nil
 			}
 
-			return ModNetworkDestination(ip4), nil
-		}
-	}
+			return Output(port), nil
+		case "group":
+			// Identifiers referred to by name result in a RawAction.
+			id, err := strconv.Atoi(args)
+			if err != nil {
+				return RawAction(raw), nil
+			}
 
-	// ActionModNetworkSource, with it hardware address
-	if strings.HasPrefix(s, patModNetworkSource[:len(patModNetworkSource)-2]) {
-		var ip string
-		n, err := fmt.Sscanf(s, patModNetworkSource, &ip)
-		if err != nil {
-			return nil, err
+			return OutputGroup(id), nil
+		case "meter":
+			// Identifiers referred to by name result in a RawAction.
+			id, err := strconv.Atoi(args)
+			if err != nil {
+				return RawAction(raw), nil
+			}
+
+			return ApplyMeter(id), nil
+		case actionController:
+			maxLen, err := strconv.ParseUint(args, 10, 16)
+			if err != nil {
+				return nil, err
+			}
+
+			return Controller(ControllerSpec{MaxLen: uint16(maxLen)}), nil
+		case "note":
+			data, err := parseHexDotted(args)
+			if err != nil {
+				return nil, err
+			}
+
+			return Note(data), nil
+		case "goto_table":
+			// Identifiers referred to by name result in a RawAction.
+			table, err := strconv.Atoi(args)
+			if err != nil {
+				return RawAction(raw), nil
+			}
+
+			return GotoTable(table), nil
+		case "write_metadata":
+			return parseWriteMetadata(args)
+		case "resubmit":
+			// Ports referred to by name, such as IN_PORT or LOCAL, result
+			// in a RawAction.
+			port, err := strconv.Atoi(args)
+			if err != nil {
+				return RawAction(raw), nil
+			}
+
+			return ResubmitPort(port), nil
+		case "push_mpls", "pop_mpls":
+			etherType, err := strconv.ParseUint(args, 0, 16)
+			if err != nil {
+				return nil, err
+			}
+
+			if name == "push_mpls" {
+				return PushMPLS(uint16(etherType)), nil
+			}
+			return PopMPLS(uint16(etherType)), nil
+		case "set_tunnel":
+			id, err := strconv.ParseUint(args, 0, 64)
+			if err != nil {
+				return nil, err
+			}
+
+			return SetTunnel(id), nil
+		case "load", "set_field", "move":
+			src, dst, err := parseArrow(args)
+			if err != nil {
+				return nil, err
+			}
+
+			switch name {
+			case "load":
+				return Load(src, dst), nil
+			case "set_field":
+				return SetField(src, dst), nil
+			default:
+				return Move(src, dst), nil
+			}
 		}
-		if n > 0 {
-			ip4 := net.ParseIP(ip).To4()
-			if ip4 == nil {
-				return nil, fmt.Errorf("invalid IPv4 address: %s", ip)
+	case '(':
+		switch name {
+		case "ct":
+			return parseCT(args)
+		case "resubmit":
+			return parseResubmit(args, raw)
+		case "conjunction":
+			return parseConjunction(args)
+		case "multipath":
+			return parseMultipath(args, raw)
+		case "bundle", "bundle_load":
+			return parseBundle(name, args, raw)
+		case "set_mpls_label":
+			label, err := strconv.ParseUint(args, 0, 32)
+			if err != nil {
+				return nil, err
 			}
 
-			return ModNetworkSource(ip4), nil
+			return SetMPLSLabel(uint32(label)), nil
+		case "encap":
+			return parseEncap(args, raw)
+		case actionController:
+			return parseController(args, raw)
+		case actionDecTTL:
+			return parseDecTTL(args)
+		case "enqueue":
+			ss := strings.Split(args, ",")
+			if len(ss) != 2 {
+				return nil, fmt.Errorf("invalid enqueue arguments: %q", args)
+			}
+
+			return parseEnqueue(strings.TrimSpace(ss[0]), strings.TrimSpace(ss[1]), raw)
+		case "sample":
+			return parseSample(args, raw)
+		case "write_actions", "clone":
+			actions, err := parseActionList(args)
+			if err != nil {
+				return nil, err
+			}
+
+			if name == "clone" {
+				return Clone(actions...), nil
+			}
+			return WriteActions(actions...), nil
+		case "check_pkt_larger":
+			return parseCheckPktLarger(args, raw)
+		case "learn":
+			return parseLearn(args, raw)
+		case "decap":
+			// Decap with a packet type is not supported by Decap.
+			if args == "" {
+				return Decap(), nil
+			}
 		}
 	}
 
-	// ActionModTransportDestinationPort, with its port.
-	if strings.HasPrefix(s, patModTransportDestinationPort[:len(patModTransportDestinationPort)-2]) {
-		var port uint16
-		n, err := fmt.Sscanf(s, patModTransportDestinationPort, &port)
-		if err != nil {
-			return nil, err
-		}
-		if n > 0 {
-			return ModTransportDestinationPort(port), nil
-		}
+	return RawAction(raw), nil
+}
+
+// parseEncap parses the arguments of an encap action.  Only NSH
+// encapsulation is supported by EncapNSH; other arguments are returned as
+// a RawAction.
+func parseEncap(args string, raw string) (Action, error) {
+	if args == "nsh" {
+		return EncapNSH(0), nil
 	}
 
-	// ActionModTransportSourcePort, with its port.
-	if strings.HasPrefix(s, patModTransportSourcePort[:len(patModTransportSourcePort)-2]) {
-		var port uint16
-		n, err := fmt.Sscanf(s, patModTransportSourcePort, &port)
-		if err != nil {
-			return nil, err
-		}
-		if n > 0 {
-			return ModTransportSourcePort(port), nil
-		}
+	s := strings.TrimPrefix(args, "nsh(md_type=")
+	if s == args || !strings.HasSuffix(s, ")") {
+		return RawAction(raw), nil
 	}
 
-	// ActionModVLANVID, with its VLAN ID
-	if strings.HasPrefix(s, patModVLANVID[:len(patModVLANVID)-2]) {
-		var vlan int
-		n, err := fmt.Sscanf(s, patModVLANVID, &vlan)
-		if err != nil {
-			return nil, err
-		}
-		if n > 0 {
-			return ModVLANVID(vlan), nil
-		}
+	mdType, err := strconv.ParseUint(strings.TrimSuffix(s, ")"), 10, 8)
+	if err != nil {
+		return RawAction(raw), nil
 	}
 
-	// ActionConjunction, with it's id, dimension number, and dimension size
-	if strings.HasPrefix(s, patConjunction[:len(patConjunction)-10]) {
-		var id, dimensionNumber, dimensionSize int
-		n, err := fmt.Sscanf(s, patConjunction, &id, &dimensionNumber, &dimensionSize)
-		if err != nil {
-			return nil, err
-		}
-		if n > 0 {
-			return Conjunction(id, dimensionNumber, dimensionSize), nil
-		}
+	return EncapNSH(uint8(mdType)), nil
+}
+
+// parseCheckPktLarger parses a check_pkt_larger action of the form
+// "check_pkt_larger(pkt_len)->dst".
+func parseCheckPktLarger(args string, raw string) (Action, error) {
+	pktLen, err := strconv.ParseUint(args, 10, 16)
+	if err != nil {
+		return nil, err
 	}
 
-	// ActionOutput, with its port number
-	if strings.HasPrefix(s, patOutput[:len(patOutput)-2]) {
-		var port int
-		n, err := fmt.Sscanf(s, patOutput, &port)
+	i := strings.Index(raw, ")->")
+	if i == -1 {
+		return nil, errActionArrowMissing
+	}
+
+	return CheckPktLarger(uint16(pktLen), strings.TrimSpace(raw[i+len(")->"):])), nil
+}
+
+// parseDecTTL parses the arguments of a dec_ttl action of the form
+// "dec_ttl(id1,id2,...)".
+func parseDecTTL(args string) (Action, error) {
+	var ids []int
+	for _, s := range strings.Split(args, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(s))
 		if err != nil {
 			return nil, err
 		}
-		if n > 0 {
-			return Output(port), nil
-		}
+
+		ids = append(ids, id)
 	}
 
-	// ActionResubmit, with both port number and table number
-	if ss := resubmitRe.FindAllStringSubmatch(s, 1); len(ss) > 0 && len(ss[0]) == 3 {
-		var (
-			port  int
-			table int
+	return DecTTL(ids...), nil
+}
 
-			err error
-		)
+// parseEnqueue parses the port and queue arguments of an enqueue action.
+// Ports referred to by name, other than LOCAL, result in a RawAction.
+func parseEnqueue(port string, queue string, raw string) (Action, error) {
+	q, err := strconv.ParseUint(queue, 10, 32)
+	if err != nil {
+		return nil, err
+	}
 
-		// Results are:
-		//  - full string
-		//  - port in parenthesis
-		//  - table in parenthesis
+	if port == portLOCAL {
+		return Enqueue(PortLOCAL, uint32(q)), nil
+	}
 
-		if s := ss[0][1]; s != "" {
-			port, err = strconv.Atoi(s)
-			if err != nil {
-				return nil, err
-			}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return RawAction(raw), nil
+	}
+
+	return Enqueue(p, uint32(q)), nil
+}
+
+// parseArrow parses arguments of the form "src->dst".
+func parseArrow(args string) (string, string, error) {
+	i := strings.Index(args, "->")
+	if i == -1 {
+		return "", "", errActionArrowMissing
+	}
+
+	src, dst := args[:i], args[i+2:]
+	if src == "" || dst == "" {
+		return "", "", errLoadSetFieldZero
+	}
+
+	return src, dst, nil
+}
+
+// parseResubmit parses the arguments of a resubmit action of the form
+// "resubmit([port],[table])". Ports referred to by name result in a
+// RawAction.
+func parseResubmit(args string, raw string) (Action, error) {
+	ss := strings.Split(args, ",")
+	switch len(ss) {
+	case 2:
+	case 3:
+		// The "ct" flag is not supported by Resubmit.
+		return RawAction(raw), nil
+	default:
+		return nil, fmt.Errorf("invalid resubmit arguments: %q", args)
+	}
+
+	var port, table int
+	for i, p := range []*int{&port, &table} {
+		if ss[i] == "" {
+			continue
 		}
-		if s := ss[0][2]; s != "" {
-			table, err = strconv.Atoi(s)
-			if err != nil {
-				return nil, err
-			}
+
+		v, err := strconv.Atoi(ss[i])
+		if err != nil {
+			return RawAction(raw), nil
 		}
+		*p = v
+	}
+
+	return Resubmit(port, table), nil
+}
+
+// parseConjunction parses the arguments of a conjunction action of the
+// form "conjunction(id,k/n)".
+func parseConjunction(args string) (Action, error) {
+	ss := strings.Split(args, ",")
+	if len(ss) != 2 {
+		return nil, fmt.Errorf("invalid conjunction arguments: %q", args)
+	}
 
-		return Resubmit(port, table), nil
+	dims := strings.Split(ss[1], "/")
+	if len(dims) != 2 {
+		return nil, fmt.Errorf("invalid conjunction arguments: %q", args)
 	}
 
-	// ActionResubmitPort, with only a port number
-	if ss := resubmitPortRe.FindAllStringSubmatch(s, 1); len(ss) > 0 && len(ss[0]) == 2 {
-		port, err := strconv.Atoi(ss[0][1])
+	var vals [3]int
+	for i, s := range []string{ss[0], dims[0], dims[1]} {
+		v, err := strconv.Atoi(s)
 		if err != nil {
 			return nil, err
 		}
-
-		return ResubmitPort(port), nil
+		vals[i] = v
 	}
 
-	if ss := loadRe.FindAllStringSubmatch(s, 2); len(ss) > 0 && len(ss[0]) == 3 {
-		// Results are:
-		//  - full string
-		//  - value
-		//  - field
-		return Load(ss[0][1], ss[0][2]), nil
+	return Conjunction(vals[0], vals[1], vals[2]), nil
+}
+
+// parseMultipath parses the arguments of a multipath action of the form
+// "multipath(fields,basis,algorithm,n_links,arg,dst)".  Fields and
+// algorithms which are not known to this package result in a RawAction.
+func parseMultipath(args string, raw string) (Action, error) {
+	ss := strings.Split(args, ",")
+	if len(ss) != 6 {
+		return nil, fmt.Errorf("invalid multipath arguments: %q", args)
 	}
 
-	if ss := moveRe.FindAllStringSubmatch(s, 2); len(ss) > 0 && len(ss[0]) == 3 {
-		// Results are:
-		//  - full string
-		//  - value
-		//  - field
-		return Move(ss[0][1], ss[0][2]), nil
+	var vals [3]int
+	for i, s := range []string{ss[1], ss[3], ss[4]} {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, err
+		}
+		vals[i] = v
 	}
 
-	if ss := setFieldRe.FindAllStringSubmatch(s, 2); len(ss) > 0 && len(ss[0]) == 3 {
-		// Results are:
-		//  - full string
-		//  - value
-		//  - field
-		return SetField(ss[0][1], ss[0][2]), nil
+	fields, algorithm := HashFields(ss[0]), MultipathAlgorithm(ss[2])
+	if !validHashFields(fields) || !validMultipathAlgorithm(algorithm) {
+		return RawAction(raw), nil
 	}
 
-	return nil, fmt.Errorf("no action matched for %q", s)
+	return Multipath(fields, vals[0], algorithm, vals[1], vals[2], ss[5]), nil
 }
@@ -17,21 +17,84 @@ package ovs
 import (
 	"net"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 )
 
 func Test_actionParser(t *testing.T) {
 	var tests = []struct {
-		name    string
-		in      string
-		raw     []string
-		invalid bool
+		name string
+		in   string
+		raw  []string
+		err  error
 	}{
 		{
-			name:    "invalid action",
-			in:      "strip_vlan,resubmit(",
-			invalid: true,
+			name: "unmatched opening parenthesis",
+			in:   "strip_vlan,resubmit(",
+			err: &ActionError{
+				Str:    "strip_vlan,resubmit(",
+				Offset: 19,
+				Err:    errActionUnmatchedParen,
+			},
+		},
+		{
+			name: "unmatched nested parenthesis",
+			in:   "ct(commit,exec(set_field:1->ct_mark)",
+			err: &ActionError{
+				Str:    "ct(commit,exec(set_field:1->ct_mark)",
+				Offset: 2,
+				Err:    errActionUnmatchedParen,
+			},
+		},
+		{
+			name: "unexpected closing parenthesis",
+			in:   "output:1),drop",
+			err: &ActionError{
+				Str:    "output:1),drop",
+				Offset: 8,
+				Err:    errActionUnexpectedParen,
+			},
+		},
+		{
+			name: "empty action",
+			in:   "output:1,,output:2",
+			err: &ActionError{
+				Str:    "output:1,,output:2",
+				Offset: 9,
+				Err:    errActionEmpty,
+			},
+		},
+		{
+			name: "unterminated quote",
+			in:   `output:1,controller(reason="foo)`,
+			err: &ActionError{
+				Str:    `output:1,controller(reason="foo)`,
+				Offset: 27,
+				Err:    errActionUnterminatedQuote,
+			},
+		},
+		{
+			name: "characters after parentheses",
+			in:   "resubmit(,1)foo",
+			err: &ActionError{
+				Str:    "resubmit(,1)foo",
+				Offset: 12,
+				Err:    errActionUnexpected,
+			},
+		},
+		{
+			name: "bad arguments",
+			in:   "output:1,mod_tp_dst:foo",
+			err: &ActionError{
+				Str:    "output:1,mod_tp_dst:foo",
+				Offset: 9,
+				Err: &strconv.NumError{
+					Func: "ParseUint",
+					Num:  "foo",
+					Err:  strconv.ErrSyntax,
+				},
+			},
 		},
 		{
 			name: "one action",
@@ -57,18 +120,62 @@ func Test_actionParser(t *testing.T) {
 				"ct(commit,exec(set_field:1->ct_label,set_field:1->ct_mark))",
 			},
 		},
+		{
+			name: "unknown actions",
+			in:   "mod_nw_tos:16,dec_ttl,controller(reason=no_match,max_len=128),note:00.01.02,output:1",
+			raw: []string{
+				"mod_nw_tos:16",
+				"dec_ttl",
+				"controller(reason=no_match,max_len=128)",
+				"note:00.01.02",
+				"output:1",
+			},
+		},
+		{
+			name: "unknown action with nested parentheses",
+			in:   "encap(nsh(md_type=2,tlv(0x1000,10,0x12345678))),resubmit(,10)",
+			raw: []string{
+				"encap(nsh(md_type=2,tlv(0x1000,10,0x12345678)))",
+				"resubmit(,10)",
+			},
+		},
+		{
+			name: "quoted values",
+			in:   `controller(reason="a,b)",userdata=00.01),output:1`,
+			raw: []string{
+				`controller(reason="a,b)",userdata=00.01)`,
+				"output:1",
+			},
+		},
+		{
+			name: "nested action lists and result fields",
+			in:   "check_pkt_larger(1500)->NXM_NX_REG0[0],clone(ct(commit,exec(set_field:0x1->ct_mark)),clone(output:1)),output:2",
+			raw: []string{
+				"check_pkt_larger(1500)->NXM_NX_REG0[0]",
+				"clone(ct(commit,exec(set_field:0x1->ct_mark)),clone(output:1))",
+				"output:2",
+			},
+		},
+		{
+			name: "whitespace",
+			in:   " strip_vlan, output:1 ",
+			raw: []string{
+				"strip_vlan",
+				"output:1",
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := newActionParser(strings.NewReader(tt.in))
 			actions, raw, err := p.Parse()
+			if want, got := tt.err, err; !reflect.DeepEqual(want, got) {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
 			if err != nil {
-				if tt.invalid {
-					return
-				}
-
-				t.Fatalf("unexpected error during parsing: %v", err)
+				return
 			}
 
 			if want, got := tt.raw, raw; !reflect.DeepEqual(want, got) {
@@ -98,9 +205,206 @@ func Test_parseAction(t *testing.T) {
 		invalid bool
 	}{
 		{
-			s:       "foo",
+			s: "foo",
+			a: RawAction("foo"),
+		},
+		{
+			s: "dec_ttl",
+			a: DecTTL(),
+		},
+		{
+			s: "dec_ttl(1,2)",
+			a: DecTTL(1, 2),
+		},
+		{
+			s:       "dec_ttl(foo)",
+			invalid: true,
+		},
+		{
+			s:       "dec_ttl(65536)",
+			invalid: true,
+		},
+		{
+			s: "pop_vlan",
+			a: PopVLAN(),
+		},
+		{
+			s: "clone(output:1)",
+			a: Clone(Output(1)),
+		},
+		{
+			s: "clone()",
+			a: Clone(),
+		},
+		{
+			s: "clone(mod_vlan_vid:10,clone(strip_vlan,output:2),ct(commit,exec(set_field:0x1->ct_mark)))",
+			a: Clone(
+				ModVLANVID(10),
+				Clone(StripVLAN(), Output(2)),
+				CT(CTSpec{
+					Commit: true,
+					Exec:   []Action{SetField("0x1", "ct_mark")},
+				}),
+			),
+		},
+		{
+			s:       "clone(mod_tp_dst:foo)",
+			invalid: true,
+		},
+		{
+			s: "check_pkt_larger(1500)->NXM_NX_REG0[0]",
+			a: CheckPktLarger(1500, "NXM_NX_REG0[0]"),
+		},
+		{
+			s:       "check_pkt_larger(1500)",
+			invalid: true,
+		},
+		{
+			s:       "check_pkt_larger(foo)->NXM_NX_REG0[0]",
+			invalid: true,
+		},
+		{
+			s: "foo(1)->NXM_NX_REG0[0]",
+			a: RawAction("foo(1)->NXM_NX_REG0[0]"),
+		},
+		{
+			s:     "learn(table=10,idle_timeout=60,NXM_OF_VLAN_TCI[0..11],NXM_OF_ETH_DST[]=NXM_OF_ETH_SRC[],load:NXM_OF_IN_PORT[]->NXM_NX_REG0[0..15],output:NXM_OF_IN_PORT[])",
+			final: "learn(priority=32768,NXM_OF_VLAN_TCI[0..11],NXM_OF_ETH_DST[]=NXM_OF_ETH_SRC[],table=10,idle_timeout=60,fin_hard_timeout=0,hard_timeout=0,load:NXM_OF_IN_PORT[]->NXM_NX_REG0[0..15],output:NXM_OF_IN_PORT[])",
+			a: Learn(&LearnedFlow{
+				Priority: 32768,
+				Matches: []Match{
+					LearnMatchField("NXM_OF_VLAN_TCI[0..11]", ""),
+					LearnMatchField("NXM_OF_ETH_DST[]", "NXM_OF_ETH_SRC[]"),
+				},
+				Table:       10,
+				IdleTimeout: 60,
+				Actions: []Action{
+					Load("NXM_OF_IN_PORT[]", "NXM_NX_REG0[0..15]"),
+					OutputField("NXM_OF_IN_PORT[]"),
+				},
+			}),
+		},
+		{
+			s:     "learn(table=0,hard_timeout=30,fin_idle_timeout=5,fin_hard_timeout=10,priority=100,send_flow_rem,delete_learned,cookie=0x1,limit=5,result_dst=NXM_NX_REG0[5],in_port=4,dl_type=0x0800,load:0x1->NXM_NX_REG1[])",
+			final: "learn(priority=100,in_port=4,dl_type=0x0800,table=0,idle_timeout=0,fin_idle_timeout=5,fin_hard_timeout=10,hard_timeout=30,limit=5,delete_learned,send_flow_rem,result_dst=NXM_NX_REG0[5],cookie=0x0000000000000001,load:0x1->NXM_NX_REG1[])",
+			a: Learn(&LearnedFlow{
+				Priority: 100,
+				InPort:   4,
+				Matches: []Match{
+					DataLinkType(0x0800),
+				},
+				Table:           0,
+				Cookie:          1,
+				Actions:         []Action{Load("0x1", "NXM_NX_REG1[]")},
+				DeleteLearned:   true,
+				SendFlowRemoved: true,
+				FinIdleTimeout:  5,
+				FinHardTimeout:  10,
+				HardTimeout:     30,
+				Limit:           5,
+				ResultDst:       "NXM_NX_REG0[5]",
+			}),
+		},
+		{
+			desc: "learn with unknown specification",
+			s:    "learn(table=1,foo:bar,output:NXM_OF_IN_PORT[])",
+			a:    RawAction("learn(table=1,foo:bar,output:NXM_OF_IN_PORT[])"),
+		},
+		{
+			desc: "learn without actions",
+			s:    "learn(table=1,NXM_OF_ETH_DST[])",
+			a:    RawAction("learn(table=1,NXM_OF_ETH_DST[])"),
+		},
+		{
+			s:       "learn(table=foo,output:NXM_OF_IN_PORT[])",
 			invalid: true,
 		},
+		{
+			s:       "learn(table=1,load:NXM_OF_IN_PORT[],output:NXM_OF_IN_PORT[])",
+			invalid: true,
+		},
+		{
+			s: "push_vlan:0x8100",
+			a: PushVLAN(0x8100),
+		},
+		{
+			s:     "push_vlan:34984",
+			final: "push_vlan:0x88a8",
+			a:     PushVLAN(0x88a8),
+		},
+		{
+			s:       "push_vlan:0x0800",
+			invalid: true,
+		},
+		{
+			s: "mod_vlan_pcp:7",
+			a: ModVLANPCP(7),
+		},
+		{
+			s:       "mod_vlan_pcp:8",
+			invalid: true,
+		},
+		{
+			s: "mod_nw_tos:184",
+			a: ModNetworkTOS(184),
+		},
+		{
+			s:       "mod_nw_tos:1",
+			invalid: true,
+		},
+		{
+			s: "mod_nw_ecn:3",
+			a: ModNetworkECN(3),
+		},
+		{
+			s:       "mod_nw_ecn:4",
+			invalid: true,
+		},
+		{
+			s: "mod_nw_ttl:64",
+			a: ModNetworkTTL(64),
+		},
+		{
+			s:       "mod_nw_ttl:foo",
+			invalid: true,
+		},
+		{
+			s: "set_queue:1",
+			a: SetQueue(1),
+		},
+		{
+			s:       "set_queue:-1",
+			invalid: true,
+		},
+		{
+			s: "pop_queue",
+			a: PopQueue(),
+		},
+		{
+			s: "enqueue:1:2",
+			a: Enqueue(1, 2),
+		},
+		{
+			s:     "enqueue(LOCAL,2)",
+			final: "enqueue:LOCAL:2",
+			a:     Enqueue(PortLOCAL, 2),
+		},
+		{
+			s: "enqueue:IN_PORT:2",
+			a: RawAction("enqueue:IN_PORT:2"),
+		},
+		{
+			s:       "enqueue:1",
+			invalid: true,
+		},
+		{
+			s:       "enqueue(1,foo)",
+			invalid: true,
+		},
+		{
+			s: "all",
+			a: All(),
+		},
 		{
 			s: "drop",
 			a: Drop(),
@@ -134,12 +438,12 @@ func Test_parseAction(t *testing.T) {
 			a: StripVLAN(),
 		},
 		{
-			s:       "ct()",
-			invalid: true,
+			s: "ct()",
+			a: CT(CTSpec{}),
 		},
 		{
 			s: "ct(commit)",
-			a: ConnectionTracking("commit"),
+			a: CT(CTSpec{Commit: true}),
 		},
 		{
 			s:       "mod_dl_dst:foo",
@@ -218,7 +522,11 @@ func Test_parseAction(t *testing.T) {
 			a: ModVLANVID(10),
 		},
 		{
-			s:       "output:foo",
+			s: "output:NXM_NX_REG0[16..31]",
+			a: OutputField("NXM_NX_REG0[16..31]"),
+		},
+		{
+			s:       "output:",
 			invalid: true,
 		},
 		{
@@ -226,21 +534,41 @@ func Test_parseAction(t *testing.T) {
 			a: Output(1),
 		},
 		{
-			s:       "resubmit(foo,)",
-			invalid: true,
+			s: "resubmit(foo,)",
+			a: RawAction("resubmit(foo,)"),
 		},
 		{
-			s:       "resubmit(,bar)",
-			invalid: true,
+			s: "resubmit(,bar)",
+			a: RawAction("resubmit(,bar)"),
 		},
 		{
-			s:       "resubmit(foo,bar)",
-			invalid: true,
+			s: "resubmit(foo,bar)",
+			a: RawAction("resubmit(foo,bar)"),
 		},
 		{
 			s: "resubmit:4",
 			a: ResubmitPort(4),
 		},
+		{
+			s: "resubmit:IN_PORT",
+			a: RawAction("resubmit:IN_PORT"),
+		},
+		{
+			s: "resubmit:LOCAL",
+			a: RawAction("resubmit:LOCAL"),
+		},
+		{
+			s: "resubmit(IN_PORT,5)",
+			a: RawAction("resubmit(IN_PORT,5)"),
+		},
+		{
+			s: "resubmit(LOCAL,)",
+			a: RawAction("resubmit(LOCAL,)"),
+		},
+		{
+			s: "resubmit(,ingress)",
+			a: RawAction("resubmit(,ingress)"),
+		},
 		{
 			s: "resubmit(1,)",
 			a: Resubmit(1, 0),
@@ -279,7 +607,7 @@ func Test_parseAction(t *testing.T) {
 		},
 		{
 			s: "move:NXM_OF_ARP_SPA[]->NXM_OF_ARP_TPA[]",
-			a: Move("move:NXM_OF_ARP_SPA[]", "NXM_OF_ARP_TPA[]"),
+			a: Move("NXM_OF_ARP_SPA[]", "NXM_OF_ARP_TPA[]"),
 		},
 		{
 			s:       "set_field:->arp_spa",
@@ -306,21 +634,309 @@ func Test_parseAction(t *testing.T) {
 			invalid: true,
 		},
 		{
-			s:       "conjunxxxxx(123,3/2)",
+			s: "conjunxxxxx(123,3/2)",
+			a: RawAction("conjunxxxxx(123,3/2)"),
+		},
+		{
+			s:       "conjunction(123)",
+			invalid: true,
+		},
+		{
+			s: "multipath(symmetric_l4,1024,hrw,2,0,NXM_NX_REG0[0..1])",
+			a: Multipath("symmetric_l4", 1024, "hrw", 2, 0, "NXM_NX_REG0[0..1]"),
+		},
+		{
+			s:       "multipath(symmetric_l4,1024,hrw)",
+			invalid: true,
+		},
+		{
+			s: "multipath(symmetric_l4,1024,foo,2,0,NXM_NX_REG0[0..1])",
+			a: RawAction("multipath(symmetric_l4,1024,foo,2,0,NXM_NX_REG0[0..1])"),
+		},
+		{
+			s: "bundle(eth_src,0,active_backup,ofport,members:1,2)",
+			a: Bundle(BundleSpec{
+				Fields:    HashFieldsEthSrc,
+				Algorithm: BundleActiveBackup,
+				Members:   []int{1, 2},
+			}),
+		},
+		{
+			s: "bundle(symmetric_l4,60,hrw,ofport,slaves:4)",
+			a: Bundle(BundleSpec{
+				Fields:    HashFieldsSymmetricL4,
+				Basis:     60,
+				Algorithm: BundleHRW,
+				Members:   []int{4},
+				Slaves:    true,
+			}),
+		},
+		{
+			s: "bundle_load(eth_src,0,hrw,ofport,NXM_NX_REG0[],members:4,8)",
+			a: BundleLoad(BundleSpec{
+				Fields:    HashFieldsEthSrc,
+				Algorithm: BundleHRW,
+				Members:   []int{4, 8},
+			}, "NXM_NX_REG0[]"),
+		},
+		{
+			s: "bundle(eth_src,0,active_backup,ofport,members:)",
+			a: Bundle(BundleSpec{
+				Fields:    HashFieldsEthSrc,
+				Algorithm: BundleActiveBackup,
+			}),
+		},
+		{
+			s: "bundle(eth_src,0,active_backup,ofport,members:eth0,eth1)",
+			a: RawAction("bundle(eth_src,0,active_backup,ofport,members:eth0,eth1)"),
+		},
+		{
+			s: "bundle(eth_src,0,foo,ofport,members:1)",
+			a: RawAction("bundle(eth_src,0,foo,ofport,members:1)"),
+		},
+		{
+			s:       "bundle(eth_src,0,hrw,ofport)",
+			invalid: true,
+		},
+		{
+			s:       "bundle(eth_src,foo,hrw,ofport,members:1)",
+			invalid: true,
+		},
+		{
+			s:       "bundle_load(eth_src,0,hrw,ofport,members:1)",
+			invalid: true,
+		},
+		{
+			s:       "bundle(eth_src,0,hrw,ofport,ports:1)",
+			invalid: true,
+		},
+		{
+			s: "resubmit(,2,ct)",
+			a: RawAction("resubmit(,2,ct)"),
+		},
+		{
+			s: "set_tunnel:0x5",
+			a: SetTunnel(5),
+		},
+		{
+			s:       "set_tunnel:foo",
+			invalid: true,
+		},
+		{
+			s: "group:10",
+			a: OutputGroup(10),
+		},
+		{
+			s: "group:foo",
+			a: RawAction("group:foo"),
+		},
+		{
+			s: "meter:1",
+			a: ApplyMeter(1),
+		},
+		{
+			s: "goto_table:2",
+			a: GotoTable(2),
+		},
+		{
+			s: "goto_table:foo",
+			a: RawAction("goto_table:foo"),
+		},
+		{
+			s:       "goto_table:255",
+			invalid: true,
+		},
+		{
+			s: "write_metadata:0x1",
+			a: WriteMetadata(0x1, 0xffffffffffffffff),
+		},
+		{
+			s: "write_metadata:0x10/0xff",
+			a: WriteMetadata(0x10, 0xff),
+		},
+		{
+			s:       "write_metadata:0x1/foo",
+			invalid: true,
+		},
+		{
+			s: "clear_actions",
+			a: ClearActions(),
+		},
+		{
+			s: "controller",
+			a: Controller(ControllerSpec{}),
+		},
+		{
+			s:     "CONTROLLER:65535",
+			final: "controller:65535",
+			a:     Controller(ControllerSpec{MaxLen: 65535}),
+		},
+		{
+			s:       "controller:foo",
+			invalid: true,
+		},
+		{
+			s: "controller(reason=no_match,max_len=128,id=1,userdata=00.01.02,pause)",
+			a: Controller(ControllerSpec{
+				Reason:   ControllerReasonNoMatch,
+				MaxLen:   128,
+				ID:       1,
+				Userdata: []byte{0x0, 0x1, 0x2},
+				Pause:    true,
+			}),
+		},
+		{
+			s:     "controller(userdata=0001)",
+			final: "controller(userdata=00.01)",
+			a:     Controller(ControllerSpec{Userdata: []byte{0x0, 0x1}}),
+		},
+		{
+			s: "controller(reason=foo)",
+			a: RawAction("controller(reason=foo)"),
+		},
+		{
+			s: "controller(meter_id=1)",
+			a: RawAction("controller(meter_id=1)"),
+		},
+		{
+			s:       "controller(max_len=foo)",
+			invalid: true,
+		},
+		{
+			s:       "controller(userdata=0g)",
 			invalid: true,
 		},
+		{
+			s: "sample(probability=65535,collector_set_id=1,obs_domain_id=2,obs_point_id=3)",
+			a: Sample(SampleSpec{
+				Probability:    65535,
+				CollectorSetID: 1,
+				ObsDomainID:    2,
+				ObsPointID:     3,
+			}),
+		},
+		{
+			s: "sample(probability=1,collector_set_id=1,obs_domain_id=0,obs_point_id=0,sampling_port=2)",
+			a: RawAction("sample(probability=1,collector_set_id=1,obs_domain_id=0,obs_point_id=0,sampling_port=2)"),
+		},
+		{
+			s: "sample(probability=1,collector_set_id=1,obs_domain_id=2,obs_point_id=NXM_NX_REG0[])",
+			a: RawAction("sample(probability=1,collector_set_id=1,obs_domain_id=2,obs_point_id=NXM_NX_REG0[])"),
+		},
+		{
+			s: "sample(probability=1,collector_set_id=1,obs_domain_id=NXM_NX_REG1[0..15],obs_point_id=3)",
+			a: RawAction("sample(probability=1,collector_set_id=1,obs_domain_id=NXM_NX_REG1[0..15],obs_point_id=3)"),
+		},
+		{
+			s:       "sample(probability=0)",
+			invalid: true,
+		},
+		{
+			s:       "sample(probability=65536)",
+			invalid: true,
+		},
+		{
+			s: "note:00.01.02",
+			a: Note([]byte{0x0, 0x1, 0x2}),
+		},
+		{
+			s:       "note:0",
+			invalid: true,
+		},
+		{
+			s: "write_actions(output:1,group:2)",
+			a: WriteActions(Output(1), OutputGroup(2)),
+		},
+		{
+			s: "write_actions()",
+			a: WriteActions(),
+		},
+		{
+			s:       "write_actions(goto_table:1)",
+			invalid: true,
+		},
+		{
+			s:       "write_actions(mod_tp_dst:foo)",
+			invalid: true,
+		},
+		{
+			s: "push_mpls:0x8847",
+			a: PushMPLS(0x8847),
+		},
+		{
+			s:       "push_mpls:0x0800",
+			invalid: true,
+		},
+		{
+			s: "pop_mpls:0x0800",
+			a: PopMPLS(0x0800),
+		},
+		{
+			s:       "pop_mpls:foo",
+			invalid: true,
+		},
+		{
+			s: "set_mpls_label(100)",
+			a: SetMPLSLabel(100),
+		},
+		{
+			s:       "set_mpls_label(foo)",
+			invalid: true,
+		},
+		{
+			s: "encap(nsh)",
+			a: EncapNSH(0),
+		},
+		{
+			s: "encap(nsh(md_type=1))",
+			a: EncapNSH(1),
+		},
+		{
+			s: "encap(nsh(md_type=1,tlv(0x1000,10,0x12345678)))",
+			a: RawAction("encap(nsh(md_type=1,tlv(0x1000,10,0x12345678)))"),
+		},
+		{
+			s: "encap(ethernet)",
+			a: RawAction("encap(ethernet)"),
+		},
+		{
+			s: "decap()",
+			a: Decap(),
+		},
+		{
+			s: "decap(packet_type(ns=0,type=0))",
+			a: RawAction("decap(packet_type(ns=0,type=0))"),
+		},
+		{
+			s: "meter:foo",
+			a: RawAction("meter:foo"),
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.s, func(t *testing.T) {
 			a, err := parseAction(tt.s)
+			if err == nil {
+				// Some actions parse correctly but contain invalid
+				// values which are only detected when marshaled.
+				_, err = a.MarshalText()
+			}
 			if err != nil && !tt.invalid {
 				t.Fatalf("unexpected error: %v", err)
 			}
 			if tt.invalid {
+				if err == nil {
+					t.Fatal("expected an error, but none occurred")
+				}
+
 				return
 			}
 
+			if want, got := tt.a.GoString(), a.GoString(); want != got {
+				t.Fatalf("unexpected Action:\n- want: %s\n-  got: %s",
+					want, got)
+			}
+
 			s, err := a.MarshalText()
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
@@ -0,0 +1,268 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// errBundleAlgorithm is returned when Bundle or BundleLoad is used with
+	// an algorithm which is not known to Open vSwitch.
+	errBundleAlgorithm = errors.New("invalid bundle algorithm")
+
+	// errBundleBasis is returned when Bundle or BundleLoad is used with a
+	// basis which is out of range.
+	errBundleBasis = errors.New("bundle basis must be between 0 and 65535")
+
+	// errBundleMember is returned when Bundle or BundleLoad is used with a
+	// member port which is not positive.
+	errBundleMember = errors.New("bundle member ports must be positive")
+
+	// errBundleLoadDstEmpty is returned when BundleLoad is called with dst
+	// set to the empty string.
+	errBundleLoadDstEmpty = errors.New("dst field for action bundle_load is empty")
+)
+
+// A BundleAlgorithm is a member selection algorithm used by Bundle and
+// BundleLoad.
+type BundleAlgorithm string
+
+// BundleAlgorithm constants which are known to Open vSwitch.
+const (
+	// BundleActiveBackup selects the first live member port, in the order
+	// in which they are specified.
+	BundleActiveBackup BundleAlgorithm = "active_backup"
+
+	// BundleHRW selects a live member port using the highest random weight
+	// of the hash of the packet's fields.
+	BundleHRW BundleAlgorithm = "hrw"
+)
+
+// A BundleSpec configures the bundle actions created by Bundle and
+// BundleLoad.
+type BundleSpec struct {
+	// Fields are hashed with Basis to select a member port.  Fields are
+	// only used by the BundleHRW algorithm, but must always be set.
+	Fields HashFields
+	Basis  int
+
+	// Algorithm is used to select a member port.
+	Algorithm BundleAlgorithm
+
+	// Members are the OpenFlow port numbers from which a port is selected.
+	Members []int
+
+	// Slaves marshals Members using the "slaves" keyword, which is
+	// required by versions of Open vSwitch prior to 2.14, instead of
+	// "members".
+	Slaves bool
+}
+
+// Constants used repeatedly while marshaling and unmarshaling bundle
+// actions.
+const (
+	actionBundle     = "bundle"
+	actionBundleLoad = "bundle_load"
+
+	bundleMemberType = "ofport"
+	bundleMembers    = "members"
+	bundleSlaves     = "slaves"
+)
+
+// Bundle outputs the packet to one of the live ports in spec.Members,
+// selected using spec.Algorithm.
+func Bundle(spec BundleSpec) Action {
+	return &bundleAction{
+		spec: spec,
+	}
+}
+
+// BundleLoad selects one of the live ports in spec.Members, as with Bundle,
+// but stores the selected port number in field dst rather than outputting
+// the packet to it.  If no port is live, 0 is stored.
+func BundleLoad(spec BundleSpec, dst string) Action {
+	return &bundleAction{
+		spec: spec,
+		dst:  dst,
+		load: true,
+	}
+}
+
+// A bundleAction is an Action which is used by Bundle and BundleLoad.
+type bundleAction struct {
+	spec BundleSpec
+	dst  string
+	load bool
+}
+
+// MarshalText implements Action.
+func (a *bundleAction) MarshalText() ([]byte, error) {
+	s := a.spec
+
+	if !validHashFields(s.Fields) {
+		return nil, errHashFieldsInvalid
+	}
+	if !validBundleAlgorithm(s.Algorithm) {
+		return nil, errBundleAlgorithm
+	}
+	if s.Basis < 0 || s.Basis > 0xffff {
+		return nil, errBundleBasis
+	}
+
+	name := actionBundle
+	args := []string{
+		string(s.Fields),
+		strconv.Itoa(s.Basis),
+		string(s.Algorithm),
+		bundleMemberType,
+	}
+
+	if a.load {
+		if a.dst == "" {
+			return nil, errBundleLoadDstEmpty
+		}
+
+		name = actionBundleLoad
+		args = append(args, a.dst)
+	}
+
+	members := make([]string, 0, len(s.Members))
+	for _, m := range s.Members {
+		if m <= 0 {
+			return nil, errBundleMember
+		}
+
+		members = append(members, strconv.Itoa(m))
+	}
+
+	keyword := bundleMembers
+	if s.Slaves {
+		keyword = bundleSlaves
+	}
+	args = append(args, keyword+":"+strings.Join(members, ","))
+
+	return bprintf("%s(%s)", name, strings.Join(args, ",")), nil
+}
+
+// GoString implements Action.
+func (a *bundleAction) GoString() string {
+	s := a.spec
+
+	fields := []string{
+		fmt.Sprintf("Fields: %q", s.Fields),
+	}
+	if s.Basis != 0 {
+		fields = append(fields, fmt.Sprintf("Basis: %d", s.Basis))
+	}
+	fields = append(fields, fmt.Sprintf("Algorithm: %q", s.Algorithm))
+	if len(s.Members) > 0 {
+		fields = append(fields, fmt.Sprintf("Members: %#v", s.Members))
+	}
+	if s.Slaves {
+		fields = append(fields, "Slaves: true")
+	}
+
+	spec := "ovs.BundleSpec{" + strings.Join(fields, ", ") + "}"
+	if a.load {
+		return fmt.Sprintf("ovs.BundleLoad(%s, %q)", spec, a.dst)
+	}
+
+	return fmt.Sprintf("ovs.Bundle(%s)", spec)
+}
+
+// validBundleAlgorithm determines if a BundleAlgorithm is known to
+// Open vSwitch.
+func validBundleAlgorithm(a BundleAlgorithm) bool {
+	switch a {
+	case BundleActiveBackup, BundleHRW:
+		return true
+	}
+
+	return false
+}
+
+// parseBundle parses the arguments of a bundle or bundle_load action of the
+// forms:
+//  bundle(fields,basis,algorithm,ofport,members:port...)
+//  bundle_load(fields,basis,algorithm,ofport,dst,members:port...)
+//
+// Fields, algorithms and member ports which are not supported by Bundle and
+// BundleLoad result in a RawAction.
+func parseBundle(name string, args string, raw string) (Action, error) {
+	ss := strings.Split(args, ",")
+
+	n := 4
+	if name == actionBundleLoad {
+		n = 5
+	}
+	if len(ss) < n+1 {
+		return nil, fmt.Errorf("invalid %s arguments: %q", name, args)
+	}
+
+	basis, err := strconv.Atoi(ss[1])
+	if err != nil {
+		return nil, err
+	}
+
+	spec := BundleSpec{
+		Fields:    HashFields(ss[0]),
+		Basis:     basis,
+		Algorithm: BundleAlgorithm(ss[2]),
+	}
+	if !validHashFields(spec.Fields) || !validBundleAlgorithm(spec.Algorithm) ||
+		ss[3] != bundleMemberType {
+		return RawAction(raw), nil
+	}
+
+	kv := strings.SplitN(ss[n], ":", 2)
+	if len(kv) != 2 {
+		return nil, fmt.Errorf("invalid %s members: %q", name, ss[n])
+	}
+
+	switch kv[0] {
+	case bundleMembers:
+	case bundleSlaves:
+		spec.Slaves = true
+	default:
+		return nil, fmt.Errorf("invalid %s members: %q", name, ss[n])
+	}
+
+	// The first member follows the keyword, and the rest are the remaining
+	// comma-separated arguments.
+	members := append([]string{kv[1]}, ss[n+1:]...)
+	if len(members) == 1 && members[0] == "" {
+		members = nil
+	}
+
+	for _, m := range members {
+		port, err := strconv.Atoi(m)
+		if err != nil || port <= 0 {
+			// Named ports are not supported by Bundle.
+			return RawAction(raw), nil
+		}
+
+		spec.Members = append(spec.Members, port)
+	}
+
+	if name == actionBundleLoad {
+		return BundleLoad(spec, ss[4]), nil
+	}
+
+	return Bundle(spec), nil
+}
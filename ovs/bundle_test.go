@@ -0,0 +1,131 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"testing"
+)
+
+func TestBundle(t *testing.T) {
+	var tests = []struct {
+		desc   string
+		a      Action
+		action string
+		err    error
+	}{
+		{
+			desc: "bundle active_backup",
+			a: Bundle(BundleSpec{
+				Fields:    HashFieldsEthSrc,
+				Algorithm: BundleActiveBackup,
+				Members:   []int{1, 2},
+			}),
+			action: "bundle(eth_src,0,active_backup,ofport,members:1,2)",
+		},
+		{
+			desc: "bundle hrw with slaves",
+			a: Bundle(BundleSpec{
+				Fields:    HashFieldsSymmetricL3L4UDP,
+				Basis:     60,
+				Algorithm: BundleHRW,
+				Members:   []int{4, 8},
+				Slaves:    true,
+			}),
+			action: "bundle(symmetric_l3l4+udp,60,hrw,ofport,slaves:4,8)",
+		},
+		{
+			desc: "bundle_load",
+			a: BundleLoad(BundleSpec{
+				Fields:    HashFieldsNetworkSource,
+				Algorithm: BundleHRW,
+				Members:   []int{4},
+			}, "NXM_NX_REG0[]"),
+			action: "bundle_load(nw_src,0,hrw,ofport,NXM_NX_REG0[],members:4)",
+		},
+		{
+			desc: "bundle no members",
+			a: Bundle(BundleSpec{
+				Fields:    HashFieldsEthSrc,
+				Algorithm: BundleActiveBackup,
+			}),
+			action: "bundle(eth_src,0,active_backup,ofport,members:)",
+		},
+		{
+			desc: "unknown fields",
+			a: Bundle(BundleSpec{
+				Fields:    "foo",
+				Algorithm: BundleHRW,
+				Members:   []int{1},
+			}),
+			err: errHashFieldsInvalid,
+		},
+		{
+			desc: "unknown algorithm",
+			a: Bundle(BundleSpec{
+				Fields:    HashFieldsEthSrc,
+				Algorithm: "modulo_n",
+				Members:   []int{1},
+			}),
+			err: errBundleAlgorithm,
+		},
+		{
+			desc: "basis out of range",
+			a: Bundle(BundleSpec{
+				Fields:    HashFieldsEthSrc,
+				Basis:     -1,
+				Algorithm: BundleHRW,
+				Members:   []int{1},
+			}),
+			err: errBundleBasis,
+		},
+		{
+			desc: "member port zero",
+			a: Bundle(BundleSpec{
+				Fields:    HashFieldsEthSrc,
+				Algorithm: BundleHRW,
+				Members:   []int{1, 0},
+			}),
+			err: errBundleMember,
+		},
+		{
+			desc: "bundle_load empty dst",
+			a: BundleLoad(BundleSpec{
+				Fields:    HashFieldsEthSrc,
+				Algorithm: BundleHRW,
+				Members:   []int{1},
+			}, ""),
+			err: errBundleLoadDstEmpty,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			action, err := tt.a.MarshalText()
+
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if err != nil {
+				return
+			}
+
+			if want, got := tt.action, string(action); want != got {
+				t.Fatalf("unexpected Action:\n- want: %q\n-  got: %q",
+					want, got)
+			}
+		})
+	}
+}
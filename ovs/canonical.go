@@ -0,0 +1,626 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CanonicalText marshals a Flow into a canonical textual form, in which
+// equivalent flows have identical text regardless of how their matches are
+// specified.  In the canonical form:
+//   - protocols such as "tcp" are expanded into dl_type and nw_proto matches
+//   - aliases such as "tcp_dst" and "eth_type" use a single field name
+//   - integers are formatted in decimal, or in hexadecimal for Ethernet
+//     types and values with masks, with masks applied to values, and masks
+//     with all bits of the field set are removed
+//   - IP addresses are formatted in CIDR notation, with masks applied
+//   - MAC addresses are formatted in lower case, with masks applied
+//   - flags such as "+trk+est" in ct_state are sorted
+//   - fields are sorted, and repeated fields are removed
+//
+// Actions remain in their original order.  Actions which set an entire field,
+// such as "load:0x1->NXM_NX_REG0[]" and "mod_nw_dst:192.0.2.1", are expressed
+// as set_field actions with short field names and values in the canonical
+// form of matches, such as "set_field:1->reg0".  Other actions are marshaled
+// as they are by MarshalText.  The canonical text can be unmarshaled into a
+// Flow using UnmarshalText.
+func (f *Flow) CanonicalText() ([]byte, error) {
+	fields, actions, err := f.canonicalFields()
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(strings.Join(fields, ",") + "," + keyActions + "=" + actions), nil
+}
+
+// Key returns a string which identifies a Flow within a bridge, consisting of
+// its table, priority, and matches in canonical form.  As with OpenFlow, two
+// flows with the same key cannot both be installed on a bridge, so adding a
+// flow replaces any flow with the same key.  Key can be used to store flows
+// in a map, or to compare flows dumped from a bridge with desired flows.
+func (f *Flow) Key() (string, error) {
+	fields, _, err := f.canonicalFields()
+	if err != nil {
+		return "", err
+	}
+
+	key := make([]string, 0, len(fields))
+	for _, s := range fields {
+		k := strings.SplitN(s, "=", 2)[0]
+		switch k {
+		case cookie, idleTimeout, hardTimeout, importance:
+			continue
+		}
+		if _, ok := flowFlags[FlowFlag(k)]; ok {
+			continue
+		}
+
+		key = append(key, s)
+	}
+
+	return strings.Join(key, ","), nil
+}
+
+// Equal determines if two Flows are semantically equal, meaning that their
+// canonical textual forms are identical.  Flow statistics are ignored.  Flows
+// which cannot be marshaled are never equal.
+func (f *Flow) Equal(g *Flow) bool {
+	if f == nil || g == nil {
+		return f == g
+	}
+
+	fb, err := f.CanonicalText()
+	if err != nil {
+		return false
+	}
+	gb, err := g.CanonicalText()
+	if err != nil {
+		return false
+	}
+
+	return string(fb) == string(gb)
+}
+
+// canonicalFields marshals a Flow and returns its fields other than actions
+// in canonical form, and its actions.
+func (f *Flow) canonicalFields() ([]string, string, error) {
+	b, err := f.MarshalText()
+	if err != nil {
+		return nil, "", err
+	}
+
+	s := string(b)
+	i := strings.Index(s, ","+keyActions+"=")
+	if i == -1 {
+		return nil, "", &FlowError{
+			Err: errNoActions,
+		}
+	}
+
+	return canonicalFields(s[:i]), canonicalActions(s[i+len(keyActions)+2:]), nil
+}
+
+// CanonicalText marshals a MatchFlow into a canonical textual form, in which
+// equivalent flows have identical text.  See Flow.CanonicalText for details
+// of the canonical form.
+func (f *MatchFlow) CanonicalText() ([]byte, error) {
+	b, err := f.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(strings.Join(canonicalFields(string(b)), ",")), nil
+}
+
+// Key returns a string which identifies a MatchFlow, consisting of all of its
+// fields in canonical form, including its priority.  Key can be used to store
+// MatchFlows in a map.
+func (f *MatchFlow) Key() (string, error) {
+	b, err := f.marshalText(true)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(canonicalFields(string(b)), ","), nil
+}
+
+// Equal determines if two MatchFlows are semantically equal, meaning that
+// their keys are identical.  MatchFlows which cannot be marshaled are never
+// equal.
+func (f *MatchFlow) Equal(g *MatchFlow) bool {
+	if f == nil || g == nil {
+		return f == g
+	}
+
+	fk, err := f.Key()
+	if err != nil {
+		return false
+	}
+	gk, err := g.Key()
+	if err != nil {
+		return false
+	}
+
+	return fk == gk
+}
+
+// protocolFields are the fields implied by each protocol which can be
+// specified in a flow.
+var protocolFields = map[Protocol][]string{
+	ProtocolARP:    {"dl_type=0x0806"},
+	ProtocolICMPv4: {"dl_type=0x0800", "nw_proto=1"},
+	ProtocolICMPv6: {"dl_type=0x86dd", "nw_proto=58"},
+	ProtocolIPv4:   {"dl_type=0x0800"},
+	ProtocolIPv6:   {"dl_type=0x86dd"},
+	ProtocolTCPv4:  {"dl_type=0x0800", "nw_proto=6"},
+	ProtocolTCPv6:  {"dl_type=0x86dd", "nw_proto=6"},
+	ProtocolUDPv4:  {"dl_type=0x0800", "nw_proto=17"},
+	ProtocolUDPv6:  {"dl_type=0x86dd", "nw_proto=17"},
+	"sctp":         {"dl_type=0x0800", "nw_proto=132"},
+	"sctp6":        {"dl_type=0x86dd", "nw_proto=132"},
+	"rarp":         {"dl_type=0x8035"},
+	"mpls":         {"dl_type=0x8847"},
+	"mplsm":        {"dl_type=0x8848"},
+}
+
+// fieldAliases maps alternative names of fields to the names used in
+// canonical form.
+var fieldAliases = map[string]string{
+	"eth_src":     dlSRC,
+	"eth_dst":     dlDST,
+	"eth_type":    dlType,
+	"vlan_pcp":    dlVLANPCP,
+	"ip_src":      nwSRC,
+	"ip_dst":      nwDST,
+	"ip_proto":    nwProto,
+	"ip_ecn":      nwECN,
+	"ip_ttl":      nwTTL,
+	"tcp_src":     tpSRC,
+	"tcp_dst":     tpDST,
+	udpSRC:        tpSRC,
+	udpDST:        tpDST,
+	"sctp_src":    tpSRC,
+	"sctp_dst":    tpDST,
+	"icmpv4_type": icmpType,
+	"icmpv4_code": icmpCode,
+	"tunnel_id":   tunID,
+}
+
+// canonicalFields splits the text of a flow, not including its actions, into
+// fields, and returns them in canonical form.
+func canonicalFields(s string) []string {
+	var fields []string
+	for _, f := range splitFields(s) {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+
+		// Expand protocols into the fields they imply.
+		if pf, ok := protocolFields[Protocol(f)]; ok {
+			fields = append(fields, pf...)
+			continue
+		}
+
+		fields = append(fields, f)
+	}
+
+	seen := make(map[string]struct{}, len(fields))
+	out := make([]string, 0, len(fields))
+	for _, f := range fields {
+		f = canonicalField(f)
+		if _, ok := seen[f]; ok {
+			continue
+		}
+
+		seen[f] = struct{}{}
+		out = append(out, f)
+	}
+
+	sort.Strings(out)
+	return out
+}
+
+// canonicalField returns a single key/value field in canonical form.  Fields
+// without values, such as flow flags, are returned unchanged.
+func canonicalField(s string) string {
+	kv := strings.SplitN(s, "=", 2)
+	if len(kv) != 2 {
+		return s
+	}
+
+	k, v := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+	if alias, ok := fieldAliases[k]; ok {
+		k = alias
+	}
+
+	switch k {
+	case nwSRC, nwDST, arpSPA, arpTPA, ctNwSRC, ctNwDST, tunSRC, tunDST,
+		ipv6SRC, ipv6DST, ndTarget, ctIPv6SRC, ctIPv6DST, tunv6SRC, tunv6DST:
+		v = canonicalIP(v)
+	case dlSRC, dlDST, arpSHA, arpTHA, ndSLL, ndTLL:
+		v = canonicalMAC(v)
+	case ctState, tcpFlags, tunFlags:
+		v = canonicalFlags(v)
+	}
+
+	// Tunnel metadata are byte strings rather than integers.
+	if strings.HasPrefix(k, tunMetadata) {
+		return k + "=" + v
+	}
+
+	return k + "=" + canonicalInteger(k, v)
+}
+
+// canonicalIP returns an IPv4 or IPv6 address, with an optional prefix length
+// or mask, in canonical form.  Values which are not IP addresses are returned
+// unchanged.
+func canonicalIP(s string) string {
+	addr, mask := splitMask(s)
+
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return s
+	}
+
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil && !strings.Contains(addr, ":") {
+		ip = ip4
+		bits = 8 * net.IPv4len
+	}
+
+	if mask == "" {
+		return ip.String()
+	}
+
+	var m net.IPMask
+	if n, err := strconv.Atoi(mask); err == nil {
+		if n < 0 || n > bits {
+			return s
+		}
+
+		m = net.CIDRMask(n, bits)
+	} else {
+		mip := net.ParseIP(mask)
+		if mip == nil {
+			return s
+		}
+		if bits == 8*net.IPv4len {
+			mip = mip.To4()
+			if mip == nil {
+				return s
+			}
+		}
+
+		m = net.IPMask(mip)
+	}
+
+	ip = ip.Mask(m)
+
+	ones, size := m.Size()
+	switch {
+	case size == 0:
+		// A non-contiguous mask can only be expressed as an address.
+		return ip.String() + "/" + net.IP(m).String()
+	case ones == bits:
+		return ip.String()
+	default:
+		return fmt.Sprintf("%s/%d", ip, ones)
+	}
+}
+
+// canonicalMAC returns a MAC address, with an optional mask, in canonical
+// form.  Values which are not MAC addresses are returned unchanged.
+func canonicalMAC(s string) string {
+	addr, mask := splitMask(s)
+
+	hw, err := net.ParseMAC(addr)
+	if err != nil || len(hw) != ethernetAddrLen {
+		return s
+	}
+
+	if mask == "" {
+		return hw.String()
+	}
+
+	m, err := net.ParseMAC(mask)
+	if err != nil || len(m) != ethernetAddrLen {
+		return s
+	}
+
+	allOnes := true
+	for i := range hw {
+		hw[i] &= m[i]
+		if m[i] != 0xff {
+			allOnes = false
+		}
+	}
+
+	if allOnes {
+		return hw.String()
+	}
+
+	return hw.String() + "/" + m.String()
+}
+
+// canonicalFlags returns flags of the form "+flag-flag" in canonical form,
+// with set flags sorted before unset flags.  Values which are not of that
+// form are returned unchanged.
+func canonicalFlags(s string) string {
+	if s == "" || (s[0] != '+' && s[0] != '-') {
+		return s
+	}
+
+	var flags []string
+	start := 0
+	for i := 1; i <= len(s); i++ {
+		if i == len(s) || s[i] == '+' || s[i] == '-' {
+			flags = append(flags, s[start:i])
+			start = i
+		}
+	}
+
+	sort.Strings(flags)
+	return strings.Join(flags, "")
+}
+
+// canonicalInteger returns an integer for field key, with an optional mask,
+// in canonical form, with the mask applied to the value.  Values which are
+// not integers are returned unchanged.
+//
+// The formats used are those accepted when unmarshaling each field:
+// Ethernet types and masked values are hexadecimal, and all other values
+// are decimal.
+func canonicalInteger(key string, s string) string {
+	value, mask := splitMask(s)
+	if mask != "" && fullMask(key, mask) {
+		// The mask matches the entire field, so the value is matched
+		// exactly.
+		s, mask = value, ""
+	}
+
+	v, err := strconv.ParseUint(value, 0, 64)
+	if err != nil {
+		return s
+	}
+
+	switch mask {
+	case "":
+		if key == dlType {
+			return fmt.Sprintf("%#04x", v)
+		}
+
+		return strconv.FormatUint(v, 10)
+	case "-1":
+		// An exact match, as used for cookies.
+		return strconv.FormatUint(v, 10) + "/" + mask
+	}
+
+	m, err := strconv.ParseUint(mask, 0, 64)
+	if err != nil {
+		return s
+	}
+
+	return fmt.Sprintf("%#x/%#x", v&m, m)
+}
+
+// splitMask splits a value of the form "value/mask".  mask is empty if no
+// mask is present.
+func splitMask(s string) (string, string) {
+	i := strings.IndexByte(s, '/')
+	if i == -1 {
+		return s, ""
+	}
+
+	return s[:i], s[i+1:]
+}
+
+// fieldWidths contains the number of bits in integer fields which may be
+// masked, other than registers and NSH context fields.
+var fieldWidths = map[string]int{
+	ctLabel:    128,
+	ctMark:     32,
+	ctTpDST:    16,
+	ctTpSRC:    16,
+	ipv6Label:  20,
+	metadata:   64,
+	"pkt_mark": 32,
+	tpDST:      16,
+	tpSRC:      16,
+	tunID:      64,
+	vlanTCI1:   16,
+	vlanTCI:    16,
+}
+
+// fieldWidth returns the number of bits in an integer field which may be
+// masked, or zero if the field is not known.
+func fieldWidth(key string) int {
+	if bits, ok := fieldWidths[key]; ok {
+		return bits
+	}
+
+	for _, rk := range registerKinds {
+		if !strings.HasPrefix(key, rk.name) {
+			continue
+		}
+
+		n, err := strconv.Atoi(key[len(rk.name):])
+		if err == nil && n >= 0 && n < rk.count {
+			return rk.bits
+		}
+	}
+
+	if strings.HasPrefix(key, nshContext) {
+		n, err := strconv.Atoi(key[len(nshContext):])
+		if err == nil && n >= 1 && n <= 4 {
+			return 32
+		}
+	}
+
+	return 0
+}
+
+// fullMask determines if mask has all of the bits of field key set.
+func fullMask(key string, mask string) bool {
+	bits := fieldWidth(key)
+	if bits == 0 {
+		return false
+	}
+
+	m, ok := new(big.Int).SetString(mask, 0)
+	if !ok {
+		return false
+	}
+
+	full := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	return m.Cmp(full.Sub(full, big.NewInt(1))) == 0
+}
+
+// nxmFields maps the NXM and OXM names of fields, as used in subfields, to
+// their short names.  Registers are handled using registerKinds.
+var nxmFields = map[string]string{
+	"NXM_OF_IN_PORT":      inPort,
+	"NXM_OF_ETH_DST":      dlDST,
+	"NXM_OF_ETH_SRC":      dlSRC,
+	"NXM_OF_ETH_TYPE":     dlType,
+	"NXM_OF_VLAN_TCI":     vlanTCI,
+	"NXM_OF_IP_TOS":       nwTOS,
+	"NXM_OF_IP_PROTO":     nwProto,
+	"NXM_OF_IP_SRC":       nwSRC,
+	"NXM_OF_IP_DST":       nwDST,
+	"NXM_OF_TCP_SRC":      tpSRC,
+	"NXM_OF_TCP_DST":      tpDST,
+	"NXM_OF_UDP_SRC":      tpSRC,
+	"NXM_OF_UDP_DST":      tpDST,
+	"NXM_OF_ARP_OP":       arpOp,
+	"NXM_OF_ARP_SPA":      arpSPA,
+	"NXM_OF_ARP_TPA":      arpTPA,
+	"NXM_NX_ARP_SHA":      arpSHA,
+	"NXM_NX_ARP_THA":      arpTHA,
+	"NXM_NX_IPV6_SRC":     ipv6SRC,
+	"NXM_NX_IPV6_DST":     ipv6DST,
+	"NXM_NX_IP_ECN":       nwECN,
+	"NXM_NX_IP_TTL":       nwTTL,
+	"NXM_NX_TUN_ID":       tunID,
+	"NXM_NX_TUN_IPV4_SRC": tunSRC,
+	"NXM_NX_TUN_IPV4_DST": tunDST,
+	"NXM_NX_PKT_MARK":     "pkt_mark",
+	"NXM_NX_CT_MARK":      ctMark,
+	"NXM_NX_CT_LABEL":     ctLabel,
+	"OXM_OF_METADATA":     metadata,
+	"OXM_OF_ETH_DST":      dlDST,
+	"OXM_OF_ETH_SRC":      dlSRC,
+	"OXM_OF_IPV4_SRC":     nwSRC,
+	"OXM_OF_IPV4_DST":     nwDST,
+	"OXM_OF_TUNNEL_ID":    tunID,
+}
+
+// modFields maps actions which set an entire field to the field they set.
+var modFields = map[string]string{
+	"mod_dl_dst":  dlDST,
+	"mod_dl_src":  dlSRC,
+	"mod_nw_dst":  nwDST,
+	"mod_nw_src":  nwSRC,
+	"mod_tp_dst":  tpDST,
+	"mod_tp_src":  tpSRC,
+	modNetworkTOS: nwTOS,
+	modNetworkECN: nwECN,
+	modNetworkTTL: nwTTL,
+}
+
+// shortFieldName returns the short name of a field which may be specified
+// using its NXM or OXM name.  It returns false if the field is not known.
+func shortFieldName(name string) (string, bool) {
+	if short, ok := nxmFields[name]; ok {
+		return short, true
+	}
+
+	for _, rk := range registerKinds {
+		if !strings.HasPrefix(name, rk.field) {
+			continue
+		}
+
+		n, err := strconv.Atoi(name[len(rk.field):])
+		if err == nil && n >= 0 && n < rk.count {
+			return rk.name + strconv.Itoa(n), true
+		}
+	}
+
+	return "", false
+}
+
+// canonicalActions returns the text of a flow's actions in canonical form.
+func canonicalActions(s string) string {
+	actions := splitFields(s)
+	for i, a := range actions {
+		actions[i] = canonicalAction(a)
+	}
+
+	return strings.Join(actions, ",")
+}
+
+// canonicalAction returns a single action in canonical form.  Actions which
+// set an entire field are expressed as set_field actions, and all other
+// actions are returned unchanged.
+func canonicalAction(s string) string {
+	ss := strings.SplitN(s, ":", 2)
+	if len(ss) != 2 {
+		return s
+	}
+	name, args := ss[0], ss[1]
+
+	if field, ok := modFields[name]; ok {
+		return canonicalSetField(args, field)
+	}
+
+	if name != "load" && name != "set_field" {
+		return s
+	}
+
+	i := strings.Index(args, "->")
+	if i == -1 {
+		return s
+	}
+	value, field := args[:i], args[i+2:]
+
+	if name == "load" {
+		// Only loads of an entire field are equivalent to set_field.
+		if !strings.HasSuffix(field, "[]") {
+			return s
+		}
+		field = strings.TrimSuffix(field, "[]")
+	}
+
+	if short, ok := shortFieldName(field); ok {
+		field = short
+	} else if name == "load" {
+		return s
+	}
+
+	return canonicalSetField(value, field)
+}
+
+// canonicalSetField returns a set_field action for value and field in
+// canonical form.
+func canonicalSetField(value string, field string) string {
+	kv := strings.SplitN(canonicalField(field+"="+value), "=", 2)
+	return "set_field:" + kv[1] + "->" + kv[0]
+}
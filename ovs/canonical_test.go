@@ -0,0 +1,432 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"net"
+	"testing"
+)
+
+func Test_canonicalField(t *testing.T) {
+	var tests = []struct {
+		desc string
+		s    string
+		want string
+	}{
+		{
+			desc: "flag",
+			s:    "send_flow_rem",
+			want: "send_flow_rem",
+		},
+		{
+			desc: "hexadecimal integer",
+			s:    "dl_type=0x0800",
+			want: "dl_type=0x0800",
+		},
+		{
+			desc: "masked integer",
+			s:    "metadata=0x1f/0xf0",
+			want: "metadata=0x10/0xf0",
+		},
+		{
+			desc: "register with full mask",
+			s:    "reg0=0x1/0xffffffff",
+			want: "reg0=1",
+		},
+		{
+			desc: "register with partial mask",
+			s:    "reg0=0x1/0xffff",
+			want: "reg0=0x1/0xffff",
+		},
+		{
+			desc: "128-bit register with full mask",
+			s:    "xxreg1=0x1/0xffffffffffffffffffffffffffffffff",
+			want: "xxreg1=1",
+		},
+		{
+			desc: "metadata with full mask",
+			s:    "metadata=0x10/0xffffffffffffffff",
+			want: "metadata=16",
+		},
+		{
+			desc: "alias",
+			s:    "tcp_dst=0x50",
+			want: "tp_dst=80",
+		},
+		{
+			desc: "IPv4 address",
+			s:    "nw_src=192.0.2.1",
+			want: "nw_src=192.0.2.1",
+		},
+		{
+			desc: "IPv4 host prefix",
+			s:    "ip_src=192.0.2.1/32",
+			want: "nw_src=192.0.2.1",
+		},
+		{
+			desc: "IPv4 prefix with host bits",
+			s:    "nw_dst=192.0.2.1/24",
+			want: "nw_dst=192.0.2.0/24",
+		},
+		{
+			desc: "IPv4 netmask",
+			s:    "arp_tpa=192.0.2.1/255.255.255.0",
+			want: "arp_tpa=192.0.2.0/24",
+		},
+		{
+			desc: "IPv4 non-contiguous netmask",
+			s:    "nw_src=192.0.2.1/255.0.255.0",
+			want: "nw_src=192.0.2.0/255.0.255.0",
+		},
+		{
+			desc: "IPv6 prefix",
+			s:    "ipv6_src=2001:DB8:0:0::1/64",
+			want: "ipv6_src=2001:db8::/64",
+		},
+		{
+			desc: "IPv6 host prefix",
+			s:    "ipv6_dst=2001:db8::1/128",
+			want: "ipv6_dst=2001:db8::1",
+		},
+		{
+			desc: "MAC address",
+			s:    "eth_src=DE:AD:BE:EF:00:01",
+			want: "dl_src=de:ad:be:ef:00:01",
+		},
+		{
+			desc: "MAC address with all ones mask",
+			s:    "dl_dst=de:ad:be:ef:00:01/ff:ff:ff:ff:ff:ff",
+			want: "dl_dst=de:ad:be:ef:00:01",
+		},
+		{
+			desc: "MAC address with mask",
+			s:    "dl_dst=01:00:5E:00:00:01/FF:FF:FF:00:00:00",
+			want: "dl_dst=01:00:5e:00:00:00/ff:ff:ff:00:00:00",
+		},
+		{
+			desc: "flags",
+			s:    "ct_state=-new+trk+est",
+			want: "ct_state=+est+trk-new",
+		},
+		{
+			desc: "numeric flags",
+			s:    "ct_state=0x22/0x22",
+			want: "ct_state=0x22/0x22",
+		},
+		{
+			desc: "unknown value",
+			s:    "ip_frag=no",
+			want: "ip_frag=no",
+		},
+		{
+			desc: "invalid IP address",
+			s:    "nw_src=foo/24",
+			want: "nw_src=foo/24",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if want, got := tt.want, canonicalField(tt.s); want != got {
+				t.Fatalf("unexpected canonical field:\n- want: %q\n-  got: %q",
+					want, got)
+			}
+		})
+	}
+}
+
+func TestFlowCanonicalText(t *testing.T) {
+	f := &Flow{
+		Priority: 100,
+		Protocol: ProtocolTCPv4,
+		InPort:   1,
+		Matches: []Match{
+			TransportDestinationPort(80),
+			NetworkSource("192.0.2.1/24"),
+		},
+		Table:       1,
+		IdleTimeout: 30,
+		Cookie:      0x10,
+		Flags:       []FlowFlag{FlowFlagSendFlowRemoved},
+		Actions:     []Action{Output(2), Load("0x1", "NXM_NX_REG0[]"), Output(3)},
+	}
+
+	want := "cookie=16,dl_type=0x0800,idle_timeout=30,in_port=1,nw_proto=6,nw_src=192.0.2.0/24,priority=100,send_flow_rem,table=1,tp_dst=80,actions=output:2,set_field:1->reg0,output:3"
+
+	b, err := f.CanonicalText()
+	if err != nil {
+		t.Fatalf("failed to marshal canonical text: %v", err)
+	}
+
+	if got := string(b); want != got {
+		t.Fatalf("unexpected canonical text:\n- want: %q\n-  got: %q",
+			want, got)
+	}
+
+	// The canonical text must be usable as a Flow.
+	g := new(Flow)
+	if err := g.UnmarshalText(b); err != nil {
+		t.Fatalf("failed to unmarshal canonical text: %v", err)
+	}
+	if !f.Equal(g) {
+		t.Fatal("flow unmarshaled from canonical text is not equal")
+	}
+
+	key, err := f.Key()
+	if err != nil {
+		t.Fatalf("failed to get key: %v", err)
+	}
+
+	wantKey := "dl_type=0x0800,in_port=1,nw_proto=6,nw_src=192.0.2.0/24,priority=100,table=1,tp_dst=80"
+	if got := key; wantKey != got {
+		t.Fatalf("unexpected key:\n- want: %q\n-  got: %q",
+			wantKey, got)
+	}
+}
+
+func TestFlowEqual(t *testing.T) {
+	desired := &Flow{
+		Priority: 100,
+		Protocol: ProtocolTCPv4,
+		InPort:   1,
+		Matches: []Match{
+			NetworkSource("192.0.2.1/24"),
+			TransportDestinationPort(80),
+			ConnectionTrackingState(SetState(CTStateTracked), SetState(CTStateEstablished)),
+		},
+		Table:   0,
+		Actions: []Action{Output(2)},
+	}
+
+	var tests = []struct {
+		desc  string
+		s     string
+		equal bool
+		key   bool
+	}{
+		{
+			desc:  "equal",
+			s:     "priority=100,ct_state=+est+trk,tcp,tcp_dst=0x50,in_port=1,nw_src=192.0.2.1/24 actions=output:2",
+			equal: true,
+			key:   true,
+		},
+		{
+			desc:  "explicit ethernet type and protocol",
+			s:     " cookie=0x0, duration=1.5s, table=0, n_packets=10, n_bytes=1000, idle_age=1, priority=100,dl_type=0x0800,nw_proto=6,ct_state=+trk+est,in_port=1,nw_src=192.0.2.0/24,tp_dst=80 actions=output:2",
+			equal: true,
+			key:   true,
+		},
+		{
+			desc: "different actions",
+			s:    "priority=100,ct_state=+est+trk,tcp,tp_dst=80,in_port=1,nw_src=192.0.2.0/24 actions=output:3",
+			key:  true,
+		},
+		{
+			desc: "different cookie",
+			s:    "cookie=0x1,priority=100,ct_state=+est+trk,tcp,tp_dst=80,in_port=1,nw_src=192.0.2.0/24 actions=output:2",
+			key:  true,
+		},
+		{
+			desc: "different priority",
+			s:    "priority=200,ct_state=+est+trk,tcp,tp_dst=80,in_port=1,nw_src=192.0.2.0/24 actions=output:2",
+		},
+		{
+			desc: "different protocol",
+			s:    "priority=100,ct_state=+est+trk,udp,tp_dst=80,in_port=1,nw_src=192.0.2.0/24 actions=output:2",
+		},
+		{
+			desc: "different prefix",
+			s:    "priority=100,ct_state=+est+trk,tcp,tp_dst=80,in_port=1,nw_src=192.0.2.0/25 actions=output:2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			f := new(Flow)
+			if err := f.UnmarshalText([]byte(tt.s)); err != nil {
+				t.Fatalf("failed to unmarshal flow: %v", err)
+			}
+
+			if want, got := tt.equal, desired.Equal(f); want != got {
+				t.Fatalf("unexpected flow equality:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+
+			dk, err := desired.Key()
+			if err != nil {
+				t.Fatalf("failed to get key: %v", err)
+			}
+			fk, err := f.Key()
+			if err != nil {
+				t.Fatalf("failed to get key: %v", err)
+			}
+
+			if want, got := tt.key, dk == fk; want != got {
+				t.Fatalf("unexpected key equality:\n- want: %v\n-  got: %v\n- desired: %s\n-  flow: %s",
+					want, got, dk, fk)
+			}
+		})
+	}
+}
+
+func TestFlowEqualEquivalentForms(t *testing.T) {
+	var tests = []struct {
+		desc  string
+		f     *Flow
+		s     string
+		equal bool
+	}{
+		{
+			desc: "load of entire field and set_field",
+			f: &Flow{
+				Actions: []Action{Load("0x1", "NXM_NX_REG0[]")},
+			},
+			s:     "priority=0 actions=set_field:0x1->reg0",
+			equal: true,
+		},
+		{
+			desc: "load of part of field and set_field",
+			f: &Flow{
+				Actions: []Action{Load("0x1", "NXM_NX_REG0[0..7]")},
+			},
+			s: "priority=0 actions=set_field:0x1->reg0",
+		},
+		{
+			desc: "set_field and mod_nw_dst",
+			f: &Flow{
+				Protocol: ProtocolIPv4,
+				Actions:  []Action{SetField("192.0.2.1", "nw_dst")},
+			},
+			s:     "priority=0,ip actions=mod_nw_dst:192.0.2.1",
+			equal: true,
+		},
+		{
+			desc: "mod_dl_src and set_field with alias",
+			f: &Flow{
+				Actions: []Action{ModDataLinkSource(net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55})},
+			},
+			s:     "priority=0 actions=set_field:00:11:22:33:44:55->eth_src",
+			equal: true,
+		},
+		{
+			desc: "set_field with different value",
+			f: &Flow{
+				Actions: []Action{Load("0x1", "NXM_NX_REG0[]")},
+			},
+			s: "priority=0 actions=set_field:0x2->reg0",
+		},
+		{
+			desc: "match with full mask",
+			f: &Flow{
+				Matches: []Match{RegMatchWithMask(0, 0x1, 0xffffffff)},
+				Actions: []Action{Drop()},
+			},
+			s:     "priority=0,reg0=0x1 actions=drop",
+			equal: true,
+		},
+		{
+			desc: "match with partial mask",
+			f: &Flow{
+				Matches: []Match{RegMatchWithMask(0, 0x1, 0xff)},
+				Actions: []Action{Drop()},
+			},
+			s: "priority=0,reg0=0x1 actions=drop",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			g := new(Flow)
+			if err := g.UnmarshalText([]byte(tt.s)); err != nil {
+				t.Fatalf("failed to unmarshal flow: %v", err)
+			}
+
+			if want, got := tt.equal, tt.f.Equal(g); want != got {
+				t.Fatalf("unexpected flow equality:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+func TestFlowEqualInvalid(t *testing.T) {
+	var nilFlow *Flow
+	if !nilFlow.Equal(nil) {
+		t.Fatal("nil flows should be equal")
+	}
+
+	f := &Flow{Actions: []Action{Output(1)}}
+	if f.Equal(nil) {
+		t.Fatal("flow should not equal nil flow")
+	}
+
+	// Flows without actions cannot be marshaled.
+	g := &Flow{}
+	if g.Equal(g) {
+		t.Fatal("flow without actions should not be equal")
+	}
+}
+
+func TestMatchFlowKey(t *testing.T) {
+	f := &MatchFlow{
+		Protocol: ProtocolIPv4,
+		Matches: []Match{
+			NetworkDestination("192.0.2.1/24"),
+		},
+		Priority: 10,
+		Cookie:   0x10,
+		Table:    AnyTable,
+	}
+	g := &MatchFlow{
+		Matches: []Match{
+			FieldMatch("ip_dst", "192.0.2.0/255.255.255.0"),
+			DataLinkType(0x0800),
+		},
+		Priority: 10,
+		Cookie:   0x10,
+		Table:    AnyTable,
+	}
+
+	b, err := f.CanonicalText()
+	if err != nil {
+		t.Fatalf("failed to marshal canonical text: %v", err)
+	}
+
+	want := "cookie=16/-1,dl_type=0x0800,nw_dst=192.0.2.0/24"
+	if got := string(b); want != got {
+		t.Fatalf("unexpected canonical text:\n- want: %q\n-  got: %q",
+			want, got)
+	}
+
+	key, err := f.Key()
+	if err != nil {
+		t.Fatalf("failed to get key: %v", err)
+	}
+
+	wantKey := "cookie=16/-1,dl_type=0x0800,nw_dst=192.0.2.0/24,priority=10"
+	if got := key; wantKey != got {
+		t.Fatalf("unexpected key:\n- want: %q\n-  got: %q",
+			wantKey, got)
+	}
+
+	if !f.Equal(g) {
+		t.Fatal("match flows should be equal")
+	}
+
+	g.Priority = 20
+	if f.Equal(g) {
+		t.Fatal("match flows with different priorities should not be equal")
+	}
+}
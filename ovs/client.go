@@ -52,6 +52,9 @@ type Client struct {
 	// Prefix all commands with "sudo".
 	sudo bool
 
+	// Reject flows with unknown match keys when parsing flows.
+	strictFlows bool
+
 	// Implementation of ExecFunc.
 	execFunc ExecFunc
 
@@ -325,6 +328,16 @@ func Protocols(versions []string) OptionFunc {
 	}
 }
 
+// StrictFlowParsing configures the Client to return an error when a flow
+// retrieved from Open vSwitch contains a match key which is not known to
+// this package, instead of preserving it verbatim.  See
+// Flow.UnmarshalTextStrict.
+func StrictFlowParsing() OptionFunc {
+	return func(c *Client) {
+		c.strictFlows = true
+	}
+}
+
 // SetSSLParam configures SSL authentication using a private key, certificate,
 // and CA certificate for use with ovs-ofctl.
 func SetSSLParam(pkey string, cert string, cacert string) OptionFunc {
@@ -0,0 +1,213 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// errControllerReason is returned when a ControllerSpec specifies a
+	// reason which is not known to Open vSwitch.
+	errControllerReason = errors.New("invalid controller reason")
+)
+
+// A ControllerReason is the reason reported to an OpenFlow controller when
+// a packet is sent to it using the Controller action.
+type ControllerReason string
+
+// ControllerReason constants which can be used with ControllerSpec.
+const (
+	ControllerReasonAction     ControllerReason = "action"
+	ControllerReasonNoMatch    ControllerReason = "no_match"
+	ControllerReasonInvalidTTL ControllerReason = "invalid_ttl"
+	ControllerReasonActionSet  ControllerReason = "action_set"
+	ControllerReasonGroup      ControllerReason = "group"
+	ControllerReasonPacketOut  ControllerReason = "packet_out"
+)
+
+// A ControllerSpec configures the controller action created by Controller.
+type ControllerSpec struct {
+	// Reason is the reason reported to the controller.  If not set,
+	// ControllerReasonAction is used.
+	Reason ControllerReason
+
+	// MaxLen is the maximum number of bytes of the packet to send to the
+	// controller.  If not set, the entire packet is sent.
+	MaxLen uint16
+
+	// ID is the connection ID of the controller to send the packet to.
+	// If not set, the packet is sent to all controllers with ID 0.
+	ID uint16
+
+	// Userdata is opaque data sent to the controller with the packet.
+	Userdata []byte
+
+	// Pause pauses pipeline processing of the packet until the
+	// controller resumes it.
+	Pause bool
+}
+
+// Controller sends a packet to an OpenFlow controller as a packet-in
+// message, using the options specified in spec.
+func Controller(spec ControllerSpec) Action {
+	return &controllerAction{
+		spec: spec,
+	}
+}
+
+// A controllerAction is an Action which is used by Controller.
+type controllerAction struct {
+	spec ControllerSpec
+}
+
+// Constants used repeatedly while marshaling and unmarshaling controller
+// actions.
+const (
+	actionController = "controller"
+
+	controllerArgReason   = "reason"
+	controllerArgMaxLen   = "max_len"
+	controllerArgID       = "id"
+	controllerArgUserdata = "userdata"
+	controllerArgPause    = "pause"
+)
+
+// MarshalText implements Action.  Arguments are marshaled in the order used
+// by Open vSwitch, and the shortest form of the action is used.
+func (a *controllerAction) MarshalText() ([]byte, error) {
+	s := a.spec
+
+	if !validControllerReason(s.Reason) {
+		return nil, errControllerReason
+	}
+
+	var args []string
+	if s.Reason != "" && s.Reason != ControllerReasonAction {
+		args = append(args, controllerArgReason+"="+string(s.Reason))
+	}
+
+	if len(args) == 0 && s.ID == 0 && len(s.Userdata) == 0 && !s.Pause {
+		if s.MaxLen == 0 {
+			return []byte(actionController), nil
+		}
+
+		return bprintf("%s:%d", actionController, s.MaxLen), nil
+	}
+
+	if s.MaxLen != 0 {
+		args = append(args, controllerArgMaxLen+"="+strconv.Itoa(int(s.MaxLen)))
+	}
+	if s.ID != 0 {
+		args = append(args, controllerArgID+"="+strconv.Itoa(int(s.ID)))
+	}
+	if len(s.Userdata) > 0 {
+		args = append(args, controllerArgUserdata+"="+hexDotted(s.Userdata))
+	}
+	if s.Pause {
+		args = append(args, controllerArgPause)
+	}
+
+	return bprintf("%s(%s)", actionController, strings.Join(args, ",")), nil
+}
+
+// GoString implements Action.
+func (a *controllerAction) GoString() string {
+	s := a.spec
+
+	var fields []string
+	if s.Reason != "" {
+		fields = append(fields, fmt.Sprintf("Reason: %q", s.Reason))
+	}
+	if s.MaxLen != 0 {
+		fields = append(fields, fmt.Sprintf("MaxLen: %d", s.MaxLen))
+	}
+	if s.ID != 0 {
+		fields = append(fields, fmt.Sprintf("ID: %d", s.ID))
+	}
+	if len(s.Userdata) > 0 {
+		fields = append(fields, fmt.Sprintf("Userdata: %#v", s.Userdata))
+	}
+	if s.Pause {
+		fields = append(fields, "Pause: true")
+	}
+
+	return "ovs.Controller(ovs.ControllerSpec{" + strings.Join(fields, ", ") + "})"
+}
+
+// validControllerReason determines if a ControllerReason is empty, or is
+// known to Open vSwitch.
+func validControllerReason(r ControllerReason) bool {
+	switch r {
+	case "", ControllerReasonAction, ControllerReasonNoMatch,
+		ControllerReasonInvalidTTL, ControllerReasonActionSet,
+		ControllerReasonGroup, ControllerReasonPacketOut:
+		return true
+	}
+
+	return false
+}
+
+// parseController parses the arguments of a controller action of the form
+// "controller(key=value,...)".  Arguments which are not supported by
+// Controller result in a RawAction.
+func parseController(args string, raw string) (Action, error) {
+	var spec ControllerSpec
+	for _, f := range splitFields(args) {
+		kv := strings.SplitN(f, "=", 2)
+		if len(kv) == 1 {
+			if f != controllerArgPause {
+				return RawAction(raw), nil
+			}
+
+			spec.Pause = true
+			continue
+		}
+
+		k, v := kv[0], kv[1]
+		switch k {
+		case controllerArgReason:
+			spec.Reason = ControllerReason(v)
+			if !validControllerReason(spec.Reason) {
+				return RawAction(raw), nil
+			}
+		case controllerArgMaxLen, controllerArgID:
+			n, err := strconv.ParseUint(v, 10, 16)
+			if err != nil {
+				return nil, err
+			}
+
+			if k == controllerArgMaxLen {
+				spec.MaxLen = uint16(n)
+			} else {
+				spec.ID = uint16(n)
+			}
+		case controllerArgUserdata:
+			b, err := parseHexDotted(v)
+			if err != nil {
+				return nil, err
+			}
+
+			spec.Userdata = b
+		default:
+			return RawAction(raw), nil
+		}
+	}
+
+	return Controller(spec), nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"testing"
+)
+
+func TestController(t *testing.T) {
+	var tests = []struct {
+		desc   string
+		a      Action
+		action string
+		err    error
+	}{
+		{
+			desc:   "no options",
+			a:      Controller(ControllerSpec{}),
+			action: "controller",
+		},
+		{
+			desc: "max_len only",
+			a: Controller(ControllerSpec{
+				MaxLen: 128,
+			}),
+			action: "controller:128",
+		},
+		{
+			desc: "default reason",
+			a: Controller(ControllerSpec{
+				Reason: ControllerReasonAction,
+			}),
+			action: "controller",
+		},
+		{
+			desc: "reason",
+			a: Controller(ControllerSpec{
+				Reason: ControllerReasonNoMatch,
+			}),
+			action: "controller(reason=no_match)",
+		},
+		{
+			desc: "all options",
+			a: Controller(ControllerSpec{
+				Reason:   ControllerReasonInvalidTTL,
+				MaxLen:   1500,
+				ID:       2,
+				Userdata: []byte{0x00, 0x01, 0xab},
+				Pause:    true,
+			}),
+			action: "controller(reason=invalid_ttl,max_len=1500,id=2,userdata=00.01.ab,pause)",
+		},
+		{
+			desc: "id with default reason",
+			a: Controller(ControllerSpec{
+				MaxLen: 64,
+				ID:     1,
+			}),
+			action: "controller(max_len=64,id=1)",
+		},
+		{
+			desc: "invalid reason",
+			a: Controller(ControllerSpec{
+				Reason: "foo",
+			}),
+			err: errControllerReason,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			action, err := tt.a.MarshalText()
+
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if err != nil {
+				return
+			}
+
+			if want, got := tt.action, string(action); want != got {
+				t.Fatalf("unexpected Action:\n- want: %q\n-  got: %q",
+					want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,509 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+var (
+	// errCTZoneAndField is returned when a CTSpec specifies both an
+	// immediate zone and a zone field.
+	errCTZoneAndField = errors.New("connection tracking zone must be either immediate or a field")
+
+	// errCTNATSrcAndDst is returned when a CTNAT specifies both source and
+	// destination translation.
+	errCTNATSrcAndDst = errors.New("NAT must be either source or destination")
+
+	// errCTNATRangeWithoutType is returned when a CTNAT specifies an address
+	// or port range without source or destination translation.
+	errCTNATRangeWithoutType = errors.New("NAT range requires source or destination")
+
+	// errCTNATPortsWithoutAddr is returned when a CTNAT specifies a port
+	// range without an address range.
+	errCTNATPortsWithoutAddr = errors.New("NAT port range requires an address range")
+
+	// errCTNATAddrFamily is returned when a CTNAT address range mixes IPv4
+	// and IPv6 addresses.
+	errCTNATAddrFamily = errors.New("NAT address range must be either IPv4 or IPv6")
+)
+
+// A CTSpec configures the connection tracking action created by CT.
+type CTSpec struct {
+	// Commit commits the connection to the connection tracker, so that
+	// it persists beyond the lifetime of the packet.
+	Commit bool
+
+	// Force, used with Commit, deletes an existing connection in the
+	// opposite direction before committing.
+	Force bool
+
+	// Recirculate and Table fork pipeline processing: the tracked packet
+	// is recirculated to Table, while the original packet continues
+	// processing as untracked.
+	Recirculate bool
+	Table       int
+
+	// Zone is an immediate connection tracking zone, and ZoneField is a
+	// field or subfield from which the zone is loaded, such as
+	// "NXM_NX_REG0[0..15]".  At most one may be set.
+	Zone      uint16
+	ZoneField string
+
+	// Alg is the application layer gateway used for related connections,
+	// such as "ftp" or "tftp".
+	Alg string
+
+	// NAT, if set, applies network address translation to the packet.
+	NAT *CTNAT
+
+	// Exec are actions applied to the connection when it is committed,
+	// such as setting ct_mark or ct_label.
+	Exec []Action
+}
+
+// A CTNAT configures network address translation within a CT action.  If
+// neither Src nor Dst is set, the NAT configuration of an existing
+// connection is applied.
+type CTNAT struct {
+	// Src and Dst specify source or destination translation for new
+	// connections.  At most one may be set.
+	Src bool
+	Dst bool
+
+	// MinAddr and MaxAddr are the range of addresses used for
+	// translation.  MaxAddr may be omitted to use a single address.
+	MinAddr net.IP
+	MaxAddr net.IP
+
+	// MinPort and MaxPort are the range of transport ports used for
+	// translation.  MaxPort may be omitted to use a single port.
+	MinPort uint16
+	MaxPort uint16
+
+	// Flags which control the selection of addresses and ports.
+	Persistent bool
+	Hash       bool
+	Random     bool
+}
+
+// CT sends a packet through the host's connection tracker, using the
+// options specified in spec.
+func CT(spec CTSpec) Action {
+	return &ctSpecAction{
+		spec: spec,
+	}
+}
+
+// A ctSpecAction is an Action which is used by CT.
+type ctSpecAction struct {
+	spec CTSpec
+}
+
+// Actions implements CompositeAction, returning the actions applied to the
+// connection when it is committed.
+func (a *ctSpecAction) Actions() []Action {
+	return a.spec.Exec
+}
+
+// Constants used repeatedly while marshaling and unmarshaling ct actions.
+const (
+	ctArgCommit      = "commit"
+	ctArgForce       = "force"
+	ctArgTable       = "table"
+	ctArgZone        = "zone"
+	ctArgAlg         = "alg"
+	ctArgNAT         = "nat"
+	ctArgExec        = "exec"
+	natArgPersistent = "persistent"
+	natArgHash       = "hash"
+	natArgRandom     = "random"
+)
+
+// MarshalText implements Action.  Arguments are marshaled in the order used
+// by Open vSwitch.
+func (a *ctSpecAction) MarshalText() ([]byte, error) {
+	s := a.spec
+
+	var args []string
+	if s.Commit {
+		args = append(args, ctArgCommit)
+	}
+	if s.Force {
+		args = append(args, ctArgForce)
+	}
+	if s.Recirculate {
+		args = append(args, ctArgTable+"="+strconv.Itoa(s.Table))
+	}
+
+	switch {
+	case s.ZoneField != "" && s.Zone != 0:
+		return nil, errCTZoneAndField
+	case s.ZoneField != "":
+		args = append(args, ctArgZone+"="+s.ZoneField)
+	case s.Zone != 0:
+		args = append(args, ctArgZone+"="+strconv.Itoa(int(s.Zone)))
+	}
+
+	if s.NAT != nil {
+		nb, err := s.NAT.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, string(nb))
+	}
+
+	if len(s.Exec) > 0 {
+		actions, err := marshalActions(s.Exec)
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, ctArgExec+"("+strings.Join(actions, ",")+")")
+	}
+
+	if s.Alg != "" {
+		args = append(args, ctArgAlg+"="+s.Alg)
+	}
+
+	return bprintf(patConnectionTracking, strings.Join(args, ",")), nil
+}
+
+// GoString implements Action.
+func (a *ctSpecAction) GoString() string {
+	s := a.spec
+
+	var fields []string
+	if s.Commit {
+		fields = append(fields, "Commit: true")
+	}
+	if s.Force {
+		fields = append(fields, "Force: true")
+	}
+	if s.Recirculate {
+		fields = append(fields, "Recirculate: true", fmt.Sprintf("Table: %d", s.Table))
+	}
+	if s.Zone != 0 {
+		fields = append(fields, fmt.Sprintf("Zone: %d", s.Zone))
+	}
+	if s.ZoneField != "" {
+		fields = append(fields, fmt.Sprintf("ZoneField: %q", s.ZoneField))
+	}
+	if s.Alg != "" {
+		fields = append(fields, fmt.Sprintf("Alg: %q", s.Alg))
+	}
+	if s.NAT != nil {
+		fields = append(fields, "NAT: "+s.NAT.goString())
+	}
+	if len(s.Exec) > 0 {
+		fields = append(fields, "Exec: []ovs.Action{"+goStringActions(s.Exec)+"}")
+	}
+
+	return "ovs.CT(ovs.CTSpec{" + strings.Join(fields, ", ") + "})"
+}
+
+// goString returns the Go syntax representation of a CTNAT.
+func (n *CTNAT) goString() string {
+	var fields []string
+	if n.Src {
+		fields = append(fields, "Src: true")
+	}
+	if n.Dst {
+		fields = append(fields, "Dst: true")
+	}
+	if n.MinAddr != nil {
+		fields = append(fields, "MinAddr: "+ipGoString(n.MinAddr))
+	}
+	if n.MaxAddr != nil {
+		fields = append(fields, "MaxAddr: "+ipGoString(n.MaxAddr))
+	}
+	if n.MinPort != 0 {
+		fields = append(fields, fmt.Sprintf("MinPort: %d", n.MinPort))
+	}
+	if n.MaxPort != 0 {
+		fields = append(fields, fmt.Sprintf("MaxPort: %d", n.MaxPort))
+	}
+	if n.Persistent {
+		fields = append(fields, "Persistent: true")
+	}
+	if n.Hash {
+		fields = append(fields, "Hash: true")
+	}
+	if n.Random {
+		fields = append(fields, "Random: true")
+	}
+
+	return "&ovs.CTNAT{" + strings.Join(fields, ", ") + "}"
+}
+
+// ipGoString converts a net.IP into its Go syntax representation.
+func ipGoString(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ipv4GoString(ip4)
+	}
+
+	return fmt.Sprintf("net.ParseIP(%q)", ip.String())
+}
+
+// MarshalText marshals a CTNAT into its textual form.
+func (n *CTNAT) MarshalText() ([]byte, error) {
+	if n.Src && n.Dst {
+		return nil, errCTNATSrcAndDst
+	}
+
+	if !n.Src && !n.Dst {
+		if n.MinAddr != nil || n.MaxAddr != nil || n.MinPort != 0 || n.MaxPort != 0 {
+			return nil, errCTNATRangeWithoutType
+		}
+
+		// Apply the NAT configuration of an existing connection.
+		return []byte(ctArgNAT), nil
+	}
+
+	typ := destination
+	if n.Src {
+		typ = source
+	}
+
+	if n.MinAddr == nil && (n.MinPort != 0 || n.MaxPort != 0) {
+		return nil, errCTNATPortsWithoutAddr
+	}
+
+	args := []string{typ}
+	if n.MinAddr != nil {
+		r, err := n.marshalRange()
+		if err != nil {
+			return nil, err
+		}
+
+		args[0] += "=" + r
+	}
+
+	if n.Persistent {
+		args = append(args, natArgPersistent)
+	}
+	if n.Hash {
+		args = append(args, natArgHash)
+	}
+	if n.Random {
+		args = append(args, natArgRandom)
+	}
+
+	return []byte(ctArgNAT + "(" + strings.Join(args, ",") + ")"), nil
+}
+
+// marshalRange marshals the address and port range of a CTNAT.
+func (n *CTNAT) marshalRange() (string, error) {
+	ipv4 := n.MinAddr.To4() != nil
+	if n.MaxAddr != nil && (n.MaxAddr.To4() != nil) != ipv4 {
+		return "", errCTNATAddrFamily
+	}
+
+	// IPv6 addresses must be enclosed in brackets when ports follow.
+	addr := func(ip net.IP) string {
+		if !ipv4 && n.MinPort != 0 {
+			return "[" + ip.String() + "]"
+		}
+
+		return ip.String()
+	}
+
+	s := addr(n.MinAddr)
+	if n.MaxAddr != nil && !n.MaxAddr.Equal(n.MinAddr) {
+		s += "-" + addr(n.MaxAddr)
+	}
+
+	if n.MinPort != 0 {
+		s += ":" + strconv.Itoa(int(n.MinPort))
+		if n.MaxPort != 0 && n.MaxPort != n.MinPort {
+			s += "-" + strconv.Itoa(int(n.MaxPort))
+		}
+	}
+
+	return s, nil
+}
+
+// parseCT parses the arguments of a ct action.  If the arguments contain
+// keywords which are not supported by CTSpec, ConnectionTracking is used
+// to preserve them as-is.
+func parseCT(args string) (Action, error) {
+	var spec CTSpec
+	for _, f := range splitFields(args) {
+		kv := strings.SplitN(f, "=", 2)
+		k := kv[0]
+
+		var v string
+		if len(kv) == 2 {
+			v = kv[1]
+		}
+
+		switch {
+		case f == ctArgCommit:
+			spec.Commit = true
+		case f == ctArgForce:
+			spec.Force = true
+		case k == ctArgTable && v != "":
+			table, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, err
+			}
+
+			spec.Recirculate = true
+			spec.Table = table
+		case k == ctArgZone && v != "":
+			zone, err := strconv.ParseUint(v, 0, 16)
+			if err != nil {
+				// Not an immediate value, so the zone is loaded
+				// from a field.
+				spec.ZoneField = v
+				continue
+			}
+
+			spec.Zone = uint16(zone)
+		case k == ctArgAlg && v != "":
+			spec.Alg = v
+		case f == ctArgNAT || strings.HasPrefix(f, ctArgNAT+"(") && strings.HasSuffix(f, ")"):
+			nat, err := parseCTNAT(strings.TrimSuffix(strings.TrimPrefix(f, ctArgNAT), ")"))
+			if err != nil {
+				return nil, err
+			}
+
+			spec.NAT = nat
+		case strings.HasPrefix(f, ctArgExec+"(") && strings.HasSuffix(f, ")"):
+			exec := f[len(ctArgExec)+1 : len(f)-1]
+			actions, err := parseActionList(exec)
+			if err != nil {
+				return nil, err
+			}
+
+			spec.Exec = actions
+		default:
+			// Unknown argument; preserve the arguments verbatim.
+			return ConnectionTracking(args), nil
+		}
+	}
+
+	return CT(spec), nil
+}
+
+// parseCTNAT parses the arguments of a nat clause within a ct action,
+// including the leading parenthesis, if any.
+func parseCTNAT(s string) (*CTNAT, error) {
+	nat := &CTNAT{}
+	if s == "" {
+		return nat, nil
+	}
+
+	for i, f := range splitFields(strings.TrimPrefix(s, "(")) {
+		kv := strings.SplitN(f, "=", 2)
+
+		switch {
+		case i == 0 && kv[0] == source:
+			nat.Src = true
+		case i == 0 && kv[0] == destination:
+			nat.Dst = true
+		case f == natArgPersistent:
+			nat.Persistent = true
+		case f == natArgHash:
+			nat.Hash = true
+		case f == natArgRandom:
+			nat.Random = true
+		default:
+			return nil, fmt.Errorf("invalid NAT argument: %q", f)
+		}
+
+		if i == 0 && len(kv) == 2 {
+			if err := nat.parseRange(kv[1]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return nat, nil
+}
+
+// parseRange parses an address and optional port range into a CTNAT, such
+// as "192.0.2.1-192.0.2.10:1000-2000" or "[2001:db8::1]:1000".
+func (n *CTNAT) parseRange(s string) error {
+	var addrs, ports string
+	switch {
+	case strings.HasPrefix(s, "["):
+		// Bracketed IPv6 addresses, which may be followed by ports.
+		i := strings.LastIndex(s, "]")
+		if i == -1 {
+			return fmt.Errorf("invalid NAT range: %q", s)
+		}
+
+		addrs = strings.NewReplacer("[", "", "]", "").Replace(s[:i+1])
+		if rest := s[i+1:]; rest != "" {
+			if !strings.HasPrefix(rest, ":") {
+				return fmt.Errorf("invalid NAT range: %q", s)
+			}
+
+			ports = rest[1:]
+		}
+	case strings.Count(s, ":") > 1:
+		// IPv6 addresses without ports.
+		addrs = s
+	default:
+		ss := strings.SplitN(s, ":", 2)
+		addrs = ss[0]
+		if len(ss) == 2 {
+			ports = ss[1]
+		}
+	}
+
+	as := strings.SplitN(addrs, "-", 2)
+	for i, a := range as {
+		ip := net.ParseIP(a)
+		if ip == nil {
+			return fmt.Errorf("invalid NAT address: %q", a)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+		}
+
+		if i == 0 {
+			n.MinAddr = ip
+		} else {
+			n.MaxAddr = ip
+		}
+	}
+
+	if ports == "" {
+		return nil
+	}
+
+	ps := strings.SplitN(ports, "-", 2)
+	for i, p := range ps {
+		port, err := strconv.ParseUint(p, 10, 16)
+		if err != nil {
+			return err
+		}
+
+		if i == 0 {
+			n.MinPort = uint16(port)
+		} else {
+			n.MaxPort = uint16(port)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,307 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCTMarshalText(t *testing.T) {
+	var tests = []struct {
+		desc string
+		a    Action
+		s    string
+		err  error
+	}{
+		{
+			desc: "no arguments",
+			a:    CT(CTSpec{}),
+			s:    "ct()",
+		},
+		{
+			desc: "zone and zone field",
+			a: CT(CTSpec{
+				Zone:      1,
+				ZoneField: "NXM_NX_REG0[0..15]",
+			}),
+			err: errCTZoneAndField,
+		},
+		{
+			desc: "NAT source and destination",
+			a: CT(CTSpec{
+				NAT: &CTNAT{
+					Src: true,
+					Dst: true,
+				},
+			}),
+			err: errCTNATSrcAndDst,
+		},
+		{
+			desc: "NAT range without type",
+			a: CT(CTSpec{
+				NAT: &CTNAT{
+					MinAddr: net.IPv4(192, 0, 2, 1),
+				},
+			}),
+			err: errCTNATRangeWithoutType,
+		},
+		{
+			desc: "NAT ports without address",
+			a: CT(CTSpec{
+				NAT: &CTNAT{
+					Src:     true,
+					MinPort: 1000,
+				},
+			}),
+			err: errCTNATPortsWithoutAddr,
+		},
+		{
+			desc: "NAT mixed address families",
+			a: CT(CTSpec{
+				NAT: &CTNAT{
+					Src:     true,
+					MinAddr: net.IPv4(192, 0, 2, 1),
+					MaxAddr: net.ParseIP("2001:db8::1"),
+				},
+			}),
+			err: errCTNATAddrFamily,
+		},
+		{
+			desc: "commit, table, and zone",
+			a: CT(CTSpec{
+				Commit:      true,
+				Recirculate: true,
+				Table:       0,
+				Zone:        10,
+			}),
+			s: "ct(commit,table=0,zone=10)",
+		},
+		{
+			desc: "zone field and alg",
+			a: CT(CTSpec{
+				Commit:    true,
+				Force:     true,
+				ZoneField: "NXM_NX_REG0[0..15]",
+				Alg:       "ftp",
+			}),
+			s: "ct(commit,force,zone=NXM_NX_REG0[0..15],alg=ftp)",
+		},
+		{
+			desc: "exec",
+			a: CT(CTSpec{
+				Commit: true,
+				Exec: []Action{
+					SetField("1", "ct_label"),
+					SetField("1", "ct_mark"),
+				},
+			}),
+			s: "ct(commit,exec(set_field:1->ct_label,set_field:1->ct_mark))",
+		},
+		{
+			desc: "NAT existing connection",
+			a: CT(CTSpec{
+				Recirculate: true,
+				Table:       1,
+				NAT:         &CTNAT{},
+			}),
+			s: "ct(table=1,nat)",
+		},
+		{
+			desc: "source NAT IPv4",
+			a: CT(CTSpec{
+				Commit: true,
+				Zone:   1,
+				NAT: &CTNAT{
+					Src:        true,
+					MinAddr:    net.IPv4(192, 0, 2, 1),
+					MaxAddr:    net.IPv4(192, 0, 2, 10),
+					MinPort:    1000,
+					MaxPort:    2000,
+					Persistent: true,
+					Random:     true,
+				},
+				Exec: []Action{SetField("0x1", "ct_mark")},
+			}),
+			s: "ct(commit,zone=1,nat(src=192.0.2.1-192.0.2.10:1000-2000,persistent,random),exec(set_field:0x1->ct_mark))",
+		},
+		{
+			desc: "destination NAT IPv6 with port",
+			a: CT(CTSpec{
+				Commit: true,
+				NAT: &CTNAT{
+					Dst:     true,
+					MinAddr: net.ParseIP("2001:db8::1"),
+					MinPort: 80,
+					Hash:    true,
+				},
+			}),
+			s: "ct(commit,nat(dst=[2001:db8::1]:80,hash))",
+		},
+		{
+			desc: "destination NAT IPv6 range",
+			a: CT(CTSpec{
+				Commit: true,
+				NAT: &CTNAT{
+					Dst:     true,
+					MinAddr: net.ParseIP("2001:db8::1"),
+					MaxAddr: net.ParseIP("2001:db8::2"),
+				},
+			}),
+			s: "ct(commit,nat(dst=2001:db8::1-2001:db8::2))",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			b, err := tt.a.MarshalText()
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(tt.s, string(b)); diff != "" {
+				t.Fatalf("unexpected ct action (-want +got):\n%s", diff)
+			}
+
+			// Every valid ct action must round-trip through the parser.
+			a, err := parseAction(tt.s)
+			if err != nil {
+				t.Fatalf("failed to parse action: %v", err)
+			}
+			if _, ok := a.(*ctSpecAction); !ok {
+				t.Fatalf("unexpected action type: %T", a)
+			}
+
+			b, err = a.MarshalText()
+			if err != nil {
+				t.Fatalf("failed to marshal parsed action: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.s, string(b)); diff != "" {
+				t.Fatalf("unexpected parsed ct action (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseCT(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		s       string
+		final   string
+		raw     bool
+		invalid bool
+	}{
+		{
+			desc:    "bad table",
+			s:       "ct(table=foo)",
+			invalid: true,
+		},
+		{
+			desc:    "bad NAT argument",
+			s:       "ct(nat(foo))",
+			invalid: true,
+		},
+		{
+			desc:    "bad NAT address",
+			s:       "ct(nat(src=foo))",
+			invalid: true,
+		},
+		{
+			desc:    "bad NAT port",
+			s:       "ct(nat(src=192.0.2.1:foo))",
+			invalid: true,
+		},
+		{
+			desc:    "bad exec action",
+			s:       "ct(commit,exec(mod_tp_dst:foo))",
+			invalid: true,
+		},
+		{
+			desc: "arguments reordered",
+			s:    "ct(zone=1,commit,table=2)",
+			// Arguments are marshaled in the order used by OVS.
+			final: "ct(commit,table=2,zone=1)",
+		},
+		{
+			desc: "exec with load",
+			s:    "ct(commit,table=65,exec(load:0x1fb5fce->NXM_NX_CT_MARK[]))",
+		},
+		{
+			desc: "unknown argument",
+			s:    "ct(commit,foo=bar)",
+			raw:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			a, err := parseAction(tt.s)
+			if err != nil {
+				if tt.invalid {
+					return
+				}
+
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.invalid {
+				t.Fatal("expected an error, but none occurred")
+			}
+
+			if _, ok := a.(*ctAction); ok != tt.raw {
+				t.Fatalf("unexpected action type: %T", a)
+			}
+
+			b, err := a.MarshalText()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			want := tt.s
+			if tt.final != "" {
+				want = tt.final
+			}
+
+			if diff := cmp.Diff(want, string(b)); diff != "" {
+				t.Fatalf("unexpected ct action (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCTGoString(t *testing.T) {
+	a := CT(CTSpec{
+		Commit:      true,
+		Recirculate: true,
+		Table:       1,
+		Zone:        2,
+		NAT: &CTNAT{
+			Src:     true,
+			MinAddr: net.IPv4(192, 0, 2, 1),
+		},
+		Exec: []Action{SetField("0x1", "ct_mark")},
+	})
+
+	want := `ovs.CT(ovs.CTSpec{Commit: true, Recirculate: true, Table: 1, Zone: 2, NAT: &ovs.CTNAT{Src: true, MinAddr: net.IPv4(192, 0, 2, 1)}, Exec: []ovs.Action{ovs.SetField("0x1", "ct_mark")}})`
+	if diff := cmp.Diff(want, a.GoString()); diff != "" {
+		t.Fatalf("unexpected Go syntax (-want +got):\n%s", diff)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -27,11 +28,14 @@ const (
 	PortLOCAL = -1
 )
 
+// ErrUnknownMatch is returned by Flow.UnmarshalTextStrict when a flow
+// contains a match key which is not known to this package.
+var ErrUnknownMatch = errors.New("unknown match for Flow")
+
 // Possible errors which may be encountered while marshaling or unmarshaling
 // a Flow.
 var (
 	errActionsWithDrop       = errors.New("Flow actions include drop, but multiple actions specified")
-	errInvalidActions        = errors.New("invalid actions for Flow")
 	errNoActions             = errors.New("no actions defined for Flow")
 	errNotEnoughElements     = errors.New("not enough elements for valid Flow")
 	errInvalidLearnedActions = errors.New("invalid actions for LearnedFlow")
@@ -53,6 +57,39 @@ const (
 	ProtocolUDPv6  Protocol = "udp6"
 )
 
+// A FlowFlag is a flag which may be set when a Flow is added or modified.
+type FlowFlag string
+
+// FlowFlag constants which can be used in OVS flow configurations.
+const (
+	// FlowFlagSendFlowRemoved sends a flow removed message to the
+	// controller when the flow expires or is deleted.
+	FlowFlagSendFlowRemoved FlowFlag = "send_flow_rem"
+
+	// FlowFlagCheckOverlap prevents a flow from being added if it
+	// overlaps with an existing flow of the same priority.
+	FlowFlagCheckOverlap FlowFlag = "check_overlap"
+
+	// FlowFlagResetCounts resets the packet and byte counters of a flow
+	// when it is modified.
+	FlowFlagResetCounts FlowFlag = "reset_counts"
+
+	// FlowFlagNoPacketCounts disables packet counting for a flow.
+	FlowFlagNoPacketCounts FlowFlag = "no_packet_counts"
+
+	// FlowFlagNoByteCounts disables byte counting for a flow.
+	FlowFlagNoByteCounts FlowFlag = "no_byte_counts"
+)
+
+// flowFlags is the set of all known FlowFlag values.
+var flowFlags = map[FlowFlag]struct{}{
+	FlowFlagSendFlowRemoved: {},
+	FlowFlagCheckOverlap:    {},
+	FlowFlagResetCounts:     {},
+	FlowFlagNoPacketCounts:  {},
+	FlowFlagNoByteCounts:    {},
+}
+
 // A Flow is an OpenFlow flow meant for adding/fetching flows to a software bridge.  It can be marshaled
 // to and from its textual form for use with Open vSwitch.
 type Flow struct {
@@ -62,12 +99,18 @@ type Flow struct {
 	Matches     []Match
 	Table       int
 	IdleTimeout int
+	HardTimeout int
+	Importance  int
+	Flags       []FlowFlag
 	Cookie      uint64
 	Actions     []Action
 	Stats       FlowStats
 }
 
 // A LearnedFlow is defined as part of the Learn action.
+//
+// Matches may copy fields from the packet which triggered the Learn action
+// using LearnMatchField, and Actions may only contain Load and OutputField.
 type LearnedFlow struct {
 	Priority    int
 	InPort      int
@@ -77,10 +120,16 @@ type LearnedFlow struct {
 	Cookie      uint64
 	Actions     []Action
 
-	DeleteLearned  bool
-	FinHardTimeout int
-	HardTimeout    int
-	Limit          int
+	DeleteLearned   bool
+	SendFlowRemoved bool
+	FinIdleTimeout  int
+	FinHardTimeout  int
+	HardTimeout     int
+	Limit           int
+
+	// ResultDst, if set, is a field such as "NXM_NX_REG0[5]" into which
+	// the learn action writes 1 if the flow was learned, or 0 otherwise.
+	ResultDst string
 }
 
 var _ error = &FlowError{}
@@ -114,7 +163,11 @@ const (
 	cookie      = "cookie"
 	keyActions  = "actions"
 	idleTimeout = "idle_timeout"
+	hardTimeout = "hard_timeout"
+	importance  = "importance"
 	inPort      = "in_port"
+	outPort     = "out_port"
+	outGroup    = "out_group"
 	table       = "table"
 	duration    = "duration"
 	nPackets    = "n_packets"
@@ -124,9 +177,10 @@ const (
 
 	// Variables used in LearnedFlows only.
 	deleteLearned  = "delete_learned"
+	finIdleTimeout = "fin_idle_timeout"
 	finHardTimeout = "fin_hard_timeout"
-	hardTimeout    = "hard_timeout"
 	limit          = "limit"
+	resultDst      = "result_dst"
 
 	portLOCAL = "LOCAL"
 )
@@ -153,6 +207,13 @@ func (f *Flow) MarshalText() ([]byte, error) {
 		return nil, err
 	}
 
+	// Instructions must appear in the order required by OpenFlow.
+	if err := verifyInstructions(f.Table, f.Actions); err != nil {
+		return nil, &FlowError{
+			Err: err,
+		}
+	}
+
 	// Action "drop" must only be specified by itself
 	if len(actions) > 1 {
 		for _, a := range actions {
@@ -195,6 +256,21 @@ func (f *Flow) MarshalText() ([]byte, error) {
 	b = append(b, ","+idleTimeout+"="...)
 	b = strconv.AppendInt(b, int64(f.IdleTimeout), 10)
 
+	if f.HardTimeout > 0 {
+		b = append(b, ","+hardTimeout+"="...)
+		b = strconv.AppendInt(b, int64(f.HardTimeout), 10)
+	}
+
+	if f.Importance > 0 {
+		b = append(b, ","+importance+"="...)
+		b = strconv.AppendInt(b, int64(f.Importance), 10)
+	}
+
+	for _, flag := range f.Flags {
+		b = append(b, ',')
+		b = append(b, flag...)
+	}
+
 	if f.Cookie > 0 {
 		// Hexadecimal cookies are much easier to read.
 		b = append(b, ","+cookie+"="...)
@@ -263,6 +339,11 @@ func (f *LearnedFlow) MarshalText() ([]byte, error) {
 	b = append(b, ","+idleTimeout+"="...)
 	b = strconv.AppendInt(b, int64(f.IdleTimeout), 10)
 
+	if f.FinIdleTimeout > 0 {
+		b = append(b, ","+finIdleTimeout+"="...)
+		b = strconv.AppendInt(b, int64(f.FinIdleTimeout), 10)
+	}
+
 	b = append(b, ","+finHardTimeout+"="...)
 	b = strconv.AppendInt(b, int64(f.FinHardTimeout), 10)
 
@@ -280,6 +361,14 @@ func (f *LearnedFlow) MarshalText() ([]byte, error) {
 		b = append(b, ","+deleteLearned...)
 	}
 
+	if f.SendFlowRemoved {
+		b = append(b, ","+string(FlowFlagSendFlowRemoved)...)
+	}
+
+	if f.ResultDst != "" {
+		b = append(b, ","+resultDst+"="+f.ResultDst...)
+	}
+
 	if f.Cookie > 0 {
 		// Hexadecimal cookies are much easier to read.
 		b = append(b, ","+cookie+"="...)
@@ -291,8 +380,23 @@ func (f *LearnedFlow) MarshalText() ([]byte, error) {
 	return b, nil
 }
 
-// UnmarshalText unmarshals flow text into a Flow.
+// UnmarshalText unmarshals flow text into a Flow.  Matches with keys which
+// are not known to this package are preserved in their original order using
+// FieldMatch, so that the Flow is unchanged when marshaled again.
 func (f *Flow) UnmarshalText(b []byte) error {
+	return f.unmarshalText(b, false)
+}
+
+// UnmarshalTextStrict unmarshals flow text into a Flow, as with
+// UnmarshalText, but returns a *FlowError with Err set to ErrUnknownMatch
+// if the flow contains a match key which is not known to this package.
+func (f *Flow) UnmarshalTextStrict(b []byte) error {
+	return f.unmarshalText(b, true)
+}
+
+// unmarshalText unmarshals flow text into a Flow, optionally rejecting
+// unknown match keys.
+func (f *Flow) unmarshalText(b []byte, strict bool) error {
 	// Make a copy per documentation for encoding.TextUnmarshaler.
 	// A string is easier to work with in this case.
 	s := string(b)
@@ -311,28 +415,31 @@ func (f *Flow) UnmarshalText(b []byte) error {
 	}
 	matchers, actions := strings.TrimSpace(ss[0]), strings.TrimSpace(ss[1])
 
-	// Handle matchers first.
-	ss = strings.Split(matchers, ",")
+	// Handle matchers first.  Flow flags in dump output are separated by
+	// spaces rather than commas, as in:
+	//  hard_timeout=10, send_flow_rem check_overlap idle_age=2,
+	ss = splitFlowFields(matchers)
 	f.Matches = make([]Match, 0)
+	var hasIdleAge, hasHardAge bool
 	for i := 0; i < len(ss); i++ {
 		if !strings.Contains(ss[i], "=") {
-			// that means this will be a protocol field.
-			if ss[i] != "" {
-				f.Protocol = Protocol(ss[i])
+			if _, ok := flowFlags[FlowFlag(ss[i])]; ok {
+				f.Flags = append(f.Flags, FlowFlag(ss[i]))
+				continue
 			}
+
+			// that means this will be a protocol field.
+			f.Protocol = Protocol(ss[i])
 			continue
 		}
 
 		// All remaining comma-separated values should be in key=value format,
 		// but parsing "actions" is done later because actions can use the form:
 		//  actions=foo,bar,baz
-		kv := strings.Split(ss[i], "=")
-		if len(kv) != 2 {
-			continue
-		}
-		kv[1] = strings.TrimSpace(kv[1])
+		kv := strings.SplitN(ss[i], "=", 2)
+		kv[0], kv[1] = strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
 
-		switch strings.TrimSpace(kv[0]) {
+		switch kv[0] {
 		case priority:
 			// Parse priority into struct field.
 			pri, err := strconv.ParseInt(kv[1], 10, 0)
@@ -386,6 +493,28 @@ func (f *Flow) UnmarshalText(b []byte) error {
 			}
 			f.IdleTimeout = int(timeout)
 			continue
+		case hardTimeout:
+			// Parse hard_timeout into struct field.
+			timeout, err := strconv.ParseInt(kv[1], 10, 0)
+			if err != nil {
+				return &FlowError{
+					Str: kv[1],
+					Err: err,
+				}
+			}
+			f.HardTimeout = int(timeout)
+			continue
+		case importance:
+			// Parse importance into struct field.
+			imp, err := strconv.ParseInt(kv[1], 10, 0)
+			if err != nil {
+				return &FlowError{
+					Str: kv[1],
+					Err: err,
+				}
+			}
+			f.Importance = int(imp)
+			continue
 		case table:
 			// Parse table into struct field.
 			table, err := strconv.ParseInt(kv[1], 10, 0)
@@ -419,8 +548,34 @@ func (f *Flow) UnmarshalText(b []byte) error {
 			}
 			f.Stats.ByteCount = uint64(byteCount)
 			continue
-		case duration, hardAge, idleAge:
-			// ignore those fields.
+		case duration:
+			// Parse duration into struct field, such as "9215.748s".
+			d, err := time.ParseDuration(kv[1])
+			if err != nil {
+				return &FlowError{
+					Str: kv[1],
+					Err: err,
+				}
+			}
+			f.Stats.Duration = d
+			continue
+		case idleAge, hardAge:
+			// Parse idle_age and hard_age, in seconds, into struct fields.
+			secs, err := strconv.ParseUint(kv[1], 10, 32)
+			if err != nil {
+				return &FlowError{
+					Str: kv[1],
+					Err: err,
+				}
+			}
+			age := time.Duration(secs) * time.Second
+			if kv[0] == idleAge {
+				f.Stats.IdleAge = age
+				hasIdleAge = true
+			} else {
+				f.Stats.HardAge = age
+				hasHardAge = true
+			}
 			continue
 		}
 
@@ -430,11 +585,26 @@ func (f *Flow) UnmarshalText(b []byte) error {
 		if err != nil {
 			return err
 		}
-		// The keyword will be skipped if unknown,
-		// don't add a nil value
-		if match != nil {
-			f.Matches = append(f.Matches, match)
+		if match == nil {
+			// Preserve unknown keys verbatim, unless strict parsing
+			// was requested.
+			if strict {
+				return &FlowError{
+					Str: ss[i],
+					Err: ErrUnknownMatch,
+				}
+			}
+
+			match = FieldMatch(kv[0], kv[1])
 		}
+
+		f.Matches = append(f.Matches, match)
+	}
+
+	// Open vSwitch omits hard_age when it is equal to the duration of the
+	// flow, but only reports ages at all for Nicira extended flow stats.
+	if hasIdleAge && !hasHardAge {
+		f.Stats.HardAge = f.Stats.Duration.Truncate(time.Second)
 	}
 
 	// Parse all actions from the flow.
@@ -443,7 +613,7 @@ func (f *Flow) UnmarshalText(b []byte) error {
 	if err != nil {
 		return &FlowError{
 			Str: actions,
-			Err: errInvalidActions,
+			Err: err,
 		}
 	}
 	f.Actions = out
@@ -473,6 +643,14 @@ func (f *Flow) MatchFlow() *MatchFlow {
 	}
 }
 
+// MatchFlowStrict converts Flow into a MatchFlow which also carries the
+// Flow's priority, for use with strict operations such as DelFlowsStrict.
+func (f *Flow) MatchFlowStrict() *MatchFlow {
+	m := f.MatchFlow()
+	m.Priority = f.Priority
+	return m
+}
+
 // marshalActions marshals all provided Actions to their text form.
 func marshalActions(aa []Action) ([]string, error) {
 	fns := make([]func() ([]byte, error), 0, len(aa))
@@ -508,6 +686,14 @@ func marshalFunctions(fns []func() ([]byte, error)) ([]string, error) {
 	return out, nil
 }
 
+// splitFlowFields splits the matchers portion of a flow into its individual
+// fields, which may be separated by commas, whitespace, or both.
+func splitFlowFields(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+}
+
 // paddedHexUint64 formats and displays a uint64 as a hexadecimal string
 // prefixed with 0x and zero-padded up to 16 characters in length.
 func paddedHexUint64(i uint64) string {
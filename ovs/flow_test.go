@@ -20,6 +20,7 @@ import (
 	"reflect"
 	"strconv"
 	"testing"
+	"time"
 )
 
 func TestFlowMarshalText(t *testing.T) {
@@ -397,6 +398,22 @@ func TestFlowMarshalText(t *testing.T) {
 			},
 			s: `priority=5000,tcp,in_port=3,nw_dst=169.254.169.254,tp_dst=80,table=0,idle_timeout=0,actions=learn(priority=5000,in_port=4,dl_type=0x0800,nw_proto=6,NXM_OF_IP_SRC[]=NXM_OF_IP_DST[],NXM_OF_TCP_SRC[]=NXM_OF_TCP_DST[],nw_dst=1.2.3.4,tp_dst=567,table=0,idle_timeout=60,fin_hard_timeout=1,hard_timeout=30,limit=10,delete_learned,load:NXM_OF_ETH_SRC[]->NXM_OF_ETH_DST[],load:NXM_OF_IP_SRC[]->NXM_OF_IP_DST[],load:NXM_OF_TCP_SRC[]->NXM_OF_TCP_DST[],output:NXM_OF_IN_PORT[]),mod_nw_src:1.2.3.4,mod_tp_src:567,output:4`,
 		},
+		{
+			desc: "Flow with hard_timeout, importance, and flags",
+			f: &Flow{
+				Priority:    10,
+				Protocol:    ProtocolIPv4,
+				IdleTimeout: 30,
+				HardTimeout: 60,
+				Importance:  5,
+				Flags: []FlowFlag{
+					FlowFlagSendFlowRemoved,
+					FlowFlagCheckOverlap,
+				},
+				Actions: []Action{Drop()},
+			},
+			s: "priority=10,ip,table=0,idle_timeout=30,hard_timeout=60,importance=5,send_flow_rem,check_overlap,actions=drop",
+		},
 	}
 
 	for _, tt := range tests {
@@ -432,28 +449,44 @@ func TestFlowUnmarshalText(t *testing.T) {
 			desc: "Flow matchers in the actions field",
 			s:    "actions=drop,priority=10",
 			err: &FlowError{
-				Err: errInvalidActions,
+				Err: &ActionError{
+					Str:    "drop,priority=10",
+					Offset: 13,
+					Err:    errActionUnexpected,
+				},
 			},
 		},
 		{
 			desc: "Flow string with malformed resubmit action, no comma",
 			s:    "priority=10,actions=resubmit(",
 			err: &FlowError{
-				Err: errInvalidActions,
+				Err: &ActionError{
+					Str:    "resubmit(",
+					Offset: 8,
+					Err:    errActionUnmatchedParen,
+				},
 			},
 		},
 		{
 			desc: "Flow string with malformed resubmit action, with comma",
 			s:    "priority=10,actions=resubmit(,",
 			err: &FlowError{
-				Err: errInvalidActions,
+				Err: &ActionError{
+					Str:    "resubmit(,",
+					Offset: 8,
+					Err:    errActionUnmatchedParen,
+				},
 			},
 		},
 		{
 			desc: "Flow string with malformed resubmit action, without ending parenthesis",
 			s:    "priority=10,actions=resubmit(,1",
 			err: &FlowError{
-				Err: errInvalidActions,
+				Err: &ActionError{
+					Str:    "resubmit(,1",
+					Offset: 8,
+					Err:    errActionUnmatchedParen,
+				},
 			},
 		},
 		{
@@ -788,6 +821,9 @@ func TestFlowUnmarshalText(t *testing.T) {
 				Stats: FlowStats{
 					PacketCount: 6,
 					ByteCount:   480,
+					Duration:    9215748 * time.Millisecond,
+					IdleAge:     9206 * time.Second,
+					HardAge:     65535 * time.Second,
 				},
 			},
 		},
@@ -804,6 +840,9 @@ func TestFlowUnmarshalText(t *testing.T) {
 				Actions: []Action{
 					ConnectionTracking("table=51"),
 				},
+				Stats: FlowStats{
+					Duration: 1121991329 * time.Millisecond,
+				},
 			},
 		},
 		{
@@ -826,6 +865,7 @@ func TestFlowUnmarshalText(t *testing.T) {
 				Stats: FlowStats{
 					PacketCount: 3,
 					ByteCount:   234,
+					Duration:    83229846 * time.Millisecond,
 				},
 			},
 		},
@@ -847,6 +887,44 @@ func TestFlowUnmarshalText(t *testing.T) {
 				Actions: []Action{
 					ConnectionTracking("commit,table=65,exec(load:0x1fb5fce->NXM_NX_CT_MARK[])"),
 				},
+				Stats: FlowStats{
+					Duration: 920420008 * time.Millisecond,
+				},
+			},
+		},
+		{
+			desc: "CT NAT flow generated by ovs-ofctl dump-flows",
+			s:    " cookie=0x0, duration=920420.008s, table=55, n_packets=0, n_bytes=0, priority=1010,ct_state=+new+trk,tcp actions=ct(commit,table=65,zone=NXM_NX_REG0[0..15],nat(src=192.0.2.1-192.0.2.10:1000-2000,random),exec(set_field:0x1->ct_mark))",
+			f: &Flow{
+				Priority: 1010,
+				Protocol: ProtocolTCPv4,
+				Matches: []Match{
+					ConnectionTrackingState(
+						SetState(CTStateNew),
+						SetState(CTStateTracked),
+					),
+				},
+				Table: 55,
+				Actions: []Action{
+					CT(CTSpec{
+						Commit:      true,
+						Recirculate: true,
+						Table:       65,
+						ZoneField:   "NXM_NX_REG0[0..15]",
+						NAT: &CTNAT{
+							Src:     true,
+							MinAddr: net.IPv4(192, 0, 2, 1),
+							MaxAddr: net.IPv4(192, 0, 2, 10),
+							MinPort: 1000,
+							MaxPort: 2000,
+							Random:  true,
+						},
+						Exec: []Action{SetField("0x1", "ct_mark")},
+					}),
+				},
+				Stats: FlowStats{
+					Duration: 920420008 * time.Millisecond,
+				},
 			},
 		},
 		{
@@ -866,6 +944,11 @@ func TestFlowUnmarshalText(t *testing.T) {
 				Actions: []Action{
 					Resubmit(0, 13),
 				},
+				Stats: FlowStats{
+					Duration: 13265 * time.Millisecond,
+					IdleAge:  13 * time.Second,
+					HardAge:  13 * time.Second,
+				},
 			},
 		},
 		{
@@ -884,6 +967,9 @@ func TestFlowUnmarshalText(t *testing.T) {
 				Actions: []Action{
 					Output(19),
 				},
+				Stats: FlowStats{
+					Duration: 1381314983 * time.Millisecond,
+				},
 			},
 		},
 		{
@@ -902,6 +988,46 @@ func TestFlowUnmarshalText(t *testing.T) {
 				Actions:     []Action{Drop()},
 			},
 		},
+		{
+			desc: "Flow with matches unknown to this package generated by ovs-ofctl dump-flows",
+			s:    " cookie=0x0, duration=9.1s, table=0, n_packets=0, n_bytes=0, priority=100,recirc_id=0x1,ip,reg0=0x10/0xff,nw_src=10.0.0.1,pkt_mark=0x2,ct_label=0x1 actions=output:1",
+			f: &Flow{
+				Priority: 100,
+				Protocol: ProtocolIPv4,
+				Matches: []Match{
+					FieldMatch("recirc_id", "0x1"),
+					RegMatchWithMask(0, 0x10, 0xff),
+					NetworkSource("10.0.0.1"),
+					FieldMatch("pkt_mark", "0x2"),
+					ConnectionTrackingLabel(Uint128{Lo: 0x1}),
+				},
+				Table:   0,
+				Actions: []Action{Output(1)},
+				Stats: FlowStats{
+					Duration: 9100 * time.Millisecond,
+				},
+			},
+		},
+		{
+			desc: "Flow with actions unknown to this package generated by ovs-ofctl dump-flows",
+			s:    " cookie=0x0, duration=9.1s, table=0, n_packets=0, n_bytes=0, priority=100,ip actions=mod_nw_tos:16,dec_ttl,controller(reason=no_match),fin_timeout(idle_timeout=10),dec_nsh_ttl,group:2",
+			f: &Flow{
+				Priority: 100,
+				Protocol: ProtocolIPv4,
+				Table:    0,
+				Actions: []Action{
+					ModNetworkTOS(16),
+					DecTTL(),
+					Controller(ControllerSpec{Reason: ControllerReasonNoMatch}),
+					RawAction("fin_timeout(idle_timeout=10)"),
+					RawAction("dec_nsh_ttl"),
+					OutputGroup(2),
+				},
+				Stats: FlowStats{
+					Duration: 9100 * time.Millisecond,
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -927,6 +1053,175 @@ func TestFlowUnmarshalText(t *testing.T) {
 	}
 }
 
+func TestFlowUnmarshalTextStrict(t *testing.T) {
+	var tests = []struct {
+		desc string
+		s    string
+		err  error
+	}{
+		{
+			desc: "known matches",
+			s:    "priority=10,ip,nw_src=10.0.0.1,actions=drop",
+		},
+		{
+			desc: "unknown match",
+			s:    "priority=10,ip,pkt_mark=0x1,actions=drop",
+			err: &FlowError{
+				Str: "pkt_mark=0x1",
+				Err: ErrUnknownMatch,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := new(Flow).UnmarshalTextStrict([]byte(tt.s))
+			if want, got := tt.err, err; !reflect.DeepEqual(want, got) {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+func TestFlowUnmarshalTextAttributes(t *testing.T) {
+	var tests = []struct {
+		desc string
+		s    string
+		f    *Flow
+	}{
+		{
+			desc: "dump-flows with ages",
+			s:    " cookie=0x0, duration=9215.748s, table=0, n_packets=6, n_bytes=480, idle_age=9206, hard_age=65, priority=820,ip actions=drop",
+			f: &Flow{
+				Priority: 820,
+				Protocol: ProtocolIPv4,
+				Matches:  []Match{},
+				Actions:  []Action{Drop()},
+				Stats: FlowStats{
+					PacketCount: 6,
+					ByteCount:   480,
+					Duration:    9215748 * time.Millisecond,
+					IdleAge:     9206 * time.Second,
+					HardAge:     65 * time.Second,
+				},
+			},
+		},
+		{
+			desc: "dump-flows with hard_age omitted",
+			s:    " cookie=0x0, duration=13.265s, table=12, n_packets=0, n_bytes=0, idle_age=13, priority=1010,tcp actions=drop",
+			f: &Flow{
+				Priority: 1010,
+				Protocol: ProtocolTCPv4,
+				Table:    12,
+				Matches:  []Match{},
+				Actions:  []Action{Drop()},
+				Stats: FlowStats{
+					Duration: 13265 * time.Millisecond,
+					IdleAge:  13 * time.Second,
+					HardAge:  13 * time.Second,
+				},
+			},
+		},
+		{
+			desc: "dump-flows without ages",
+			s:    " cookie=0x0, duration=1.5s, table=0, n_packets=0, n_bytes=0, priority=10,ip actions=drop",
+			f: &Flow{
+				Priority: 10,
+				Protocol: ProtocolIPv4,
+				Matches:  []Match{},
+				Actions:  []Action{Drop()},
+				Stats: FlowStats{
+					Duration: 1500 * time.Millisecond,
+				},
+			},
+		},
+		{
+			desc: "dump-flows with timeouts, flags, and importance",
+			s:    " cookie=0x0, duration=2.001s, table=0, n_packets=0, n_bytes=0, idle_timeout=30, hard_timeout=60, send_flow_rem check_overlap reset_counts importance=5, idle_age=2, hard_age=1, priority=10,ip actions=drop",
+			f: &Flow{
+				Priority:    10,
+				Protocol:    ProtocolIPv4,
+				IdleTimeout: 30,
+				HardTimeout: 60,
+				Importance:  5,
+				Flags: []FlowFlag{
+					FlowFlagSendFlowRemoved,
+					FlowFlagCheckOverlap,
+					FlowFlagResetCounts,
+				},
+				Matches: []Match{},
+				Actions: []Action{Drop()},
+				Stats: FlowStats{
+					Duration: 2001 * time.Millisecond,
+					IdleAge:  2 * time.Second,
+					HardAge:  1 * time.Second,
+				},
+			},
+		},
+		{
+			desc: "flags separated by commas",
+			s:    "priority=10,ip,hard_timeout=60,no_packet_counts,no_byte_counts,actions=drop",
+			f: &Flow{
+				Priority:    10,
+				Protocol:    ProtocolIPv4,
+				HardTimeout: 60,
+				Flags: []FlowFlag{
+					FlowFlagNoPacketCounts,
+					FlowFlagNoByteCounts,
+				},
+				Matches: []Match{},
+				Actions: []Action{Drop()},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			f := new(Flow)
+			if err := f.UnmarshalText([]byte(tt.s)); err != nil {
+				t.Fatalf("failed to unmarshal flow: %v", err)
+			}
+
+			if want, got := tt.f, f; !reflect.DeepEqual(want, got) {
+				t.Fatalf("unexpected Flow:\n- want: %#v\n-  got: %#v",
+					want, got)
+			}
+		})
+	}
+}
+
+func TestFlowUnmarshalTextRoundTrip(t *testing.T) {
+	const s = "priority=100,ip,table=0,recirc_id=0x1,reg0=0x10/0xff,nw_src=10.0.0.1,pkt_mark=0x2,actions=dec_ttl,output:1"
+
+	f := new(Flow)
+	if err := f.UnmarshalText([]byte(s)); err != nil {
+		t.Fatalf("failed to unmarshal flow: %v", err)
+	}
+
+	b, err := f.MarshalText()
+	if err != nil {
+		t.Fatalf("failed to marshal flow: %v", err)
+	}
+
+	want := "priority=100,ip,recirc_id=0x1,reg0=0x10/0xff,nw_src=10.0.0.1,pkt_mark=0x2,table=0,idle_timeout=0,actions=dec_ttl,output:1"
+	if got := string(b); want != got {
+		t.Fatalf("unexpected flow text:\n- want: %q\n-  got: %q",
+			want, got)
+	}
+
+	mb, err := f.MatchFlow().MarshalText()
+	if err != nil {
+		t.Fatalf("failed to marshal match flow: %v", err)
+	}
+
+	want = "ip,recirc_id=0x1,reg0=0x10/0xff,nw_src=10.0.0.1,pkt_mark=0x2,table=0"
+	if got := string(mb); want != got {
+		t.Fatalf("unexpected match flow text:\n- want: %q\n-  got: %q",
+			want, got)
+	}
+}
+
 func TestFlowMatchFlow(t *testing.T) {
 	var tests = []struct {
 		desc string
@@ -1256,6 +1551,40 @@ func TestFlowMatchFlow(t *testing.T) {
 	}
 }
 
+func TestFlowMatchFlowStrict(t *testing.T) {
+	f := &Flow{
+		Priority: 2005,
+		Protocol: ProtocolIPv4,
+		InPort:   1,
+		Matches: []Match{
+			NetworkSource("10.0.0.1"),
+		},
+		Table:   2,
+		Cookie:  0xab,
+		Actions: []Action{Drop()},
+	}
+
+	want := &MatchFlow{
+		Protocol: ProtocolIPv4,
+		InPort:   1,
+		Matches: []Match{
+			NetworkSource("10.0.0.1"),
+		},
+		Table:    2,
+		Priority: 2005,
+		Cookie:   0xab,
+	}
+
+	if got := f.MatchFlowStrict(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected MatchFlow:\n- want: %#v\n-  got: %#v",
+			want, got)
+	}
+
+	if got := f.MatchFlow().Priority; got != 0 {
+		t.Fatalf("unexpected non-strict MatchFlow priority: %d", got)
+	}
+}
+
 // flowsEqual determines if two possible Flows are equal.
 func flowsEqual(a *Flow, b *Flow) bool {
 	// Special case: both nil is OK
@@ -18,6 +18,7 @@ import (
 	"errors"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var (
@@ -29,9 +30,22 @@ var (
 // FlowStats contains a variety of statistics about an Open vSwitch port,
 // including its port ID and numbers about packet receive and transmit
 // operations.
+//
+// Duration, IdleAge, and HardAge are only populated for flows retrieved
+// using 'ovs-ofctl dump-flows', and are zero when Open vSwitch does not
+// report them.  IdleAge and HardAge have a resolution of one second.
 type FlowStats struct {
 	PacketCount uint64
 	ByteCount   uint64
+
+	// Duration is the amount of time the flow has been installed.
+	Duration time.Duration
+
+	// IdleAge is the amount of time since the flow last matched a packet.
+	IdleAge time.Duration
+
+	// HardAge is the amount of time since the flow was added or modified.
+	HardAge time.Duration
 }
 
 // UnmarshalText unmarshals a FlowStats from textual form.
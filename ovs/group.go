@@ -0,0 +1,480 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidGroup is returned when groups from 'ovs-ofctl dump-groups'
+	// do not match the expected output format.
+	ErrInvalidGroup = errors.New("invalid openflow group")
+
+	// ErrInvalidGroupStats is returned when group statistics from
+	// 'ovs-ofctl dump-group-stats' do not match the expected output format.
+	ErrInvalidGroupStats = errors.New("invalid group statistics")
+
+	// errGroupNoBuckets is returned when an indirect or fast failover Group
+	// is marshaled without any buckets.
+	errGroupNoBuckets = errors.New("group must have at least one bucket")
+)
+
+// A GroupType is the type of an OpenFlow group, which determines how its
+// buckets are executed.
+type GroupType string
+
+// GroupType constants which can be used in OpenFlow groups.
+const (
+	// GroupTypeAll executes all buckets in the group.
+	GroupTypeAll GroupType = "all"
+
+	// GroupTypeSelect executes one bucket in the group, chosen by the
+	// group's selection method and bucket weights.
+	GroupTypeSelect GroupType = "select"
+
+	// GroupTypeIndirect executes the one and only bucket in the group.
+	GroupTypeIndirect GroupType = "indirect"
+
+	// GroupTypeFastFailover executes the first live bucket in the group.
+	GroupTypeFastFailover GroupType = "ff"
+)
+
+// A Group is an OpenFlow group, which can be referenced by the OutputGroup action.
+// It can be marshaled to and from its textual form for use with Open vSwitch.
+type Group struct {
+	ID   int
+	Type GroupType
+
+	// SelectionMethod, SelectionMethodParam and Fields configure how
+	// buckets are chosen in a select group.  They are only supported by
+	// OpenFlow 1.5 and later.
+	SelectionMethod      string
+	SelectionMethodParam uint64
+	Fields               []string
+
+	Buckets []Bucket
+}
+
+// A Bucket is a set of actions executed by a Group.
+type Bucket struct {
+	// ID is the bucket's ID.  Bucket IDs are only marshaled if any bucket
+	// in a Group has a non-zero ID, and are only supported by OpenFlow 1.5
+	// and later.
+	ID int
+
+	// Weight is the relative weight of the bucket in a select group.
+	Weight int
+
+	// WatchPort and WatchGroup are the port and group whose liveness
+	// determines the liveness of the bucket in a fast failover group.
+	WatchPort  int
+	WatchGroup int
+
+	Actions []Action
+}
+
+var _ error = &GroupError{}
+
+// A GroupError is an error encountered while marshaling or unmarshaling
+// a Group.
+type GroupError struct {
+	// Str indicates the string, if any, that caused the group to
+	// fail while unmarshaling.
+	Str string
+
+	// Err indicates the error that halted group marshaling or unmarshaling.
+	Err error
+}
+
+// Error returns the string representation of a GroupError.
+func (e *GroupError) Error() string {
+	if e.Str == "" {
+		return e.Err.Error()
+	}
+
+	return fmt.Sprintf("group error due to string %q: %v",
+		e.Str, e.Err)
+}
+
+// Constants used repeatedly while marshaling and unmarshaling groups.
+const (
+	groupID              = "group_id"
+	groupType            = "type"
+	selectionMethod      = "selection_method"
+	selectionMethodParam = "selection_method_param"
+	groupFields          = "fields"
+	bucket               = "bucket"
+	bucketID             = "bucket_id"
+	bucketWeight         = "weight"
+	bucketWatchPort      = "watch_port"
+	bucketWatchGroup     = "watch_group"
+)
+
+// MarshalText marshals a Group into its textual form.
+func (g *Group) MarshalText() ([]byte, error) {
+	if g.ID < 0 {
+		return nil, &GroupError{
+			Err: errGroupNegative,
+		}
+	}
+
+	if (g.Type == GroupTypeIndirect || g.Type == GroupTypeFastFailover) && len(g.Buckets) == 0 {
+		return nil, &GroupError{
+			Err: errGroupNoBuckets,
+		}
+	}
+
+	b := []byte(groupID + "=")
+	b = strconv.AppendInt(b, int64(g.ID), 10)
+
+	if g.Type != "" {
+		b = append(b, ","+groupType+"="...)
+		b = append(b, g.Type...)
+	}
+
+	if g.SelectionMethod != "" {
+		b = append(b, ","+selectionMethod+"="...)
+		b = append(b, g.SelectionMethod...)
+	}
+
+	if g.SelectionMethodParam != 0 {
+		b = append(b, ","+selectionMethodParam+"="...)
+		b = strconv.AppendUint(b, g.SelectionMethodParam, 10)
+	}
+
+	if len(g.Fields) > 0 {
+		b = append(b, ","+groupFields+"("...)
+		b = append(b, strings.Join(g.Fields, ",")...)
+		b = append(b, ')')
+	}
+
+	var withIDs bool
+	for _, bk := range g.Buckets {
+		if bk.ID != 0 {
+			withIDs = true
+			break
+		}
+	}
+
+	for _, bk := range g.Buckets {
+		bb, err := bk.marshalText(withIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		b = append(b, ","+bucket+"="...)
+		b = append(b, bb...)
+	}
+
+	return b, nil
+}
+
+// marshalText marshals a Bucket into its textual form, optionally including
+// its bucket ID.
+func (bk *Bucket) marshalText(withID bool) ([]byte, error) {
+	var ss []string
+
+	if withID {
+		ss = append(ss, bucketID+":"+strconv.Itoa(bk.ID))
+	}
+	if bk.Weight != 0 {
+		ss = append(ss, bucketWeight+":"+strconv.Itoa(bk.Weight))
+	}
+	if bk.WatchPort != 0 {
+		ss = append(ss, bucketWatchPort+":"+strconv.Itoa(bk.WatchPort))
+	}
+	if bk.WatchGroup != 0 {
+		ss = append(ss, bucketWatchGroup+":"+strconv.Itoa(bk.WatchGroup))
+	}
+
+	actions, err := marshalActions(bk.Actions)
+	if err != nil {
+		return nil, err
+	}
+
+	// A bucket with no actions drops packets.
+	ss = append(ss, keyActions+"="+strings.Join(actions, ","))
+
+	return []byte(strings.Join(ss, ",")), nil
+}
+
+// UnmarshalText unmarshals a Group from textual form as output by
+// 'ovs-ofctl dump-groups':
+//  group_id=1,type=select,bucket=weight:100,actions=output:1
+func (g *Group) UnmarshalText(b []byte) error {
+	// Make a copy per documentation for encoding.TextUnmarshaler.
+	s := strings.TrimSpace(string(b))
+
+	*g = Group{}
+
+	var (
+		bk      *Bucket
+		actions []string
+	)
+
+	// finishBucket parses the actions of the current bucket, if any.
+	finishBucket := func() error {
+		if bk == nil {
+			return nil
+		}
+
+		str := strings.Join(actions, ",")
+		out, _, err := newActionParser(strings.NewReader(str)).Parse()
+		if err != nil {
+			return &GroupError{
+				Str: str,
+				Err: err,
+			}
+		}
+
+		bk.Actions = out
+		g.Buckets = append(g.Buckets, *bk)
+		bk, actions = nil, nil
+		return nil
+	}
+
+	for _, f := range splitFields(s) {
+		// Each bucket extends to the next bucket or the end of the group.
+		if strings.HasPrefix(f, bucket+"=") {
+			if err := finishBucket(); err != nil {
+				return err
+			}
+
+			bk = new(Bucket)
+			f = strings.TrimPrefix(f, bucket+"=")
+		}
+
+		if bk != nil {
+			if actions != nil {
+				actions = append(actions, f)
+				continue
+			}
+
+			if err := bk.parseField(f); err != nil {
+				return err
+			}
+			if strings.HasPrefix(f, keyActions+"=") {
+				actions = []string{strings.TrimPrefix(f, keyActions+"=")}
+			}
+			continue
+		}
+
+		if err := g.parseField(f); err != nil {
+			return err
+		}
+	}
+
+	return finishBucket()
+}
+
+// parseField parses a single group field into a Group.
+func (g *Group) parseField(f string) error {
+	if strings.HasPrefix(f, groupFields+"(") && strings.HasSuffix(f, ")") {
+		fields := strings.TrimSuffix(strings.TrimPrefix(f, groupFields+"("), ")")
+		g.Fields = strings.Split(fields, ",")
+		return nil
+	}
+
+	kv := strings.SplitN(f, "=", 2)
+	if len(kv) != 2 {
+		return &GroupError{
+			Str: f,
+			Err: ErrInvalidGroup,
+		}
+	}
+
+	var err error
+	switch kv[0] {
+	case groupID:
+		g.ID, err = strconv.Atoi(kv[1])
+	case groupType:
+		g.Type = GroupType(kv[1])
+		if g.Type == "fast_failover" {
+			g.Type = GroupTypeFastFailover
+		}
+	case selectionMethod:
+		g.SelectionMethod = kv[1]
+	case selectionMethodParam:
+		g.SelectionMethodParam, err = strconv.ParseUint(kv[1], 0, 64)
+	case groupFields:
+		g.Fields = []string{kv[1]}
+	default:
+		err = ErrInvalidGroup
+	}
+	if err != nil {
+		return &GroupError{
+			Str: f,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+// parseField parses a single bucket field into a Bucket.  Fields may use
+// either ':' or '=' to separate keys from values.
+func (bk *Bucket) parseField(f string) error {
+	i := strings.IndexAny(f, ":=")
+	if i == -1 {
+		return &GroupError{
+			Str: f,
+			Err: ErrInvalidGroup,
+		}
+	}
+
+	k, v := f[:i], f[i+1:]
+
+	var err error
+	switch k {
+	case bucketID:
+		bk.ID, err = strconv.Atoi(v)
+	case bucketWeight:
+		bk.Weight, err = strconv.Atoi(v)
+	case bucketWatchPort:
+		bk.WatchPort, err = strconv.Atoi(v)
+	case bucketWatchGroup:
+		bk.WatchGroup, err = strconv.Atoi(v)
+	case keyActions:
+		// Actions are parsed by the caller.
+	default:
+		err = ErrInvalidGroup
+	}
+	if err != nil {
+		return &GroupError{
+			Str: f,
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+// GroupStats contains statistics about an OpenFlow group and its buckets.
+type GroupStats struct {
+	GroupID     int
+	RefCount    uint64
+	PacketCount uint64
+	ByteCount   uint64
+	Buckets     []BucketStats
+}
+
+// BucketStats contains statistics about a bucket in an OpenFlow group.
+type BucketStats struct {
+	PacketCount uint64
+	ByteCount   uint64
+}
+
+// UnmarshalText unmarshals a GroupStats from textual form as output by
+// 'ovs-ofctl dump-group-stats':
+//  group_id=1,duration=3.456s,ref_count=0,packet_count=0,byte_count=0,bucket0:packet_count=0,byte_count=0
+func (g *GroupStats) UnmarshalText(b []byte) error {
+	// Make a copy per documentation for encoding.TextUnmarshaler.
+	s := strings.TrimSpace(string(b))
+
+	// Constants only needed within this method, to avoid polluting the
+	// package namespace with generic names
+	const (
+		refCount    = "ref_count"
+		packetCount = "packet_count"
+		byteCount   = "byte_count"
+	)
+
+	*g = GroupStats{}
+
+	// Counters are stored in the group until the first bucket is found.
+	packets, bytes := &g.PacketCount, &g.ByteCount
+
+	var hasID bool
+	for _, f := range strings.Split(s, ",") {
+		if strings.HasPrefix(f, bucket) {
+			// A new bucket's statistics, such as "bucket0:packet_count=0".
+			i := strings.IndexByte(f, ':')
+			if i == -1 {
+				return ErrInvalidGroupStats
+			}
+
+			g.Buckets = append(g.Buckets, BucketStats{})
+			bs := &g.Buckets[len(g.Buckets)-1]
+			packets, bytes = &bs.PacketCount, &bs.ByteCount
+
+			f = f[i+1:]
+		}
+
+		kv := strings.SplitN(f, "=", 2)
+		if len(kv) != 2 {
+			return ErrInvalidGroupStats
+		}
+
+		var err error
+		switch kv[0] {
+		case groupID:
+			g.GroupID, err = strconv.Atoi(kv[1])
+			hasID = true
+		case refCount:
+			g.RefCount, err = strconv.ParseUint(kv[1], 10, 64)
+		case packetCount:
+			*packets, err = strconv.ParseUint(kv[1], 10, 64)
+		case byteCount:
+			*bytes, err = strconv.ParseUint(kv[1], 10, 64)
+		case duration:
+			// Ignore duration.
+		default:
+			return ErrInvalidGroupStats
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if !hasID {
+		return ErrInvalidGroupStats
+	}
+
+	return nil
+}
+
+// splitFields splits a comma-separated string into fields, ignoring commas
+// within parentheses.
+func splitFields(s string) []string {
+	var (
+		fields []string
+		depth  int
+		start  int
+	)
+
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				fields = append(fields, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	if start < len(s) {
+		fields = append(fields, s[start:])
+	}
+
+	return fields
+}
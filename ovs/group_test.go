@@ -0,0 +1,324 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGroupMarshalText(t *testing.T) {
+	var tests = []struct {
+		desc string
+		g    *Group
+		s    string
+		err  error
+	}{
+		{
+			desc: "negative ID",
+			g: &Group{
+				ID: -1,
+			},
+			err: errGroupNegative,
+		},
+		{
+			desc: "indirect without buckets",
+			g: &Group{
+				ID:   1,
+				Type: GroupTypeIndirect,
+			},
+			err: errGroupNoBuckets,
+		},
+		{
+			desc: "all without buckets",
+			g: &Group{
+				ID:   1,
+				Type: GroupTypeAll,
+			},
+			s: "group_id=1,type=all",
+		},
+		{
+			desc: "select",
+			g: &Group{
+				ID:   1,
+				Type: GroupTypeSelect,
+				Buckets: []Bucket{
+					{
+						Weight:  100,
+						Actions: []Action{Output(1)},
+					},
+					{
+						Weight:  50,
+						Actions: []Action{ModVLANVID(10), Output(2)},
+					},
+				},
+			},
+			s: "group_id=1,type=select,bucket=weight:100,actions=output:1,bucket=weight:50,actions=mod_vlan_vid:10,output:2",
+		},
+		{
+			desc: "select with selection method and bucket IDs",
+			g: &Group{
+				ID:              2,
+				Type:            GroupTypeSelect,
+				SelectionMethod: "hash",
+				Fields:          []string{"ip_src", "nw_proto"},
+				Buckets: []Bucket{
+					{
+						Actions: []Action{Output(1)},
+					},
+					{
+						ID:      1,
+						Actions: []Action{Output(2)},
+					},
+				},
+			},
+			s: "group_id=2,type=select,selection_method=hash,fields(ip_src,nw_proto),bucket=bucket_id:0,actions=output:1,bucket=bucket_id:1,actions=output:2",
+		},
+		{
+			desc: "fast failover",
+			g: &Group{
+				ID:   3,
+				Type: GroupTypeFastFailover,
+				Buckets: []Bucket{
+					{
+						WatchPort: 1,
+						Actions:   []Action{Output(1)},
+					},
+					{
+						WatchGroup: 4,
+						Actions:    []Action{OutputGroup(4)},
+					},
+				},
+			},
+			s: "group_id=3,type=ff,bucket=watch_port:1,actions=output:1,bucket=watch_group:4,actions=group:4",
+		},
+		{
+			desc: "bucket without actions",
+			g: &Group{
+				ID:      4,
+				Type:    GroupTypeIndirect,
+				Buckets: []Bucket{{}},
+			},
+			s: "group_id=4,type=indirect,bucket=actions=",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			b, err := tt.g.MarshalText()
+			if err != nil {
+				gerr, ok := err.(*GroupError)
+				if !ok || gerr.Err != tt.err {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				return
+			}
+			if tt.err != nil {
+				t.Fatalf("expected error %v, but none occurred", tt.err)
+			}
+
+			if diff := cmp.Diff(tt.s, string(b)); diff != "" {
+				t.Fatalf("unexpected group text (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGroupUnmarshalText(t *testing.T) {
+	var tests = []struct {
+		desc string
+		s    string
+		g    *Group
+		ok   bool
+	}{
+		{
+			desc: "unknown field",
+			s:    "group_id=1,type=all,foo=bar",
+		},
+		{
+			desc: "bad group ID",
+			s:    "group_id=foo,type=all",
+		},
+		{
+			desc: "bad bucket weight",
+			s:    "group_id=1,type=select,bucket=weight:foo,actions=output:1",
+		},
+		{
+			desc: "bad bucket actions",
+			s:    "group_id=1,type=select,bucket=actions=mod_tp_dst:foo",
+		},
+		{
+			desc: "all",
+			s:    " group_id=1,type=all,bucket=actions=output:1,bucket=actions=output:2",
+			g: &Group{
+				ID:   1,
+				Type: GroupTypeAll,
+				Buckets: []Bucket{
+					{Actions: []Action{Output(1)}},
+					{Actions: []Action{Output(2)}},
+				},
+			},
+			ok: true,
+		},
+		{
+			desc: "select OpenFlow 1.5",
+			s:    "group_id=2,type=select,selection_method=hash,fields(ip_src,nw_proto),bucket=bucket_id:0,weight:100,actions=ct(commit,zone=1),output:1,bucket=bucket_id:1,weight:100,actions=output:2",
+			g: &Group{
+				ID:              2,
+				Type:            GroupTypeSelect,
+				SelectionMethod: "hash",
+				Fields:          []string{"ip_src", "nw_proto"},
+				Buckets: []Bucket{
+					{
+						Weight: 100,
+						Actions: []Action{
+							ConnectionTracking("commit,zone=1"),
+							Output(1),
+						},
+					},
+					{
+						ID:      1,
+						Weight:  100,
+						Actions: []Action{Output(2)},
+					},
+				},
+			},
+			ok: true,
+		},
+		{
+			desc: "fast failover",
+			s:    "group_id=3,type=fast_failover,bucket=watch_port:1,actions=output:1,bucket=watch_group:4,actions=group:4",
+			g: &Group{
+				ID:   3,
+				Type: GroupTypeFastFailover,
+				Buckets: []Bucket{
+					{
+						WatchPort: 1,
+						Actions:   []Action{Output(1)},
+					},
+					{
+						WatchGroup: 4,
+						Actions:    []Action{OutputGroup(4)},
+					},
+				},
+			},
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			g := new(Group)
+			err := g.UnmarshalText([]byte(tt.s))
+			if err != nil && tt.ok {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.ok {
+				if err == nil {
+					t.Fatal("expected an error, but none occurred")
+				}
+
+				return
+			}
+
+			// Compare text forms, as Actions contain unexported fields.
+			want, err := tt.g.MarshalText()
+			if err != nil {
+				t.Fatalf("failed to marshal group: %v", err)
+			}
+			got, err := g.MarshalText()
+			if err != nil {
+				t.Fatalf("failed to marshal group: %v", err)
+			}
+
+			if diff := cmp.Diff(string(want), string(got)); diff != "" {
+				t.Fatalf("unexpected group (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGroupStatsUnmarshalText(t *testing.T) {
+	var tests = []struct {
+		desc  string
+		s     string
+		stats *GroupStats
+		ok    bool
+	}{
+		{
+			desc: "empty string",
+		},
+		{
+			desc: "group_id missing",
+			s:    "ref_count=0,packet_count=0,byte_count=0",
+		},
+		{
+			desc: "unknown field",
+			s:    "group_id=1,ref_count=0,packet_count=0,byte_count=0,foo=0",
+		},
+		{
+			desc: "bad packet count",
+			s:    "group_id=1,ref_count=0,packet_count=foo,byte_count=0",
+		},
+		{
+			desc: "bad bucket",
+			s:    "group_id=1,ref_count=0,packet_count=0,byte_count=0,bucket0",
+		},
+		{
+			desc: "OK",
+			s:    " group_id=1,duration=3.456s,ref_count=2,packet_count=30,byte_count=3000,bucket0:packet_count=10,byte_count=1000,bucket1:packet_count=20,byte_count=2000",
+			stats: &GroupStats{
+				GroupID:     1,
+				RefCount:    2,
+				PacketCount: 30,
+				ByteCount:   3000,
+				Buckets: []BucketStats{
+					{
+						PacketCount: 10,
+						ByteCount:   1000,
+					},
+					{
+						PacketCount: 20,
+						ByteCount:   2000,
+					},
+				},
+			},
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			stats := new(GroupStats)
+			err := stats.UnmarshalText([]byte(tt.s))
+			if err != nil && tt.ok {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.ok {
+				if err == nil {
+					t.Fatal("expected an error, but none occurred")
+				}
+
+				return
+			}
+
+			if diff := cmp.Diff(tt.stats, stats); diff != "" {
+				t.Fatalf("unexpected group stats (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
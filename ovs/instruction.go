@@ -0,0 +1,243 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Possible errors which may be encountered while marshaling instructions.
+var (
+	// errGotoTableInvalid is returned when GotoTable is used with a table
+	// outside of the range of valid OpenFlow tables.
+	errGotoTableInvalid = errors.New("goto_table table must be between 0 and 254")
+
+	// errGotoTableBackwards is returned when GotoTable refers to a table
+	// which is not after the table of the Flow containing it.
+	errGotoTableBackwards = errors.New("goto_table table must be greater than the flow's table")
+
+	// errInstructionOrder is returned when instructions appear out of order
+	// in the actions of a Flow.
+	errInstructionOrder = errors.New("instructions must be in the order: meter, actions, clear_actions, write_actions, write_metadata, goto_table")
+
+	// errInstructionRepeated is returned when an instruction other than
+	// the apply actions appears more than once in the actions of a Flow.
+	errInstructionRepeated = errors.New("instruction may not be specified more than once")
+
+	// errWriteActionsInstruction is returned when WriteActions is used with
+	// an instruction instead of an action.
+	errWriteActionsInstruction = errors.New("write_actions may only contain actions, not instructions")
+)
+
+const (
+	actionClearActions = "clear_actions"
+
+	patGotoTable         = "goto_table:%d"
+	patWriteMetadata     = "write_metadata:%#x"
+	patWriteMetadataMask = "write_metadata:%#x/%#x"
+	patWriteActions      = "write_actions(%s)"
+)
+
+// OpenFlow instruction types, in the order in which they must appear in
+// the actions of a Flow.  Open vSwitch applies the same ordering rules
+// when parsing flows.
+const (
+	instMeter = iota
+	instApplyActions
+	instClearActions
+	instWriteActions
+	instWriteMetadata
+	instGotoTable
+)
+
+// GotoTable is an OpenFlow instruction which continues processing of the
+// packet in the specified table.  table must be between 0 and 254, and
+// must be greater than the table of the Flow which contains it.
+//
+// Instructions other than the meter instruction must appear after all other
+// actions in a Flow, in the order: ClearActions, WriteActions, WriteMetadata,
+// GotoTable.  The meter instruction, applied using ApplyMeter, must appear
+// before all other actions.
+func GotoTable(table int) Action {
+	return &gotoTableAction{
+		table: table,
+	}
+}
+
+// A gotoTableAction is an Action which is used by GotoTable.
+type gotoTableAction struct {
+	table int
+}
+
+// MarshalText implements Action.
+func (a *gotoTableAction) MarshalText() ([]byte, error) {
+	if a.table < 0 || a.table > 254 {
+		return nil, errGotoTableInvalid
+	}
+
+	return bprintf(patGotoTable, a.table), nil
+}
+
+// GoString implements Action.
+func (a *gotoTableAction) GoString() string {
+	return fmt.Sprintf("ovs.GotoTable(%d)", a.table)
+}
+
+// WriteMetadata is an OpenFlow instruction which writes value to the bits of
+// the metadata field which are set in mask.  If mask is zero or all ones,
+// the entire metadata field is written.
+func WriteMetadata(value, mask uint64) Action {
+	return &writeMetadataAction{
+		value: value,
+		mask:  mask,
+	}
+}
+
+// A writeMetadataAction is an Action which is used by WriteMetadata.
+type writeMetadataAction struct {
+	value uint64
+	mask  uint64
+}
+
+// MarshalText implements Action.
+func (a *writeMetadataAction) MarshalText() ([]byte, error) {
+	if a.mask == 0 || a.mask == ^uint64(0) {
+		return bprintf(patWriteMetadata, a.value), nil
+	}
+
+	return bprintf(patWriteMetadataMask, a.value, a.mask), nil
+}
+
+// GoString implements Action.
+func (a *writeMetadataAction) GoString() string {
+	return fmt.Sprintf("ovs.WriteMetadata(%#x, %#x)", a.value, a.mask)
+}
+
+// WriteActions is an OpenFlow instruction which merges the specified actions
+// into the action set of the packet.  The action set is executed when
+// processing of the packet stops without a GotoTable instruction.
+func WriteActions(actions ...Action) Action {
+	return &writeActionsAction{
+		actions: actions,
+	}
+}
+
+// A writeActionsAction is an Action which is used by WriteActions.
+type writeActionsAction struct {
+	actions []Action
+}
+
+// Actions implements CompositeAction.
+func (a *writeActionsAction) Actions() []Action {
+	return a.actions
+}
+
+// MarshalText implements Action.
+func (a *writeActionsAction) MarshalText() ([]byte, error) {
+	for _, aa := range a.actions {
+		if instructionType(aa) != instApplyActions {
+			return nil, errWriteActionsInstruction
+		}
+	}
+
+	actions, err := marshalActions(a.actions)
+	if err != nil {
+		return nil, err
+	}
+
+	return bprintf(patWriteActions, strings.Join(actions, ",")), nil
+}
+
+// GoString implements Action.
+func (a *writeActionsAction) GoString() string {
+	return "ovs.WriteActions(" + goStringActions(a.actions) + ")"
+}
+
+// ClearActions is an OpenFlow instruction which clears the action set of
+// the packet.
+func ClearActions() Action {
+	return &textAction{
+		action: actionClearActions,
+	}
+}
+
+// instructionType returns the OpenFlow instruction type of an Action.
+// Actions which are not instructions are part of the apply actions
+// instruction.
+func instructionType(a Action) int {
+	switch a := a.(type) {
+	case *meterAction:
+		return instMeter
+	case *textAction:
+		if a.action == actionClearActions {
+			return instClearActions
+		}
+	case *writeActionsAction:
+		return instWriteActions
+	case *writeMetadataAction:
+		return instWriteMetadata
+	case *gotoTableAction:
+		return instGotoTable
+	}
+
+	return instApplyActions
+}
+
+// verifyInstructions verifies that the instructions within the actions of
+// a Flow in the specified table appear in the order required by OpenFlow.
+func verifyInstructions(table int, actions []Action) error {
+	// No instruction precedes the first action.
+	prev := instMeter - 1
+	for _, a := range actions {
+		inst := instructionType(a)
+		switch {
+		case inst < prev:
+			return errInstructionOrder
+		case inst == prev && inst != instApplyActions:
+			return errInstructionRepeated
+		}
+		prev = inst
+
+		if g, ok := a.(*gotoTableAction); ok && g.table <= table {
+			return errGotoTableBackwards
+		}
+	}
+
+	return nil
+}
+
+// parseWriteMetadata parses the arguments of a write_metadata instruction
+// of the form "write_metadata:value[/mask]".
+func parseWriteMetadata(args string) (Action, error) {
+	mask := ^uint64(0)
+	ss := strings.SplitN(args, "/", 2)
+	if len(ss) == 2 {
+		m, err := strconv.ParseUint(ss[1], 0, 64)
+		if err != nil {
+			return nil, err
+		}
+		mask = m
+	}
+
+	value, err := strconv.ParseUint(ss[0], 0, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return WriteMetadata(value, mask), nil
+}
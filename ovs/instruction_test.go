@@ -0,0 +1,273 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"testing"
+)
+
+func TestInstructions(t *testing.T) {
+	var tests = []struct {
+		desc   string
+		a      Action
+		action string
+		err    error
+	}{
+		{
+			desc:   "goto_table",
+			a:      GotoTable(10),
+			action: "goto_table:10",
+		},
+		{
+			desc: "goto_table negative table",
+			a:    GotoTable(-1),
+			err:  errGotoTableInvalid,
+		},
+		{
+			desc: "goto_table table too large",
+			a:    GotoTable(255),
+			err:  errGotoTableInvalid,
+		},
+		{
+			desc:   "write_metadata",
+			a:      WriteMetadata(0x1, 0),
+			action: "write_metadata:0x1",
+		},
+		{
+			desc:   "write_metadata with all ones mask",
+			a:      WriteMetadata(0x1, 0xffffffffffffffff),
+			action: "write_metadata:0x1",
+		},
+		{
+			desc:   "write_metadata with mask",
+			a:      WriteMetadata(0x100, 0xff00),
+			action: "write_metadata:0x100/0xff00",
+		},
+		{
+			desc:   "write_actions",
+			a:      WriteActions(SetField("0x1", "ct_mark"), Output(1)),
+			action: "write_actions(set_field:0x1->ct_mark,output:1)",
+		},
+		{
+			desc: "write_actions with instruction",
+			a:    WriteActions(Output(1), ClearActions()),
+			err:  errWriteActionsInstruction,
+		},
+		{
+			desc: "write_actions with meter",
+			a:    WriteActions(ApplyMeter(1)),
+			err:  errWriteActionsInstruction,
+		},
+		{
+			desc:   "clear_actions",
+			a:      ClearActions(),
+			action: "clear_actions",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			action, err := tt.a.MarshalText()
+
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if err != nil {
+				return
+			}
+
+			if want, got := tt.action, string(action); want != got {
+				t.Fatalf("unexpected Action:\n- want: %q\n-  got: %q",
+					want, got)
+			}
+		})
+	}
+}
+
+func TestFlowMarshalTextInstructions(t *testing.T) {
+	var tests = []struct {
+		desc string
+		f    *Flow
+		s    string
+		err  error
+	}{
+		{
+			desc: "all instructions in order",
+			f: &Flow{
+				Table: 1,
+				Actions: []Action{
+					ApplyMeter(1),
+					SetField("0x1", "ct_mark"),
+					ClearActions(),
+					WriteActions(Output(1)),
+					WriteMetadata(0x1, 0xff),
+					GotoTable(2),
+				},
+			},
+			s: "priority=0,table=1,idle_timeout=0,actions=meter:1,set_field:0x1->ct_mark,clear_actions,write_actions(output:1),write_metadata:0x1/0xff,goto_table:2",
+		},
+		{
+			desc: "instructions only",
+			f: &Flow{
+				Actions: []Action{
+					WriteMetadata(0x1, 0),
+					GotoTable(1),
+				},
+			},
+			s: "priority=0,table=0,idle_timeout=0,actions=write_metadata:0x1,goto_table:1",
+		},
+		{
+			desc: "action after instruction",
+			f: &Flow{
+				Actions: []Action{
+					GotoTable(1),
+					Output(1),
+				},
+			},
+			err: &FlowError{
+				Err: errInstructionOrder,
+			},
+		},
+		{
+			desc: "instructions out of order",
+			f: &Flow{
+				Actions: []Action{
+					WriteActions(Output(1)),
+					ClearActions(),
+				},
+			},
+			err: &FlowError{
+				Err: errInstructionOrder,
+			},
+		},
+		{
+			desc: "instruction repeated",
+			f: &Flow{
+				Actions: []Action{
+					WriteMetadata(0x1, 0),
+					WriteMetadata(0x2, 0),
+				},
+			},
+			err: &FlowError{
+				Err: errInstructionRepeated,
+			},
+		},
+		{
+			desc: "meter only",
+			f: &Flow{
+				Actions: []Action{
+					ApplyMeter(1),
+				},
+			},
+			s: "priority=0,table=0,idle_timeout=0,actions=meter:1",
+		},
+		{
+			desc: "meter after action",
+			f: &Flow{
+				Actions: []Action{
+					Output(1),
+					ApplyMeter(1),
+				},
+			},
+			err: &FlowError{
+				Err: errInstructionOrder,
+			},
+		},
+		{
+			desc: "meter after instruction",
+			f: &Flow{
+				Actions: []Action{
+					WriteMetadata(0x1, 0),
+					ApplyMeter(1),
+				},
+			},
+			err: &FlowError{
+				Err: errInstructionOrder,
+			},
+		},
+		{
+			desc: "meter repeated",
+			f: &Flow{
+				Actions: []Action{
+					ApplyMeter(1),
+					ApplyMeter(2),
+				},
+			},
+			err: &FlowError{
+				Err: errInstructionRepeated,
+			},
+		},
+		{
+			desc: "goto_table to earlier table",
+			f: &Flow{
+				Table: 2,
+				Actions: []Action{
+					GotoTable(1),
+				},
+			},
+			err: &FlowError{
+				Err: errGotoTableBackwards,
+			},
+		},
+		{
+			desc: "goto_table to same table",
+			f: &Flow{
+				Table: 2,
+				Actions: []Action{
+					GotoTable(2),
+				},
+			},
+			err: &FlowError{
+				Err: errGotoTableBackwards,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			b, err := tt.f.MarshalText()
+			if want, got := tt.err, err; !flowErrorEqual(want, got) {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+
+			if want, got := tt.s, string(b); want != got {
+				t.Fatalf("unexpected Flow text:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+func TestFlowUnmarshalTextInstructions(t *testing.T) {
+	const s = " cookie=0x0, duration=1.5s, table=0, n_packets=0, n_bytes=0, priority=10,ip actions=meter:1,dec_ttl,clear_actions,write_actions(output:2),write_metadata:0x1/0xff,goto_table:1"
+
+	f := new(Flow)
+	if err := f.UnmarshalText([]byte(s)); err != nil {
+		t.Fatalf("failed to unmarshal flow: %v", err)
+	}
+
+	b, err := f.MarshalText()
+	if err != nil {
+		t.Fatalf("failed to marshal flow: %v", err)
+	}
+
+	want := "priority=10,ip,table=0,idle_timeout=0,actions=meter:1,dec_ttl,clear_actions,write_actions(output:2),write_metadata:0x1/0xff,goto_table:1"
+	if got := string(b); want != got {
+		t.Fatalf("unexpected flow text:\n- want: %q\n-  got: %q",
+			want, got)
+	}
+}
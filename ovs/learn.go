@@ -0,0 +1,254 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Possible errors which may be encountered while working with learned flows.
+var (
+	// errLearnMatchFieldEmpty is returned when LearnMatchField is used
+	// with an empty destination field.
+	errLearnMatchFieldEmpty = errors.New("learn match field must not be empty")
+
+	// errLearnSpecUnknown is returned when a learn action contains a
+	// specification which is not known to this package.
+	errLearnSpecUnknown = errors.New("unknown learn specification")
+)
+
+// Default values applied by Open vSwitch to attributes omitted from a
+// learn action, which are also omitted from its output in flow dumps.
+const (
+	learnDefaultPriority = 32768
+	learnDefaultTable    = 1
+)
+
+// LearnMatchField is a match specification for a LearnedFlow which matches
+// field dst in the learned flow against the value of field src in the packet
+// which triggered the Learn action, as in:
+//  NXM_OF_ETH_DST[]=NXM_OF_ETH_SRC[]
+//
+// If src is empty, dst is matched against its own value in the packet,
+// as in:
+//  NXM_OF_VLAN_TCI[0..11]
+//
+// Matches against immediate values may be specified using the other Match
+// functions in this package, or using FieldMatch.
+func LearnMatchField(dst, src string) Match {
+	return &learnMatchField{
+		dst: dst,
+		src: src,
+	}
+}
+
+// A learnMatchField is a Match which is used by LearnMatchField.
+type learnMatchField struct {
+	dst string
+	src string
+}
+
+var _ Match = &learnMatchField{}
+
+// GoString implements Match.
+func (m *learnMatchField) GoString() string {
+	return fmt.Sprintf("ovs.LearnMatchField(%q, %q)", m.dst, m.src)
+}
+
+// MarshalText implements Match.
+func (m *learnMatchField) MarshalText() ([]byte, error) {
+	if m.dst == "" {
+		return nil, errLearnMatchFieldEmpty
+	}
+
+	if m.src == "" {
+		return []byte(m.dst), nil
+	}
+
+	return bprintf("%s=%s", m.dst, m.src), nil
+}
+
+// UnmarshalText unmarshals the arguments of a learn action, as found
+// between its parentheses in flow dumps, into a LearnedFlow.  Open vSwitch
+// omits the priority and table of a learned flow when they are set to their
+// defaults of 32768 and 1, so Priority and Table are set to those defaults
+// unless present in the text.
+func (f *LearnedFlow) UnmarshalText(b []byte) error {
+	*f = LearnedFlow{
+		Priority: learnDefaultPriority,
+		Table:    learnDefaultTable,
+	}
+
+	for _, s := range splitFields(string(b)) {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		// Learned actions use a colon rather than an equals sign, as in
+		// "load:NXM_OF_IN_PORT[]->NXM_NX_REG0[0..15]".
+		if name, args, ok := cutLearnAction(s); ok {
+			switch name {
+			case "load":
+				src, dst, err := parseArrow(args)
+				if err != nil {
+					return err
+				}
+				f.Actions = append(f.Actions, Load(src, dst))
+			case "output":
+				f.Actions = append(f.Actions, OutputField(args))
+			default:
+				return &FlowError{
+					Str: s,
+					Err: errLearnSpecUnknown,
+				}
+			}
+
+			continue
+		}
+
+		kv := strings.SplitN(s, "=", 2)
+		if len(kv) == 1 {
+			switch s {
+			case deleteLearned:
+				f.DeleteLearned = true
+			case string(FlowFlagSendFlowRemoved):
+				f.SendFlowRemoved = true
+			default:
+				// A field matched against its own value in the packet.
+				f.Matches = append(f.Matches, LearnMatchField(s, ""))
+			}
+
+			continue
+		}
+
+		key, value := kv[0], kv[1]
+		if err := f.unmarshalAttribute(key, value); err != errLearnSpecUnknown {
+			if err != nil {
+				return &FlowError{
+					Str: s,
+					Err: err,
+				}
+			}
+
+			continue
+		}
+
+		// Fields in the packet are always formatted with brackets by
+		// Open vSwitch, as in "NXM_OF_ETH_SRC[]".
+		if strings.HasSuffix(value, "]") && strings.Contains(value, "[") {
+			f.Matches = append(f.Matches, LearnMatchField(key, value))
+			continue
+		}
+
+		// The value may also be a field name without brackets, which
+		// cannot be parsed as a known match, so preserve it verbatim.
+		match, err := parseMatch(key, value)
+		if err != nil || match == nil {
+			match = FieldMatch(key, value)
+		}
+
+		f.Matches = append(f.Matches, match)
+	}
+
+	return nil
+}
+
+// unmarshalAttribute unmarshals a key/value attribute of a learned flow
+// into f.  errLearnSpecUnknown is returned if key is not an attribute.
+func (f *LearnedFlow) unmarshalAttribute(key, value string) error {
+	var dst *int
+	switch key {
+	case priority:
+		dst = &f.Priority
+	case table:
+		dst = &f.Table
+	case idleTimeout:
+		dst = &f.IdleTimeout
+	case hardTimeout:
+		dst = &f.HardTimeout
+	case finIdleTimeout:
+		dst = &f.FinIdleTimeout
+	case finHardTimeout:
+		dst = &f.FinHardTimeout
+	case limit:
+		dst = &f.Limit
+	case inPort:
+		// A field copy such as "in_port=NXM_OF_IN_PORT[]" is a match.
+		if value == portLOCAL {
+			f.InPort = PortLOCAL
+			return nil
+		}
+		if strings.Contains(value, "[") {
+			return errLearnSpecUnknown
+		}
+		dst = &f.InPort
+	case cookie:
+		c, err := strconv.ParseUint(value, 0, 64)
+		if err != nil {
+			return err
+		}
+		f.Cookie = c
+		return nil
+	case resultDst:
+		f.ResultDst = value
+		return nil
+	default:
+		return errLearnSpecUnknown
+	}
+
+	v, err := strconv.ParseInt(value, 10, 0)
+	if err != nil {
+		return err
+	}
+	*dst = int(v)
+
+	return nil
+}
+
+// cutLearnAction splits a learned action of the form "name:args".  ok is
+// false if s is not of that form.
+func cutLearnAction(s string) (name string, args string, ok bool) {
+	i := strings.IndexAny(s, ":=")
+	if i == -1 || s[i] != ':' {
+		return "", "", false
+	}
+
+	return s[:i], s[i+1:], true
+}
+
+// parseLearn parses the arguments of a learn action.  Learn actions which
+// contain specifications unknown to this package, or which have no learned
+// actions, are returned as a RawAction.
+func parseLearn(args string, raw string) (Action, error) {
+	f := new(LearnedFlow)
+	if err := f.UnmarshalText([]byte(args)); err != nil {
+		if fe, ok := err.(*FlowError); ok && fe.Err == errLearnSpecUnknown {
+			return RawAction(raw), nil
+		}
+
+		return nil, err
+	}
+
+	// LearnedFlow requires at least one action when marshaled.
+	if len(f.Actions) == 0 {
+		return RawAction(raw), nil
+	}
+
+	return Learn(f), nil
+}
@@ -0,0 +1,195 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLearnMatchField(t *testing.T) {
+	var tests = []struct {
+		desc  string
+		m     Match
+		match string
+		err   error
+	}{
+		{
+			desc:  "field copy",
+			m:     LearnMatchField("NXM_OF_ETH_DST[]", "NXM_OF_ETH_SRC[]"),
+			match: "NXM_OF_ETH_DST[]=NXM_OF_ETH_SRC[]",
+		},
+		{
+			desc:  "same field",
+			m:     LearnMatchField("NXM_OF_VLAN_TCI[0..11]", ""),
+			match: "NXM_OF_VLAN_TCI[0..11]",
+		},
+		{
+			desc: "empty field",
+			m:    LearnMatchField("", "NXM_OF_ETH_SRC[]"),
+			err:  errLearnMatchFieldEmpty,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			match, err := tt.m.MarshalText()
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if err != nil {
+				return
+			}
+
+			if want, got := tt.match, string(match); want != got {
+				t.Fatalf("unexpected Match:\n- want: %q\n-  got: %q",
+					want, got)
+			}
+		})
+	}
+}
+
+func TestLearnedFlowUnmarshalText(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		s       string
+		f       *LearnedFlow
+		invalid bool
+	}{
+		{
+			desc: "defaults",
+			s:    "NXM_OF_ETH_DST[]=NXM_OF_ETH_SRC[],output:NXM_OF_IN_PORT[]",
+			f: &LearnedFlow{
+				Priority: 32768,
+				Table:    1,
+				Matches: []Match{
+					LearnMatchField("NXM_OF_ETH_DST[]", "NXM_OF_ETH_SRC[]"),
+				},
+				Actions: []Action{
+					OutputField("NXM_OF_IN_PORT[]"),
+				},
+			},
+		},
+		{
+			desc: "in_port field copy and immediate matches",
+			s:    "table=2,in_port=NXM_OF_IN_PORT[],vlan_tci,dl_type=0x0800,nw_proto=ip_proto,load:NXM_NX_REG0[]->NXM_NX_REG1[]",
+			f: &LearnedFlow{
+				Priority: 32768,
+				Table:    2,
+				Matches: []Match{
+					LearnMatchField("in_port", "NXM_OF_IN_PORT[]"),
+					LearnMatchField("vlan_tci", ""),
+					DataLinkType(0x0800),
+					FieldMatch("nw_proto", "ip_proto"),
+				},
+				Actions: []Action{
+					Load("NXM_NX_REG0[]", "NXM_NX_REG1[]"),
+				},
+			},
+		},
+		{
+			desc: "in_port LOCAL",
+			s:    "in_port=LOCAL,output:NXM_OF_IN_PORT[]",
+			f: &LearnedFlow{
+				Priority: 32768,
+				Table:    1,
+				InPort:   PortLOCAL,
+				Actions: []Action{
+					OutputField("NXM_OF_IN_PORT[]"),
+				},
+			},
+		},
+		{
+			desc:    "bad cookie",
+			s:       "cookie=foo,output:NXM_OF_IN_PORT[]",
+			invalid: true,
+		},
+		{
+			desc:    "unknown specification",
+			s:       "foo:bar,output:NXM_OF_IN_PORT[]",
+			invalid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			f := new(LearnedFlow)
+			err := f.UnmarshalText([]byte(tt.s))
+			if err != nil && !tt.invalid {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.invalid {
+				if err == nil {
+					t.Fatal("expected an error, but none occurred")
+				}
+				return
+			}
+
+			if want, got := Learn(tt.f).GoString(), Learn(f).GoString(); want != got {
+				t.Fatalf("unexpected LearnedFlow:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+func TestFlowUnmarshalTextLearn(t *testing.T) {
+	s := "cookie=0x0, duration=5.2s, table=0, n_packets=0, n_bytes=0, priority=10,in_port=1 actions=learn(table=10,NXM_OF_VLAN_TCI[0..11],NXM_OF_ETH_DST[]=NXM_OF_ETH_SRC[],output:NXM_OF_IN_PORT[]),resubmit(,10)"
+
+	f := new(Flow)
+	if err := f.UnmarshalText([]byte(s)); err != nil {
+		t.Fatalf("failed to unmarshal flow: %v", err)
+	}
+
+	want := []Action{
+		Learn(&LearnedFlow{
+			Priority: 32768,
+			Table:    10,
+			Matches: []Match{
+				LearnMatchField("NXM_OF_VLAN_TCI[0..11]", ""),
+				LearnMatchField("NXM_OF_ETH_DST[]", "NXM_OF_ETH_SRC[]"),
+			},
+			Actions: []Action{
+				OutputField("NXM_OF_IN_PORT[]"),
+			},
+		}),
+		Resubmit(0, 10),
+	}
+
+	if want, got := goStringActions(want), goStringActions(f.Actions); want != got {
+		t.Fatalf("unexpected actions:\n- want: %v\n-  got: %v",
+			want, got)
+	}
+
+	// The learned flow must be usable when marshaling the flow again.
+	if _, err := f.MarshalText(); err != nil {
+		t.Fatalf("failed to marshal flow: %v", err)
+	}
+
+	// Unmarshaling the marshaled learn action must produce the same flow.
+	b, err := f.Actions[0].MarshalText()
+	if err != nil {
+		t.Fatalf("failed to marshal learn action: %v", err)
+	}
+	a, err := parseAction(string(b))
+	if err != nil {
+		t.Fatalf("failed to parse learn action: %v", err)
+	}
+	if !reflect.DeepEqual(a, f.Actions[0]) {
+		t.Fatalf("unexpected learn action after round trip:\n- want: %#v\n-  got: %#v",
+			f.Actions[0], a)
+	}
+}
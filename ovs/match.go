@@ -17,8 +17,10 @@ package ovs
 import (
 	"bytes"
 	"encoding"
+	"encoding/hex"
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 )
 
@@ -36,8 +38,16 @@ const (
 	arpTHA      = "arp_tha"
 	arpTPA      = "arp_tpa"
 	conjID      = "conj_id"
+	ctIPv6DST   = "ct_ipv6_dst"
+	ctIPv6SRC   = "ct_ipv6_src"
+	ctLabel     = "ct_label"
 	ctMark      = "ct_mark"
+	ctNwDST     = "ct_nw_dst"
+	ctNwProto   = "ct_nw_proto"
+	ctNwSRC     = "ct_nw_src"
 	ctState     = "ct_state"
+	ctTpDST     = "ct_tp_dst"
+	ctTpSRC     = "ct_tp_src"
 	ctZone      = "ct_zone"
 	dlDST       = "dl_dst"
 	dlSRC       = "dl_src"
@@ -53,6 +63,10 @@ const (
 	ipv6Label   = "ipv6_label"
 	ipv6SRC     = "ipv6_src"
 	metadata    = "metadata"
+	mplsBOS     = "mpls_bos"
+	mplsLabel   = "mpls_label"
+	mplsTC      = "mpls_tc"
+	mplsTTL     = "mpls_ttl"
 	ndSLL       = "nd_sll"
 	ndTarget    = "nd_target"
 	ndTLL       = "nd_tll"
@@ -62,6 +76,9 @@ const (
 	nwSRC       = "nw_src"
 	nwTOS       = "nw_tos"
 	nwTTL       = "nw_ttl"
+	nshContext  = "nsh_c"
+	nshSI       = "nsh_si"
+	nshSPI      = "nsh_spi"
 	tcpFlags    = "tcp_flags"
 	tpDST       = "tp_dst"
 	tpSRC       = "tp_src"
@@ -70,6 +87,7 @@ const (
 	tunGbpFlags = "tun_gbp_flags"
 	tunGbpID    = "tun_gbp_id"
 	tunID       = "tun_id"
+	tunMetadata = "tun_metadata"
 	tunSRC      = "tun_src"
 	tunTOS      = "tun_tos"
 	tunTTL      = "tun_ttl"
@@ -112,6 +130,18 @@ func DataLinkDestination(addr string) Match {
 const (
 	// ethernetAddrLen is the length in bytes of an ethernet hardware address.
 	ethernetAddrLen = 6
+
+	// mplsLabelMax is the maximum value of a 20-bit MPLS label.
+	mplsLabelMax = 0xfffff
+
+	// nshSPIMax is the maximum value of a 24-bit NSH service path
+	// identifier.
+	nshSPIMax = 0xffffff
+
+	// tunMetadataFields is the number of tun_metadata fields, and
+	// tunMetadataMaxLen is the maximum length in bytes of their values.
+	tunMetadataFields = 64
+	tunMetadataMaxLen = 124
 )
 
 var _ Match = &dataLinkMatch{}
@@ -1289,6 +1319,238 @@ func (m *connectionTrackingZoneMatch) GoString() string {
 	return fmt.Sprintf("ovs.ConnectionTrackingZone(%d)", m.zone)
 }
 
+// ConnectionTrackingLabel matches a 128-bit metadata label set on a
+// connection by the ct action.
+func ConnectionTrackingLabel(label Uint128) Match {
+	return &connectionTrackingLabelMatch{
+		label: label,
+	}
+}
+
+// ConnectionTrackingLabelWithMask matches a 128-bit metadata label set on a
+// connection by the ct action, in the bits set in mask.
+func ConnectionTrackingLabelWithMask(label, mask Uint128) Match {
+	return &connectionTrackingLabelMatch{
+		label:  label,
+		mask:   mask,
+		masked: true,
+	}
+}
+
+var _ Match = &connectionTrackingLabelMatch{}
+
+// A connectionTrackingLabelMatch is a Match returned by ConnectionTrackingLabel
+// and ConnectionTrackingLabelWithMask.
+type connectionTrackingLabelMatch struct {
+	label  Uint128
+	mask   Uint128
+	masked bool
+}
+
+// MarshalText implements Match.
+func (m *connectionTrackingLabelMatch) MarshalText() ([]byte, error) {
+	if !m.masked {
+		return bprintf("%s=%s", ctLabel, m.label), nil
+	}
+
+	return bprintf("%s=%s/%s", ctLabel, m.label, m.mask), nil
+}
+
+// GoString implements Match.
+func (m *connectionTrackingLabelMatch) GoString() string {
+	if !m.masked {
+		return fmt.Sprintf("ovs.ConnectionTrackingLabel(%#v)", m.label)
+	}
+
+	return fmt.Sprintf("ovs.ConnectionTrackingLabelWithMask(%#v, %#v)", m.label, m.mask)
+}
+
+// ConnectionTrackingNetworkSource matches packets whose connection's
+// original direction IPv4 source address matches an address or CIDR block.
+func ConnectionTrackingNetworkSource(ip string) Match {
+	return &connectionTrackingNetworkMatch{
+		srcdst: source,
+		ip:     ip,
+	}
+}
+
+// ConnectionTrackingNetworkDestination matches packets whose connection's
+// original direction IPv4 destination address matches an address or CIDR
+// block.
+func ConnectionTrackingNetworkDestination(ip string) Match {
+	return &connectionTrackingNetworkMatch{
+		srcdst: destination,
+		ip:     ip,
+	}
+}
+
+var _ Match = &connectionTrackingNetworkMatch{}
+
+// A connectionTrackingNetworkMatch is a Match returned by
+// ConnectionTrackingNetwork{Source,Destination}.
+type connectionTrackingNetworkMatch struct {
+	srcdst string
+	ip     string
+}
+
+// MarshalText implements Match.
+func (m *connectionTrackingNetworkMatch) MarshalText() ([]byte, error) {
+	return matchIPv4AddressOrCIDR(fmt.Sprintf("ct_nw_%s", m.srcdst), m.ip)
+}
+
+// GoString implements Match.
+func (m *connectionTrackingNetworkMatch) GoString() string {
+	if m.srcdst == source {
+		return fmt.Sprintf("ovs.ConnectionTrackingNetworkSource(%q)", m.ip)
+	}
+
+	return fmt.Sprintf("ovs.ConnectionTrackingNetworkDestination(%q)", m.ip)
+}
+
+// ConnectionTrackingIPv6Source matches packets whose connection's original
+// direction IPv6 source address matches an address or CIDR block.
+func ConnectionTrackingIPv6Source(ip string) Match {
+	return &connectionTrackingIPv6Match{
+		srcdst: source,
+		ip:     ip,
+	}
+}
+
+// ConnectionTrackingIPv6Destination matches packets whose connection's
+// original direction IPv6 destination address matches an address or CIDR
+// block.
+func ConnectionTrackingIPv6Destination(ip string) Match {
+	return &connectionTrackingIPv6Match{
+		srcdst: destination,
+		ip:     ip,
+	}
+}
+
+var _ Match = &connectionTrackingIPv6Match{}
+
+// A connectionTrackingIPv6Match is a Match returned by
+// ConnectionTrackingIPv6{Source,Destination}.
+type connectionTrackingIPv6Match struct {
+	srcdst string
+	ip     string
+}
+
+// MarshalText implements Match.
+func (m *connectionTrackingIPv6Match) MarshalText() ([]byte, error) {
+	return matchIPv6AddressOrCIDR(fmt.Sprintf("ct_ipv6_%s", m.srcdst), m.ip)
+}
+
+// GoString implements Match.
+func (m *connectionTrackingIPv6Match) GoString() string {
+	if m.srcdst == source {
+		return fmt.Sprintf("ovs.ConnectionTrackingIPv6Source(%q)", m.ip)
+	}
+
+	return fmt.Sprintf("ovs.ConnectionTrackingIPv6Destination(%q)", m.ip)
+}
+
+// ConnectionTrackingNetworkProtocol matches packets whose connection's IP
+// protocol number matches num.
+func ConnectionTrackingNetworkProtocol(num uint8) Match {
+	return &connectionTrackingNetworkProtocolMatch{
+		num: num,
+	}
+}
+
+var _ Match = &connectionTrackingNetworkProtocolMatch{}
+
+// A connectionTrackingNetworkProtocolMatch is a Match returned by
+// ConnectionTrackingNetworkProtocol.
+type connectionTrackingNetworkProtocolMatch struct {
+	num uint8
+}
+
+// MarshalText implements Match.
+func (m *connectionTrackingNetworkProtocolMatch) MarshalText() ([]byte, error) {
+	return bprintf("%s=%d", ctNwProto, m.num), nil
+}
+
+// GoString implements Match.
+func (m *connectionTrackingNetworkProtocolMatch) GoString() string {
+	return fmt.Sprintf("ovs.ConnectionTrackingNetworkProtocol(%d)", m.num)
+}
+
+// ConnectionTrackingTransportSourcePort matches packets whose connection's
+// original direction transport source port matches port.
+func ConnectionTrackingTransportSourcePort(port uint16) Match {
+	return &connectionTrackingTransportPortMatch{
+		srcdst: source,
+		port:   port,
+	}
+}
+
+// ConnectionTrackingTransportDestinationPort matches packets whose
+// connection's original direction transport destination port matches port.
+func ConnectionTrackingTransportDestinationPort(port uint16) Match {
+	return &connectionTrackingTransportPortMatch{
+		srcdst: destination,
+		port:   port,
+	}
+}
+
+// ConnectionTrackingTransportSourceMaskedPort matches packets whose
+// connection's original direction transport source port matches a masked
+// port range.
+func ConnectionTrackingTransportSourceMaskedPort(port, mask uint16) Match {
+	return &connectionTrackingTransportPortMatch{
+		srcdst: source,
+		port:   port,
+		mask:   mask,
+		masked: true,
+	}
+}
+
+// ConnectionTrackingTransportDestinationMaskedPort matches packets whose
+// connection's original direction transport destination port matches a
+// masked port range.
+func ConnectionTrackingTransportDestinationMaskedPort(port, mask uint16) Match {
+	return &connectionTrackingTransportPortMatch{
+		srcdst: destination,
+		port:   port,
+		mask:   mask,
+		masked: true,
+	}
+}
+
+var _ Match = &connectionTrackingTransportPortMatch{}
+
+// A connectionTrackingTransportPortMatch is a Match returned by
+// ConnectionTrackingTransport{Source,Destination}{,Masked}Port.
+type connectionTrackingTransportPortMatch struct {
+	srcdst string
+	port   uint16
+	mask   uint16
+	masked bool
+}
+
+// MarshalText implements Match.
+func (m *connectionTrackingTransportPortMatch) MarshalText() ([]byte, error) {
+	if !m.masked {
+		return bprintf("ct_tp_%s=%d", m.srcdst, m.port), nil
+	}
+
+	return bprintf("ct_tp_%s=0x%04x/0x%04x", m.srcdst, m.port, m.mask), nil
+}
+
+// GoString implements Match.
+func (m *connectionTrackingTransportPortMatch) GoString() string {
+	dir := "Destination"
+	if m.srcdst == source {
+		dir = "Source"
+	}
+
+	if !m.masked {
+		return fmt.Sprintf("ovs.ConnectionTrackingTransport%sPort(%d)", dir, m.port)
+	}
+
+	return fmt.Sprintf("ovs.ConnectionTrackingTransport%sMaskedPort(%d, %#x)", dir, m.port, m.mask)
+}
+
 // ConnectionTrackingState matches packets using their connection state, when
 // connection tracking is enabled on the host.  Use the SetState and UnsetState
 // functions to populate the parameter list for this function.
@@ -1534,6 +1796,238 @@ func (m *tunnelMatch) MarshalText() ([]byte, error) {
 	return matchIPv4AddressOrCIDR(fmt.Sprintf("tun_%s", m.srcdst), m.ip)
 }
 
+// MPLSLabel matches packets with an outermost MPLS label matching label.
+func MPLSLabel(label uint32) Match {
+	return &mplsMatch{
+		field: mplsLabel,
+		value: label,
+	}
+}
+
+// MPLSTC matches packets with an outermost MPLS traffic class matching tc.
+func MPLSTC(tc uint8) Match {
+	return &mplsMatch{
+		field: mplsTC,
+		value: uint32(tc),
+	}
+}
+
+// MPLSBOS matches packets with an outermost MPLS bottom of stack bit
+// matching bos.
+func MPLSBOS(bos uint8) Match {
+	return &mplsMatch{
+		field: mplsBOS,
+		value: uint32(bos),
+	}
+}
+
+// MPLSTTL matches packets with an outermost MPLS time to live matching ttl.
+func MPLSTTL(ttl uint8) Match {
+	return &mplsMatch{
+		field: mplsTTL,
+		value: uint32(ttl),
+	}
+}
+
+var _ Match = &mplsMatch{}
+
+// An mplsMatch is a Match returned by MPLS{Label,TC,BOS,TTL}.
+type mplsMatch struct {
+	field string
+	value uint32
+}
+
+// MarshalText implements Match.
+func (m *mplsMatch) MarshalText() ([]byte, error) {
+	var max uint32
+	switch m.field {
+	case mplsLabel:
+		if m.value > mplsLabelMax {
+			return nil, errInvalidMPLSLabel
+		}
+
+		max = mplsLabelMax
+	case mplsTC:
+		max = 7
+	case mplsBOS:
+		max = 1
+	case mplsTTL:
+		max = 255
+	}
+
+	if m.value > max {
+		return nil, fmt.Errorf("%s must be between 0 and %d", m.field, max)
+	}
+
+	return bprintf("%s=%d", m.field, m.value), nil
+}
+
+// GoString implements Match.
+func (m *mplsMatch) GoString() string {
+	switch m.field {
+	case mplsLabel:
+		return fmt.Sprintf("ovs.MPLSLabel(%d)", m.value)
+	case mplsTC:
+		return fmt.Sprintf("ovs.MPLSTC(%d)", m.value)
+	case mplsBOS:
+		return fmt.Sprintf("ovs.MPLSBOS(%d)", m.value)
+	default:
+		return fmt.Sprintf("ovs.MPLSTTL(%d)", m.value)
+	}
+}
+
+// NSHSPI matches packets with a 24-bit NSH service path identifier
+// matching spi.
+func NSHSPI(spi uint32) Match {
+	return &nshMatch{
+		field: nshSPI,
+		value: spi,
+	}
+}
+
+// NSHSI matches packets with an NSH service index matching si.
+func NSHSI(si uint8) Match {
+	return &nshMatch{
+		field: nshSI,
+		value: uint32(si),
+	}
+}
+
+// NSHContext matches packets with the NSH context header nsh_cN, where N
+// is in the range 1-4, exactly matching value.
+func NSHContext(n int, value uint32) Match {
+	return &nshMatch{
+		field: nshContext + strconv.Itoa(n),
+		ctx:   n,
+		value: value,
+	}
+}
+
+// NSHContextWithMask matches packets with the NSH context header nsh_cN,
+// where N is in the range 1-4, matching value under mask.
+func NSHContextWithMask(n int, value, mask uint32) Match {
+	return &nshMatch{
+		field:  nshContext + strconv.Itoa(n),
+		ctx:    n,
+		value:  value,
+		mask:   mask,
+		masked: true,
+	}
+}
+
+var _ Match = &nshMatch{}
+
+// An nshMatch is a Match returned by NSH{SPI,SI,Context,ContextWithMask}.
+type nshMatch struct {
+	field  string
+	ctx    int
+	value  uint32
+	mask   uint32
+	masked bool
+}
+
+// MarshalText implements Match.
+func (m *nshMatch) MarshalText() ([]byte, error) {
+	switch m.field {
+	case nshSPI:
+		if m.value > nshSPIMax {
+			return nil, errInvalidNSHSPI
+		}
+
+		return bprintf("%s=%#x", m.field, m.value), nil
+	case nshSI:
+		return bprintf("%s=%d", m.field, m.value), nil
+	}
+
+	if m.ctx < 1 || m.ctx > 4 {
+		return nil, errInvalidNSHContext
+	}
+	if m.masked {
+		return bprintf("%s=%#x/%#x", m.field, m.value, m.mask), nil
+	}
+
+	return bprintf("%s=%#x", m.field, m.value), nil
+}
+
+// GoString implements Match.
+func (m *nshMatch) GoString() string {
+	switch m.field {
+	case nshSPI:
+		return fmt.Sprintf("ovs.NSHSPI(%#x)", m.value)
+	case nshSI:
+		return fmt.Sprintf("ovs.NSHSI(%d)", m.value)
+	}
+
+	if m.masked {
+		return fmt.Sprintf("ovs.NSHContextWithMask(%d, %#x, %#x)", m.ctx, m.value, m.mask)
+	}
+
+	return fmt.Sprintf("ovs.NSHContext(%d, %#x)", m.ctx, m.value)
+}
+
+// TunnelMetadata matches packets with the Geneve tunnel option mapped to
+// tun_metadataN, where N is in the range 0-63, exactly matching value.
+// Options must be mapped to fields using OpenFlowService.AddTLVMap before
+// they can be matched.
+func TunnelMetadata(n int, value []byte) Match {
+	return &tunnelMetadataMatch{
+		index: n,
+		value: value,
+	}
+}
+
+// TunnelMetadataWithMask matches packets with the Geneve tunnel option
+// mapped to tun_metadataN, where N is in the range 0-63, matching value
+// under mask.
+func TunnelMetadataWithMask(n int, value, mask []byte) Match {
+	return &tunnelMetadataMatch{
+		index:  n,
+		value:  value,
+		mask:   mask,
+		masked: true,
+	}
+}
+
+var _ Match = &tunnelMetadataMatch{}
+
+// A tunnelMetadataMatch is a Match returned by TunnelMetadata{,WithMask}.
+type tunnelMetadataMatch struct {
+	index  int
+	value  []byte
+	mask   []byte
+	masked bool
+}
+
+// MarshalText implements Match.
+func (m *tunnelMetadataMatch) MarshalText() ([]byte, error) {
+	if m.index < 0 || m.index >= tunMetadataFields {
+		return nil, errInvalidTunnelMetadata
+	}
+	if len(m.value) == 0 || len(m.value) > tunMetadataMaxLen {
+		return nil, errInvalidTunnelMetadataValue
+	}
+
+	b := bprintf("%s%d=0x%s", tunMetadata, m.index, hex.EncodeToString(m.value))
+	if m.masked {
+		if len(m.mask) == 0 || len(m.mask) > tunMetadataMaxLen {
+			return nil, errInvalidTunnelMetadataMask
+		}
+
+		b = append(b, "/0x"+hex.EncodeToString(m.mask)...)
+	}
+
+	return b, nil
+}
+
+// GoString implements Match.
+func (m *tunnelMetadataMatch) GoString() string {
+	if m.masked {
+		return fmt.Sprintf("ovs.TunnelMetadataWithMask(%d, %#v, %#v)", m.index, m.value, m.mask)
+	}
+
+	return fmt.Sprintf("ovs.TunnelMetadata(%d, %#v)", m.index, m.value)
+}
+
 // matchIPv4AddressOrCIDR attempts to create a Match using the specified key
 // and input string, which could be interpreted as an IPv4 address or IPv4
 // CIDR block.
@@ -1671,7 +2165,7 @@ var _ Match = &fieldMatch{}
 
 // GoString implements Match.
 func (m *fieldMatch) GoString() string {
-	return fmt.Sprintf("ovs.FieldMatch(%v,%v)", m.field, m.srcOrValue)
+	return fmt.Sprintf("ovs.FieldMatch(%q, %q)", m.field, m.srcOrValue)
 }
 
 // MarshalText implements Match.
@@ -1242,6 +1242,220 @@ func TestMatchConnectionTrackingZone(t *testing.T) {
 	}
 }
 
+func TestMatchConnectionTrackingTuple(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		m       Match
+		out     string
+		invalid bool
+	}{
+		{
+			desc: "label, no mask",
+			m:    ConnectionTrackingLabel(Uint128{Lo: 0x1}),
+			out:  "ct_label=0x1",
+		},
+		{
+			desc: "label with mask",
+			m:    ConnectionTrackingLabelWithMask(Uint128{Hi: 0x1}, Uint128{Hi: 0xffff}),
+			out:  "ct_label=0x10000000000000000/0xffff0000000000000000",
+		},
+		{
+			desc: "label with zero mask",
+			m:    ConnectionTrackingLabelWithMask(Uint128{Lo: 0x1}, Uint128{}),
+			out:  "ct_label=0x1/0x0",
+		},
+		{
+			desc: "IPv4 source",
+			m:    ConnectionTrackingNetworkSource("192.0.2.1"),
+			out:  "ct_nw_src=192.0.2.1",
+		},
+		{
+			desc: "IPv4 destination CIDR",
+			m:    ConnectionTrackingNetworkDestination("192.0.2.0/24"),
+			out:  "ct_nw_dst=192.0.2.0/24",
+		},
+		{
+			desc:    "IPv4 source with IPv6 address",
+			m:       ConnectionTrackingNetworkSource("2001:db8::1"),
+			invalid: true,
+		},
+		{
+			desc: "IPv6 source",
+			m:    ConnectionTrackingIPv6Source("2001:db8::1"),
+			out:  "ct_ipv6_src=2001:db8::1",
+		},
+		{
+			desc: "IPv6 destination CIDR",
+			m:    ConnectionTrackingIPv6Destination("2001:db8::/32"),
+			out:  "ct_ipv6_dst=2001:db8::/32",
+		},
+		{
+			desc:    "IPv6 destination with IPv4 address",
+			m:       ConnectionTrackingIPv6Destination("192.0.2.1"),
+			invalid: true,
+		},
+		{
+			desc: "protocol",
+			m:    ConnectionTrackingNetworkProtocol(6),
+			out:  "ct_nw_proto=6",
+		},
+		{
+			desc: "transport source port",
+			m:    ConnectionTrackingTransportSourcePort(80),
+			out:  "ct_tp_src=80",
+		},
+		{
+			desc: "transport destination port with mask",
+			m:    ConnectionTrackingTransportDestinationMaskedPort(0xea60, 0xffe0),
+			out:  "ct_tp_dst=0xea60/0xffe0",
+		},
+		{
+			desc: "transport source port with zero mask",
+			m:    ConnectionTrackingTransportSourceMaskedPort(80, 0),
+			out:  "ct_tp_src=0x0050/0x0000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			out, err := tt.m.MarshalText()
+			if err != nil && !tt.invalid {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.invalid {
+				if err == nil {
+					t.Fatal("expected an error, but none occurred")
+				}
+
+				return
+			}
+
+			if want, got := tt.out, string(out); want != got {
+				t.Fatalf("unexpected Match output:\n- want: %q\n-  got: %q",
+					want, got)
+			}
+		})
+	}
+}
+
+func TestMatchMPLSNSHTunnelMetadata(t *testing.T) {
+	var tests = []struct {
+		desc string
+		m    Match
+		out  string
+		err  error
+	}{
+		{
+			desc: "MPLS label",
+			m:    MPLSLabel(100),
+			out:  "mpls_label=100",
+		},
+		{
+			desc: "MPLS label too large",
+			m:    MPLSLabel(0x100000),
+			err:  errInvalidMPLSLabel,
+		},
+		{
+			desc: "MPLS traffic class",
+			m:    MPLSTC(7),
+			out:  "mpls_tc=7",
+		},
+		{
+			desc: "MPLS bottom of stack",
+			m:    MPLSBOS(1),
+			out:  "mpls_bos=1",
+		},
+		{
+			desc: "MPLS TTL",
+			m:    MPLSTTL(64),
+			out:  "mpls_ttl=64",
+		},
+		{
+			desc: "NSH service path identifier",
+			m:    NSHSPI(0x1234),
+			out:  "nsh_spi=0x1234",
+		},
+		{
+			desc: "NSH service path identifier too large",
+			m:    NSHSPI(0x1000000),
+			err:  errInvalidNSHSPI,
+		},
+		{
+			desc: "NSH service index",
+			m:    NSHSI(255),
+			out:  "nsh_si=255",
+		},
+		{
+			desc: "NSH context",
+			m:    NSHContext(1, 0x11223344),
+			out:  "nsh_c1=0x11223344",
+		},
+		{
+			desc: "NSH context with mask",
+			m:    NSHContextWithMask(4, 0x1, 0xff),
+			out:  "nsh_c4=0x1/0xff",
+		},
+		{
+			desc: "NSH context with zero mask",
+			m:    NSHContextWithMask(2, 0x1, 0),
+			out:  "nsh_c2=0x1/0x0",
+		},
+		{
+			desc: "NSH context out of range",
+			m:    NSHContext(5, 0x1),
+			err:  errInvalidNSHContext,
+		},
+		{
+			desc: "tunnel metadata",
+			m:    TunnelMetadata(0, []byte{0x12, 0x34}),
+			out:  "tun_metadata0=0x1234",
+		},
+		{
+			desc: "tunnel metadata with mask",
+			m:    TunnelMetadataWithMask(63, []byte{0x00, 0x01}, []byte{0x00, 0xff}),
+			out:  "tun_metadata63=0x0001/0x00ff",
+		},
+		{
+			desc: "tunnel metadata with zero mask",
+			m:    TunnelMetadataWithMask(1, []byte{0x01}, []byte{0x00}),
+			out:  "tun_metadata1=0x01/0x00",
+		},
+		{
+			desc: "tunnel metadata with empty mask",
+			m:    TunnelMetadataWithMask(1, []byte{0x01}, nil),
+			err:  errInvalidTunnelMetadataMask,
+		},
+		{
+			desc: "tunnel metadata out of range",
+			m:    TunnelMetadata(64, []byte{0x1}),
+			err:  errInvalidTunnelMetadata,
+		},
+		{
+			desc: "tunnel metadata empty",
+			m:    TunnelMetadata(0, nil),
+			err:  errInvalidTunnelMetadataValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			out, err := tt.m.MarshalText()
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if err != nil {
+				return
+			}
+
+			if want, got := tt.out, string(out); want != got {
+				t.Fatalf("unexpected Match output:\n- want: %q\n-  got: %q",
+					want, got)
+			}
+		})
+	}
+}
+
 func TestMatchTunnelID(t *testing.T) {
 	var negOne int32 = -1
 
@@ -1476,6 +1690,82 @@ func TestMatchGoString(t *testing.T) {
 			m: ARPOperation(2),
 			s: `ovs.ARPOperation(2)`,
 		},
+		{
+			m: ConnectionTrackingLabel(Uint128{Lo: 0x1}),
+			s: `ovs.ConnectionTrackingLabel(ovs.Uint128{Hi: 0x0, Lo: 0x1})`,
+		},
+		{
+			m: ConnectionTrackingLabelWithMask(Uint128{Lo: 0x1}, Uint128{Lo: 0xff}),
+			s: `ovs.ConnectionTrackingLabelWithMask(ovs.Uint128{Hi: 0x0, Lo: 0x1}, ovs.Uint128{Hi: 0x0, Lo: 0xff})`,
+		},
+		{
+			m: ConnectionTrackingNetworkSource("192.0.2.1"),
+			s: `ovs.ConnectionTrackingNetworkSource("192.0.2.1")`,
+		},
+		{
+			m: ConnectionTrackingNetworkDestination("192.0.2.0/24"),
+			s: `ovs.ConnectionTrackingNetworkDestination("192.0.2.0/24")`,
+		},
+		{
+			m: ConnectionTrackingIPv6Source("2001:db8::1"),
+			s: `ovs.ConnectionTrackingIPv6Source("2001:db8::1")`,
+		},
+		{
+			m: ConnectionTrackingIPv6Destination("2001:db8::/32"),
+			s: `ovs.ConnectionTrackingIPv6Destination("2001:db8::/32")`,
+		},
+		{
+			m: ConnectionTrackingNetworkProtocol(17),
+			s: `ovs.ConnectionTrackingNetworkProtocol(17)`,
+		},
+		{
+			m: ConnectionTrackingTransportSourcePort(80),
+			s: `ovs.ConnectionTrackingTransportSourcePort(80)`,
+		},
+		{
+			m: ConnectionTrackingTransportDestinationMaskedPort(60000, 0xffe0),
+			s: `ovs.ConnectionTrackingTransportDestinationMaskedPort(60000, 0xffe0)`,
+		},
+		{
+			m: MPLSLabel(100),
+			s: `ovs.MPLSLabel(100)`,
+		},
+		{
+			m: MPLSTC(1),
+			s: `ovs.MPLSTC(1)`,
+		},
+		{
+			m: MPLSBOS(1),
+			s: `ovs.MPLSBOS(1)`,
+		},
+		{
+			m: MPLSTTL(64),
+			s: `ovs.MPLSTTL(64)`,
+		},
+		{
+			m: NSHSPI(0x1234),
+			s: `ovs.NSHSPI(0x1234)`,
+		},
+		{
+			m: NSHSI(255),
+			s: `ovs.NSHSI(255)`,
+		},
+		{
+			m: NSHContext(2, 0x1),
+			s: `ovs.NSHContext(2, 0x1)`,
+		},
+		{
+			m: NSHContextWithMask(2, 0x1, 0x0),
+			s: `ovs.NSHContextWithMask(2, 0x1, 0x0)`,
+		},
+		{
+			m: TunnelMetadata(1, []byte{0x12, 0x34}),
+			s: `ovs.TunnelMetadata(1, []byte{0x12, 0x34})`,
+		},
+		{
+			m: TunnelMetadataWithMask(1, []byte{0x12, 0x34}, []byte{0xff, 0x00}),
+			s: `ovs.TunnelMetadataWithMask(1, []byte{0x12, 0x34}, []byte{0xff, 0x0})`,
+		},
 	}
 
 	for _, tt := range tests {
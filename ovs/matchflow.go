@@ -42,6 +42,20 @@ type MatchFlow struct {
 	Matches  []Match
 	Table    int
 
+	// Priority indicates the priority of flows to match.  Priority is only
+	// used for strict operations, such as OpenFlowService.DelFlowsStrict,
+	// where it is always matched exactly.
+	Priority int
+
+	// OutPort, if set, restricts matching to flows which output to the
+	// specified port.  PortLOCAL may be used for the local port.
+	OutPort int
+
+	// OutGroup, if set, restricts matching to flows which output to the
+	// specified group.  Because zero indicates that OutGroup is not set,
+	// group 0 cannot be used as a filter.
+	OutGroup int
+
 	// Cookie indicates a cookie value to use when matching flows.
 	Cookie uint64
 
@@ -76,6 +90,12 @@ func (e *MatchFlowError) Error() string {
 
 // MarshalText marshals a MatchFlow into its textual form.
 func (f *MatchFlow) MarshalText() ([]byte, error) {
+	return f.marshalText(false)
+}
+
+// marshalText marshals a MatchFlow into its textual form.  If strict is
+// set, the flow's priority is included so that it is matched exactly.
+func (f *MatchFlow) marshalText(strict bool) ([]byte, error) {
 	matches, err := f.marshalMatches()
 	if err != nil {
 		return nil, err
@@ -83,6 +103,12 @@ func (f *MatchFlow) MarshalText() ([]byte, error) {
 
 	var b []byte
 
+	if strict {
+		b = append(b, priorityString...)
+		b = strconv.AppendInt(b, int64(f.Priority), 10)
+		b = append(b, ',')
+	}
+
 	if f.Protocol != "" {
 		b = append(b, f.Protocol...)
 		b = append(b, ',')
@@ -105,6 +131,23 @@ func (f *MatchFlow) MarshalText() ([]byte, error) {
 		b = append(b, ',')
 	}
 
+	if f.OutPort != 0 {
+		b = append(b, outPort+"="...)
+
+		if f.OutPort == PortLOCAL {
+			b = append(b, portLOCAL...)
+		} else {
+			b = strconv.AppendInt(b, int64(f.OutPort), 10)
+		}
+		b = append(b, ',')
+	}
+
+	if f.OutGroup != 0 {
+		b = append(b, outGroup+"="...)
+		b = strconv.AppendInt(b, int64(f.OutGroup), 10)
+		b = append(b, ',')
+	}
+
 	if f.Cookie > 0 || f.CookieMask > 0 {
 		// Hexadecimal cookies and masks are much easier to read.
 		b = append(b, cookie+"="...)
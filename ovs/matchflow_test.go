@@ -250,6 +250,33 @@ func TestMatchFlowMarshalText(t *testing.T) {
 			},
 			s: "table=45",
 		},
+		{
+			desc: "Flow with out_port=LOCAL, in any table",
+			f: &MatchFlow{
+				OutPort: PortLOCAL,
+				Table:   AnyTable,
+			},
+			s: "out_port=LOCAL",
+		},
+		{
+			desc: "Flow with out_port and out_group",
+			f: &MatchFlow{
+				Protocol: ProtocolIPv4,
+				OutPort:  2,
+				OutGroup: 10,
+				Table:    0,
+			},
+			s: "ip,out_port=2,out_group=10,table=0",
+		},
+		{
+			desc: "Flow priority ignored when not strict",
+			f: &MatchFlow{
+				Protocol: ProtocolIPv4,
+				Priority: 100,
+				Table:    AnyTable,
+			},
+			s: "ip",
+		},
 	}
 
 	for _, tt := range tests {
@@ -268,6 +295,49 @@ func TestMatchFlowMarshalText(t *testing.T) {
 	}
 }
 
+func TestMatchFlowMarshalTextStrict(t *testing.T) {
+	var tests = []struct {
+		desc string
+		f    *MatchFlow
+		s    string
+	}{
+		{
+			desc: "zero priority",
+			f: &MatchFlow{
+				Table: AnyTable,
+			},
+			s: "priority=0",
+		},
+		{
+			desc: "IPv4 Flow with priority=100",
+			f: &MatchFlow{
+				Protocol: ProtocolIPv4,
+				Priority: 100,
+				Matches: []Match{
+					NetworkSource("192.0.2.1"),
+				},
+				OutPort: 1,
+				Table:   10,
+			},
+			s: "priority=100,ip,nw_src=192.0.2.1,out_port=1,table=10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			b, err := tt.f.marshalText(true)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if want, got := tt.s, string(b); want != got {
+				t.Fatalf("unexpected Flow text:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
 // matchFlowErrorEqual determines if two possible MatchFlowErrors are equal.
 func matchFlowErrorEqual(a error, b error) bool {
 	// Special case: both nil is OK
@@ -16,6 +16,7 @@ package ovs
 
 import (
 	"bytes"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"math"
@@ -26,6 +27,16 @@ import (
 
 // parseMatch creates a Match function from the input string.
 func parseMatch(key string, value string) (Match, error) {
+	if m, ok, err := parseRegisterMatch(key, value); ok {
+		return m, err
+	}
+	if strings.HasPrefix(key, nshContext) {
+		return parseNSHContext(key, value)
+	}
+	if strings.HasPrefix(key, tunMetadata) {
+		return parseTunnelMetadata(key, value)
+	}
+
 	switch key {
 	case arpSHA, arpTHA, ndSLL, ndTLL:
 		return parseMACMatch(key, value)
@@ -35,7 +46,32 @@ func parseMatch(key string, value string) (Match, error) {
 		return parseIntMatch(key, value, math.MaxUint8)
 	case ctZone:
 		return parseIntMatch(key, value, math.MaxUint16)
-	case tpSRC, tpDST, udpSRC, udpDST:
+	case ctNwProto, mplsTTL, nshSI:
+		return parseIntMatch(key, value, math.MaxUint8)
+	case mplsLabel:
+		return parseIntMatch(key, value, mplsLabelMax)
+	case mplsTC:
+		return parseIntMatch(key, value, 7)
+	case mplsBOS:
+		return parseIntMatch(key, value, 1)
+	case nshSPI:
+		spi, err := strconv.ParseUint(value, 0, 24)
+		if err != nil {
+			return nil, err
+		}
+
+		return NSHSPI(uint32(spi)), nil
+	case ctLabel:
+		return parseCTLabel(value)
+	case ctNwSRC:
+		return ConnectionTrackingNetworkSource(value), nil
+	case ctNwDST:
+		return ConnectionTrackingNetworkDestination(value), nil
+	case ctIPv6SRC:
+		return ConnectionTrackingIPv6Source(value), nil
+	case ctIPv6DST:
+		return ConnectionTrackingIPv6Destination(value), nil
+	case tpSRC, tpDST, udpSRC, udpDST, ctTpSRC, ctTpDST:
 		return parsePort(key, value, math.MaxUint16)
 	case conjID:
 		return parseIntMatch(key, value, math.MaxUint32)
@@ -168,6 +204,18 @@ func parseIntMatch(key string, value string, max int) (Match, error) {
 		return NetworkProtocol(uint8(t)), nil
 	case ctZone:
 		return ConnectionTrackingZone(uint16(t)), nil
+	case ctNwProto:
+		return ConnectionTrackingNetworkProtocol(uint8(t)), nil
+	case mplsLabel:
+		return MPLSLabel(uint32(t)), nil
+	case mplsTC:
+		return MPLSTC(uint8(t)), nil
+	case mplsBOS:
+		return MPLSBOS(uint8(t)), nil
+	case mplsTTL:
+		return MPLSTTL(uint8(t)), nil
+	case nshSI:
+		return NSHSI(uint8(t)), nil
 	case conjID:
 		return ConjunctionID(uint32(t)), nil
 	}
@@ -219,6 +267,16 @@ func parsePort(key string, value string, max int) (Match, error) {
 		return UDPSourceMaskedPort(uint16(values[0]), uint16(values[1])), nil
 	case udpDST:
 		return UDPDestinationMaskedPort(uint16(values[0]), uint16(values[1])), nil
+	case ctTpSRC:
+		if len(ss) == 1 {
+			return ConnectionTrackingTransportSourcePort(uint16(values[0])), nil
+		}
+		return ConnectionTrackingTransportSourceMaskedPort(uint16(values[0]), uint16(values[1])), nil
+	case ctTpDST:
+		if len(ss) == 1 {
+			return ConnectionTrackingTransportDestinationPort(uint16(values[0])), nil
+		}
+		return ConnectionTrackingTransportDestinationMaskedPort(uint16(values[0]), uint16(values[1])), nil
 	}
 	// Return error if input is invalid
 	return nil, fmt.Errorf("no action matched for %s=%s", key, value)
@@ -488,6 +546,102 @@ func parseCTMark(value string) (Match, error) {
 	}
 }
 
+// parseCTLabel parses a ct_label Match value, with an optional mask.
+func parseCTLabel(value string) (Match, error) {
+	ss := strings.Split(value, "/")
+	if len(ss) > 2 {
+		// Match had too many parts, e.g. "ct_label=10/10/10"
+		return nil, fmt.Errorf("invalid ct_label match: %q", value)
+	}
+
+	var values [2]Uint128
+	for i, s := range ss {
+		v, err := parseUint128(s, 128)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+
+	if len(ss) == 1 {
+		return ConnectionTrackingLabel(values[0]), nil
+	}
+
+	return ConnectionTrackingLabelWithMask(values[0], values[1]), nil
+}
+
+// parseNSHContext parses an nsh_cN Match value, with an optional mask.
+func parseNSHContext(key string, value string) (Match, error) {
+	n, err := strconv.Atoi(strings.TrimPrefix(key, nshContext))
+	if err != nil || n < 1 || n > 4 {
+		return nil, fmt.Errorf("invalid NSH context header: %q", key)
+	}
+
+	ss := strings.Split(value, "/")
+	if len(ss) > 2 {
+		return nil, fmt.Errorf("invalid %s match: %q", key, value)
+	}
+
+	var values [2]uint32
+	for i, s := range ss {
+		v, err := strconv.ParseUint(s, 0, 32)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = uint32(v)
+	}
+
+	if len(ss) == 1 {
+		return NSHContext(n, values[0]), nil
+	}
+
+	return NSHContextWithMask(n, values[0], values[1]), nil
+}
+
+// parseTunnelMetadata parses a tun_metadataN Match value, with an optional
+// mask.
+func parseTunnelMetadata(key string, value string) (Match, error) {
+	n, err := strconv.Atoi(strings.TrimPrefix(key, tunMetadata))
+	if err != nil || n < 0 || n >= tunMetadataFields {
+		return nil, fmt.Errorf("invalid tunnel metadata field: %q", key)
+	}
+
+	ss := strings.Split(value, "/")
+	if len(ss) > 2 {
+		return nil, fmt.Errorf("invalid %s match: %q", key, value)
+	}
+
+	var values [2][]byte
+	for i, s := range ss {
+		b, err := parseHexBytes(s)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = b
+	}
+
+	if len(ss) == 1 {
+		return TunnelMetadata(n, values[0]), nil
+	}
+
+	return TunnelMetadataWithMask(n, values[0], values[1]), nil
+}
+
+// parseHexBytes parses a hexadecimal string with a "0x" prefix into bytes,
+// padding it with a leading zero if needed.
+func parseHexBytes(s string) ([]byte, error) {
+	if !strings.HasPrefix(s, hexPrefix) {
+		return nil, fmt.Errorf("missing %q prefix for hexadecimal value: %q", hexPrefix, s)
+	}
+
+	s = strings.TrimPrefix(s, hexPrefix)
+	if len(s)%2 != 0 {
+		s = "0" + s
+	}
+
+	return hex.DecodeString(s)
+}
+
 // parseMetadata parses a Metadata Match from value.
 func parseMetadata(value string) (Match, error) {
 	var values []uint64
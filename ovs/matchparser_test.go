@@ -38,6 +38,179 @@ func Test_parseMatch(t *testing.T) {
 			s:       "foo=bar",
 			invalid: true,
 		},
+		{
+			s: "ct_label=0x1",
+			m: ConnectionTrackingLabel(Uint128{Lo: 0x1}),
+		},
+		{
+			s: "ct_label=0x10000000000000001/0xffffffffffffffffffffffffffffffff",
+			m: ConnectionTrackingLabelWithMask(Uint128{Hi: 0x1, Lo: 0x1}, Uint128{Hi: ^uint64(0), Lo: ^uint64(0)}),
+		},
+		{
+			s: "ct_label=0x1/0x0",
+			m: ConnectionTrackingLabelWithMask(Uint128{Lo: 0x1}, Uint128{}),
+		},
+		{
+			s:       "ct_label=0x1/0x1/0x1",
+			invalid: true,
+		},
+		{
+			s:       "ct_label=foo",
+			invalid: true,
+		},
+		{
+			s: "ct_nw_src=192.0.2.1",
+			m: ConnectionTrackingNetworkSource("192.0.2.1"),
+		},
+		{
+			s: "ct_nw_dst=192.0.2.0/24",
+			m: ConnectionTrackingNetworkDestination("192.0.2.0/24"),
+		},
+		{
+			s: "ct_ipv6_src=2001:db8::1",
+			m: ConnectionTrackingIPv6Source("2001:db8::1"),
+		},
+		{
+			s: "ct_ipv6_dst=2001:db8::/32",
+			m: ConnectionTrackingIPv6Destination("2001:db8::/32"),
+		},
+		{
+			s: "ct_nw_proto=6",
+			m: ConnectionTrackingNetworkProtocol(6),
+		},
+		{
+			s:       "ct_nw_proto=256",
+			invalid: true,
+		},
+		{
+			s: "ct_tp_src=80",
+			m: ConnectionTrackingTransportSourcePort(80),
+		},
+		{
+			s: "ct_tp_dst=0xea60/0xffe0",
+			m: ConnectionTrackingTransportDestinationMaskedPort(0xea60, 0xffe0),
+		},
+		{
+			s: "ct_tp_src=0x0050/0x0000",
+			m: ConnectionTrackingTransportSourceMaskedPort(80, 0),
+		},
+		{
+			s:       "ct_tp_dst=65536",
+			invalid: true,
+		},
+		{
+			s: "mpls_label=100",
+			m: MPLSLabel(100),
+		},
+		{
+			s:       "mpls_label=1048576",
+			invalid: true,
+		},
+		{
+			s: "mpls_tc=7",
+			m: MPLSTC(7),
+		},
+		{
+			s:       "mpls_tc=8",
+			invalid: true,
+		},
+		{
+			s: "mpls_bos=1",
+			m: MPLSBOS(1),
+		},
+		{
+			s: "mpls_ttl=64",
+			m: MPLSTTL(64),
+		},
+		{
+			s: "nsh_spi=0x1234",
+			m: NSHSPI(0x1234),
+		},
+		{
+			s:       "nsh_spi=0x1000000",
+			invalid: true,
+		},
+		{
+			s: "nsh_si=255",
+			m: NSHSI(255),
+		},
+		{
+			s: "nsh_c1=0x11223344",
+			m: NSHContext(1, 0x11223344),
+		},
+		{
+			s: "nsh_c4=0x1/0xff",
+			m: NSHContextWithMask(4, 0x1, 0xff),
+		},
+		{
+			s: "nsh_c2=0x1/0x0",
+			m: NSHContextWithMask(2, 0x1, 0x0),
+		},
+		{
+			s:       "nsh_c5=0x1",
+			invalid: true,
+		},
+		{
+			s:     "tun_metadata0=0x1234",
+			final: "tun_metadata0=0x1234",
+			m:     TunnelMetadata(0, []byte{0x12, 0x34}),
+		},
+		{
+			s:     "tun_metadata63=0x1/0xfff",
+			final: "tun_metadata63=0x01/0x0fff",
+			m:     TunnelMetadataWithMask(63, []byte{0x01}, []byte{0x0f, 0xff}),
+		},
+		{
+			s:     "tun_metadata1=0x1/0x0",
+			final: "tun_metadata1=0x01/0x00",
+			m:     TunnelMetadataWithMask(1, []byte{0x01}, []byte{0x00}),
+		},
+		{
+			s:       "tun_metadata64=0x1",
+			invalid: true,
+		},
+		{
+			s:       "tun_metadata0=1234",
+			invalid: true,
+		},
+		{
+			s: "reg0=0x10",
+			m: RegMatch(0, 0x10),
+		},
+		{
+			s: "reg15=0x10/0xff",
+			m: RegMatchWithMask(15, 0x10, 0xff),
+		},
+		{
+			s:     "reg1=16",
+			final: "reg1=0x10",
+			m:     RegMatch(1, 0x10),
+		},
+		{
+			s:       "reg0=0x100000000",
+			invalid: true,
+		},
+		{
+			s:     "reg2=0x1/0",
+			final: "reg2=0x1/0x0",
+			m:     RegMatchWithMask(2, 0x1, 0),
+		},
+		{
+			s:       "reg0=0x1/0x1/0x1",
+			invalid: true,
+		},
+		{
+			s: "xreg7=0x100000000/0xffffffff00000000",
+			m: XRegMatchWithMask(7, 0x100000000, 0xffffffff00000000),
+		},
+		{
+			s: "xxreg3=0x1000000000000000000000001",
+			m: XXRegMatch(3, Uint128{Hi: 0x100000000, Lo: 0x1}),
+		},
+		{
+			s:       "xxreg0=foo",
+			invalid: true,
+		},
 		{
 			s:       "arp_sha=foo",
 			invalid: true,
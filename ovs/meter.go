@@ -0,0 +1,428 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidMeter is returned when meters from 'ovs-ofctl dump-meters'
+	// do not match the expected output format.
+	ErrInvalidMeter = errors.New("invalid openflow meter")
+
+	// ErrInvalidMeterStats is returned when meter statistics from
+	// 'ovs-ofctl meter-stats' do not match the expected output format.
+	ErrInvalidMeterStats = errors.New("invalid meter statistics")
+
+	// errMeterNegativeID is returned when a Meter or the ApplyMeter action
+	// use a negative meter ID.
+	errMeterNegativeID = errors.New("meter ID must not be negative")
+
+	// errMeterRateUnits is returned when a Meter specifies both kbps and
+	// pktps rate units.
+	errMeterRateUnits = errors.New("meter must not specify both kbps and pktps")
+
+	// errMeterNoBands is returned when a Meter has no bands.
+	errMeterNoBands = errors.New("meter must have at least one band")
+
+	// errMeterBandType is returned when a MeterBand has an unknown type.
+	errMeterBandType = errors.New("unknown meter band type")
+)
+
+// A MeterBandType is the type of a MeterBand, which determines the action
+// applied to packets exceeding the band's rate.
+type MeterBandType string
+
+// MeterBandType constants which can be used in OpenFlow meters.
+const (
+	// MeterBandTypeDrop drops packets exceeding the band's rate.
+	MeterBandTypeDrop MeterBandType = "drop"
+
+	// MeterBandTypeDSCPRemark increases the drop precedence of the DSCP
+	// field of packets exceeding the band's rate.
+	MeterBandTypeDSCPRemark MeterBandType = "dscp_remark"
+)
+
+// A Meter is an OpenFlow meter, which can be applied to packets using the
+// ApplyMeter action.  It can be marshaled to and from its textual form for
+// use with Open vSwitch.
+type Meter struct {
+	ID int
+
+	// Kbps and PacketsPerSecond specify the units of the rates of the
+	// meter's bands.  If neither is set, Open vSwitch uses kbps.
+	Kbps             bool
+	PacketsPerSecond bool
+
+	// Burst enables the burst sizes of the meter's bands.
+	Burst bool
+
+	// Stats enables collection of statistics for the meter.
+	Stats bool
+
+	Bands []MeterBand
+}
+
+// A MeterBand is a rate band of a Meter.
+type MeterBand struct {
+	Type MeterBandType
+
+	// Rate is the rate above which the band applies, in the units of the
+	// band's Meter.
+	Rate int
+
+	// BurstSize is the burst size of the band, in kilobits or packets.
+	BurstSize int
+
+	// PrecLevel is the amount by which the drop precedence is increased
+	// by a MeterBandTypeDSCPRemark band.
+	PrecLevel int
+}
+
+var _ error = &MeterError{}
+
+// A MeterError is an error encountered while marshaling or unmarshaling
+// a Meter.
+type MeterError struct {
+	// Str indicates the string, if any, that caused the meter to
+	// fail while unmarshaling.
+	Str string
+
+	// Err indicates the error that halted meter marshaling or unmarshaling.
+	Err error
+}
+
+// Error returns the string representation of a MeterError.
+func (e *MeterError) Error() string {
+	if e.Str == "" {
+		return e.Err.Error()
+	}
+
+	return fmt.Sprintf("meter error due to string %q: %v",
+		e.Str, e.Err)
+}
+
+// Constants used repeatedly while marshaling and unmarshaling meters.
+const (
+	meterID        = "meter"
+	meterKbps      = "kbps"
+	meterPktps     = "pktps"
+	meterBurst     = "burst"
+	meterStats     = "stats"
+	meterBands     = "bands"
+	bandType       = "type"
+	bandRate       = "rate"
+	bandBurstSize  = "burst_size"
+	bandPrecLevel  = "prec_level"
+	meterBandsDesc = meterBands + "="
+)
+
+// MarshalText marshals a Meter into its textual form.
+func (m *Meter) MarshalText() ([]byte, error) {
+	if m.ID < 0 {
+		return nil, &MeterError{
+			Err: errMeterNegativeID,
+		}
+	}
+
+	if m.Kbps && m.PacketsPerSecond {
+		return nil, &MeterError{
+			Err: errMeterRateUnits,
+		}
+	}
+
+	if len(m.Bands) == 0 {
+		return nil, &MeterError{
+			Err: errMeterNoBands,
+		}
+	}
+
+	b := []byte(meterID + "=")
+	b = strconv.AppendInt(b, int64(m.ID), 10)
+
+	if m.Kbps {
+		b = append(b, ","+meterKbps...)
+	}
+	if m.PacketsPerSecond {
+		b = append(b, ","+meterPktps...)
+	}
+	if m.Burst {
+		b = append(b, ","+meterBurst...)
+	}
+	if m.Stats {
+		b = append(b, ","+meterStats...)
+	}
+
+	bands := make([]string, 0, len(m.Bands))
+	for _, band := range m.Bands {
+		bb, err := band.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+
+		bands = append(bands, string(bb))
+	}
+
+	b = append(b, ","+meterBandsDesc...)
+	b = append(b, strings.Join(bands, " ")...)
+
+	return b, nil
+}
+
+// MarshalText marshals a MeterBand into its textual form.
+func (mb *MeterBand) MarshalText() ([]byte, error) {
+	switch mb.Type {
+	case MeterBandTypeDrop:
+	case MeterBandTypeDSCPRemark:
+	default:
+		return nil, &MeterError{
+			Str: string(mb.Type),
+			Err: errMeterBandType,
+		}
+	}
+
+	b := []byte(bandType + "=")
+	b = append(b, mb.Type...)
+
+	b = append(b, " "+bandRate+"="...)
+	b = strconv.AppendInt(b, int64(mb.Rate), 10)
+
+	if mb.BurstSize != 0 {
+		b = append(b, " "+bandBurstSize+"="...)
+		b = strconv.AppendInt(b, int64(mb.BurstSize), 10)
+	}
+
+	if mb.Type == MeterBandTypeDSCPRemark {
+		b = append(b, " "+bandPrecLevel+"="...)
+		b = strconv.AppendInt(b, int64(mb.PrecLevel), 10)
+	}
+
+	return b, nil
+}
+
+// UnmarshalText unmarshals a Meter from its textual form, either as
+// produced by MarshalText or as output by 'ovs-ofctl dump-meters':
+//  meter=1 kbps burst stats bands=
+//  type=drop rate=1000 burst_size=100
+func (m *Meter) UnmarshalText(b []byte) error {
+	// Make a copy per documentation for encoding.TextUnmarshaler.
+	s := string(b)
+
+	*m = Meter{}
+
+	// Fields may be separated by commas, spaces, or newlines.
+	ss := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+
+	var (
+		hasID bool
+		band  *MeterBand
+	)
+
+	for _, f := range ss {
+		if band == nil {
+			// Parsing meter fields until bands are found.
+			switch f {
+			case meterKbps:
+				m.Kbps = true
+				continue
+			case meterPktps:
+				m.PacketsPerSecond = true
+				continue
+			case meterBurst:
+				m.Burst = true
+				continue
+			case meterStats:
+				m.Stats = true
+				continue
+			}
+
+			if strings.HasPrefix(f, meterBandsDesc) {
+				// The first band's type may immediately follow "bands=".
+				f = strings.TrimPrefix(f, meterBandsDesc)
+				band = &MeterBand{}
+				if f == "" {
+					continue
+				}
+			}
+		}
+
+		kv := strings.SplitN(f, "=", 2)
+		if len(kv) != 2 {
+			return &MeterError{
+				Str: f,
+				Err: ErrInvalidMeter,
+			}
+		}
+
+		if band == nil {
+			if kv[0] != meterID {
+				return &MeterError{
+					Str: f,
+					Err: ErrInvalidMeter,
+				}
+			}
+
+			id, err := strconv.Atoi(kv[1])
+			if err != nil {
+				return &MeterError{
+					Str: f,
+					Err: err,
+				}
+			}
+
+			m.ID = id
+			hasID = true
+			continue
+		}
+
+		var err error
+		switch kv[0] {
+		case bandType:
+			// Each band begins with its type.
+			if band.Type != "" {
+				m.Bands = append(m.Bands, *band)
+				band = &MeterBand{}
+			}
+
+			band.Type = MeterBandType(kv[1])
+		case bandRate:
+			band.Rate, err = strconv.Atoi(kv[1])
+		case bandBurstSize:
+			band.BurstSize, err = strconv.Atoi(kv[1])
+		case bandPrecLevel:
+			band.PrecLevel, err = strconv.Atoi(kv[1])
+		default:
+			err = ErrInvalidMeter
+		}
+		if err != nil {
+			return &MeterError{
+				Str: f,
+				Err: err,
+			}
+		}
+	}
+
+	if !hasID {
+		return &MeterError{
+			Str: s,
+			Err: ErrInvalidMeter,
+		}
+	}
+
+	if band != nil && band.Type != "" {
+		m.Bands = append(m.Bands, *band)
+	}
+
+	return nil
+}
+
+// MeterStats contains statistics about an OpenFlow meter and its bands.
+type MeterStats struct {
+	MeterID       int
+	FlowCount     uint64
+	PacketInCount uint64
+	ByteInCount   uint64
+	Bands         []MeterBandStats
+}
+
+// MeterBandStats contains statistics about a band of an OpenFlow meter.
+type MeterBandStats struct {
+	PacketCount uint64
+	ByteCount   uint64
+}
+
+// UnmarshalText unmarshals a MeterStats from textual form as output by
+// 'ovs-ofctl meter-stats':
+//  meter:1 flow_count:0 packet_in_count:0 byte_in_count:0 duration:1.234s bands:
+//  0: packet_count:0 byte_count:0
+func (m *MeterStats) UnmarshalText(b []byte) error {
+	// Make a copy per documentation for encoding.TextUnmarshaler.
+	s := string(b)
+
+	// Constants only needed within this method, to avoid polluting the
+	// package namespace with generic names
+	const (
+		flowCount     = "flow_count"
+		packetInCount = "packet_in_count"
+		byteInCount   = "byte_in_count"
+		packetCount   = "packet_count"
+		byteCount     = "byte_count"
+	)
+
+	*m = MeterStats{}
+
+	var (
+		hasID bool
+		band  *MeterBandStats
+	)
+
+	for _, f := range strings.Fields(s) {
+		kv := strings.SplitN(f, ":", 2)
+		if len(kv) != 2 {
+			return ErrInvalidMeterStats
+		}
+
+		if kv[0] == meterBands {
+			continue
+		}
+
+		// Each band's statistics begin with its index, such as "0:".
+		if kv[1] == "" {
+			if _, err := strconv.Atoi(kv[0]); err != nil {
+				return ErrInvalidMeterStats
+			}
+
+			m.Bands = append(m.Bands, MeterBandStats{})
+			band = &m.Bands[len(m.Bands)-1]
+			continue
+		}
+
+		var err error
+		switch {
+		case kv[0] == meterID && band == nil:
+			m.MeterID, err = strconv.Atoi(kv[1])
+			hasID = true
+		case kv[0] == flowCount && band == nil:
+			m.FlowCount, err = strconv.ParseUint(kv[1], 10, 64)
+		case kv[0] == packetInCount && band == nil:
+			m.PacketInCount, err = strconv.ParseUint(kv[1], 10, 64)
+		case kv[0] == byteInCount && band == nil:
+			m.ByteInCount, err = strconv.ParseUint(kv[1], 10, 64)
+		case kv[0] == duration && band == nil:
+			// Ignore duration.
+		case kv[0] == packetCount && band != nil:
+			band.PacketCount, err = strconv.ParseUint(kv[1], 10, 64)
+		case kv[0] == byteCount && band != nil:
+			band.ByteCount, err = strconv.ParseUint(kv[1], 10, 64)
+		default:
+			return ErrInvalidMeterStats
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if !hasID {
+		return ErrInvalidMeterStats
+	}
+
+	return nil
+}
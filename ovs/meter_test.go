@@ -0,0 +1,278 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMeterMarshalText(t *testing.T) {
+	var tests = []struct {
+		desc string
+		m    *Meter
+		s    string
+		err  error
+	}{
+		{
+			desc: "negative ID",
+			m: &Meter{
+				ID: -1,
+			},
+			err: errMeterNegativeID,
+		},
+		{
+			desc: "kbps and pktps",
+			m: &Meter{
+				ID:               1,
+				Kbps:             true,
+				PacketsPerSecond: true,
+			},
+			err: errMeterRateUnits,
+		},
+		{
+			desc: "no bands",
+			m: &Meter{
+				ID: 1,
+			},
+			err: errMeterNoBands,
+		},
+		{
+			desc: "unknown band type",
+			m: &Meter{
+				ID: 1,
+				Bands: []MeterBand{{
+					Type: "foo",
+					Rate: 1000,
+				}},
+			},
+			err: errMeterBandType,
+		},
+		{
+			desc: "drop",
+			m: &Meter{
+				ID:    1,
+				Kbps:  true,
+				Burst: true,
+				Stats: true,
+				Bands: []MeterBand{{
+					Type:      MeterBandTypeDrop,
+					Rate:      1000,
+					BurstSize: 100,
+				}},
+			},
+			s: "meter=1,kbps,burst,stats,bands=type=drop rate=1000 burst_size=100",
+		},
+		{
+			desc: "drop and dscp_remark",
+			m: &Meter{
+				ID:               2,
+				PacketsPerSecond: true,
+				Bands: []MeterBand{
+					{
+						Type:      MeterBandTypeDSCPRemark,
+						Rate:      100,
+						PrecLevel: 1,
+					},
+					{
+						Type: MeterBandTypeDrop,
+						Rate: 200,
+					},
+				},
+			},
+			s: "meter=2,pktps,bands=type=dscp_remark rate=100 prec_level=1 type=drop rate=200",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			b, err := tt.m.MarshalText()
+			if err != nil {
+				merr, ok := err.(*MeterError)
+				if !ok || merr.Err != tt.err {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				return
+			}
+			if tt.err != nil {
+				t.Fatalf("expected error %v, but none occurred", tt.err)
+			}
+
+			if diff := cmp.Diff(tt.s, string(b)); diff != "" {
+				t.Fatalf("unexpected meter text (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMeterUnmarshalText(t *testing.T) {
+	var tests = []struct {
+		desc string
+		s    string
+		m    *Meter
+		ok   bool
+	}{
+		{
+			desc: "empty string",
+		},
+		{
+			desc: "meter ID missing",
+			s:    "kbps bands=\ntype=drop rate=1000",
+		},
+		{
+			desc: "bad meter ID",
+			s:    "meter=foo kbps bands=\ntype=drop rate=1000",
+		},
+		{
+			desc: "unknown band field",
+			s:    "meter=1 kbps bands=\ntype=drop rate=1000 foo=1",
+		},
+		{
+			desc: "bad band rate",
+			s:    "meter=1 kbps bands=\ntype=drop rate=foo",
+		},
+		{
+			desc: "dump-meters",
+			s:    "meter=1 kbps burst stats bands=\ntype=drop rate=1000 burst_size=100\ntype=dscp_remark rate=2000 burst_size=200 prec_level=1",
+			m: &Meter{
+				ID:    1,
+				Kbps:  true,
+				Burst: true,
+				Stats: true,
+				Bands: []MeterBand{
+					{
+						Type:      MeterBandTypeDrop,
+						Rate:      1000,
+						BurstSize: 100,
+					},
+					{
+						Type:      MeterBandTypeDSCPRemark,
+						Rate:      2000,
+						BurstSize: 200,
+						PrecLevel: 1,
+					},
+				},
+			},
+			ok: true,
+		},
+		{
+			desc: "MarshalText",
+			s:    "meter=2,pktps,bands=type=drop rate=200",
+			m: &Meter{
+				ID:               2,
+				PacketsPerSecond: true,
+				Bands: []MeterBand{{
+					Type: MeterBandTypeDrop,
+					Rate: 200,
+				}},
+			},
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			m := new(Meter)
+			err := m.UnmarshalText([]byte(tt.s))
+			if err != nil && tt.ok {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.ok {
+				if err == nil {
+					t.Fatal("expected an error, but none occurred")
+				}
+
+				return
+			}
+
+			if diff := cmp.Diff(tt.m, m); diff != "" {
+				t.Fatalf("unexpected meter (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMeterStatsUnmarshalText(t *testing.T) {
+	var tests = []struct {
+		desc  string
+		s     string
+		stats *MeterStats
+		ok    bool
+	}{
+		{
+			desc: "empty string",
+		},
+		{
+			desc: "meter ID missing",
+			s:    "flow_count:0 packet_in_count:0 byte_in_count:0 bands:",
+		},
+		{
+			desc: "unknown field",
+			s:    "meter:1 flow_count:0 foo:0 bands:",
+		},
+		{
+			desc: "bad flow count",
+			s:    "meter:1 flow_count:foo bands:",
+		},
+		{
+			desc: "band field before band",
+			s:    "meter:1 packet_count:0",
+		},
+		{
+			desc: "OK",
+			s:    "meter:1 flow_count:2 packet_in_count:30 byte_in_count:3000 duration:12.345s bands:\n0: packet_count:10 byte_count:1000\n1: packet_count:5 byte_count:500",
+			stats: &MeterStats{
+				MeterID:       1,
+				FlowCount:     2,
+				PacketInCount: 30,
+				ByteInCount:   3000,
+				Bands: []MeterBandStats{
+					{
+						PacketCount: 10,
+						ByteCount:   1000,
+					},
+					{
+						PacketCount: 5,
+						ByteCount:   500,
+					},
+				},
+			},
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			stats := new(MeterStats)
+			err := stats.UnmarshalText([]byte(tt.s))
+			if err != nil && tt.ok {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.ok {
+				if err == nil {
+					t.Fatal("expected an error, but none occurred")
+				}
+
+				return
+			}
+
+			if diff := cmp.Diff(tt.stats, stats); diff != "" {
+				t.Fatalf("unexpected meter stats (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
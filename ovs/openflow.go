@@ -21,6 +21,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 )
 
 var (
@@ -33,6 +35,10 @@ var (
 	errNotCommitted = errors.New("flow bundle not committed, discarding flows")
 )
 
+// flagStrict is the 'ovs-ofctl' flag used to match flows exactly, including
+// their priority, when modifying or deleting flows.
+const flagStrict = "--strict"
+
 // An OpenFlowService is used in a Client to execute 'ovs-ofctl' commands.
 type OpenFlowService struct {
 	// Wrapped Client for ExecFunc and debugging.
@@ -69,10 +75,26 @@ type flowDirective struct {
 	flow      string
 }
 
+// isGroup determines if a flowDirective modifies a group rather than a flow.
+func (d flowDirective) isGroup() bool {
+	return strings.HasPrefix(d.directive, dirGroup+" ")
+}
+
 // Possible flowDirective directive values.
 const (
-	dirAdd    = "add"
-	dirDelete = "delete"
+	dirAdd          = "add"
+	dirDelete       = "delete"
+	dirDeleteStrict = "delete_strict"
+	dirModifyStrict = "modify_strict"
+
+	dirGroup       = "group"
+	dirGroupAdd    = dirGroup + " add"
+	dirGroupModify = dirGroup + " modify"
+	dirGroupDelete = dirGroup + " delete"
+
+	// dirFlow prefixes flow directives in bundles which also contain
+	// group directives.
+	dirFlow = "flow"
 )
 
 // Add pushes zero or more Flows on to the transaction, to be added by
@@ -107,6 +129,95 @@ func (tx *FlowTransaction) Delete(flows ...*MatchFlow) {
 	tx.push(dirDelete, tms...)
 }
 
+// DeleteStrict pushes zero or more MatchFlows on to the transaction, to be
+// deleted by Open vSwitch only if their matching fields and priority match
+// exactly.  If any of the flows are invalid, DeleteStrict becomes a no-op and
+// the error will be surfaced when Commit is called.
+func (tx *FlowTransaction) DeleteStrict(flows ...*MatchFlow) {
+	if tx.err != nil {
+		return
+	}
+
+	for _, f := range flows {
+		fb, err := f.marshalText(true)
+		if err != nil {
+			tx.err = err
+			return
+		}
+
+		tx.flows = append(tx.flows, flowDirective{
+			directive: dirDeleteStrict,
+			flow:      string(fb),
+		})
+	}
+}
+
+// ModifyStrict pushes zero or more Flows on to the transaction, to replace
+// the actions and cookie of the flows in Open vSwitch whose matching fields
+// and priority match exactly.  The timeouts, flags and statistics of the
+// existing flows are retained.  If any of the flows are invalid, ModifyStrict
+// becomes a no-op and the error will be surfaced when Commit is called.
+func (tx *FlowTransaction) ModifyStrict(flows ...*Flow) {
+	if tx.err != nil {
+		return
+	}
+
+	tms := make([]encoding.TextMarshaler, 0, len(flows))
+	for _, f := range flows {
+		tms = append(tms, f)
+	}
+
+	tx.push(dirModifyStrict, tms...)
+}
+
+// AddGroups pushes zero or more Groups on to the transaction, to be added
+// by Open vSwitch.  If any of the groups are invalid, AddGroups becomes a
+// no-op and the error will be surfaced when Commit is called.
+func (tx *FlowTransaction) AddGroups(groups ...*Group) {
+	if tx.err != nil {
+		return
+	}
+
+	tms := make([]encoding.TextMarshaler, 0, len(groups))
+	for _, g := range groups {
+		tms = append(tms, g)
+	}
+
+	tx.push(dirGroupAdd, tms...)
+}
+
+// ModGroups pushes zero or more Groups on to the transaction, to replace
+// existing groups with the same IDs in Open vSwitch.  If any of the groups
+// are invalid, ModGroups becomes a no-op and the error will be surfaced
+// when Commit is called.
+func (tx *FlowTransaction) ModGroups(groups ...*Group) {
+	if tx.err != nil {
+		return
+	}
+
+	tms := make([]encoding.TextMarshaler, 0, len(groups))
+	for _, g := range groups {
+		tms = append(tms, g)
+	}
+
+	tx.push(dirGroupModify, tms...)
+}
+
+// DeleteGroups pushes zero or more group IDs on to the transaction, for
+// the groups to be deleted by Open vSwitch.
+func (tx *FlowTransaction) DeleteGroups(ids ...int) {
+	if tx.err != nil {
+		return
+	}
+
+	for _, id := range ids {
+		tx.flows = append(tx.flows, flowDirective{
+			directive: dirGroupDelete,
+			flow:      groupID + "=" + strconv.Itoa(id),
+		})
+	}
+}
+
 // push pushes zero or more encoding.TextMarshalers on to the transaction
 // (typically a Flow or MatchFlow).
 func (tx *FlowTransaction) push(directive string, flows ...encoding.TextMarshaler) {
@@ -148,7 +259,10 @@ func (tx *FlowTransaction) Discard(err error) error {
 // AddFlowBundle creates an Open vSwitch flow bundle and enables adding and
 // removing flows to and from the specified bridge using a FlowTransaction.
 // This function enables atomic addition and deletion of flows to and from
-// Open vSwitch.
+// Open vSwitch.  Groups may also be added, modified, and deleted within the
+// same bundle; groups must be added before any flows which reference them.
+// Bundles containing groups are applied using 'ovs-ofctl bundle' rather than
+// 'ovs-ofctl add-flow'.
 func (o *OpenFlowService) AddFlowBundle(bridge string, fn func(tx *FlowTransaction) error) error {
 	// Flows will be added to and read from an in-memory buffer.  The buffer's
 	// contents are piped to 'ovs-ofctl' using stdin.
@@ -165,16 +279,32 @@ func (o *OpenFlowService) AddFlowBundle(bridge string, fn func(tx *FlowTransacti
 		return errNotCommitted
 	}
 
+	// The 'add-flow' command only accepts flow directives.  Group directives
+	// require the 'bundle' command, in which flow directives are prefixed
+	// with "flow", as in "flow add priority=10,ip,actions=drop".
+	cmd, prefix := "add-flow", ""
+	for _, flow := range tx.flows {
+		if flow.isGroup() {
+			cmd, prefix = "bundle", dirFlow+" "
+			break
+		}
+	}
+
 	for _, flow := range tx.flows {
 		// Syntax for adding a flow in the file is:
 		// "add priority=10,ip,actions=drop\n"
-		s := fmt.Sprintf("%s %s\n", flow.directive, flow.flow)
+		directive := flow.directive
+		if !flow.isGroup() {
+			directive = prefix + directive
+		}
+
+		s := fmt.Sprintf("%s %s\n", directive, flow.flow)
 		if _, err := io.WriteString(buf, s); err != nil {
 			return err
 		}
 	}
 
-	args := []string{"--bundle", "add-flow"}
+	args := []string{"--bundle", cmd}
 	args = append(args, o.c.ofctlFlags...)
 	// Read from stdin.
 	args = append(args, bridge, "-")
@@ -182,6 +312,38 @@ func (o *OpenFlowService) AddFlowBundle(bridge string, fn func(tx *FlowTransacti
 	return o.pipe(buf, args...)
 }
 
+// ModFlows modifies the actions of all flows on a bridge attached to Open
+// vSwitch which match the matching fields of flow, without resetting
+// their counters.  Flow priority is ignored.
+func (o *OpenFlowService) ModFlows(bridge string, flow *Flow) error {
+	return o.modFlows(bridge, flow, false)
+}
+
+// ModFlowsStrict modifies the actions of the flow on a bridge attached to
+// Open vSwitch whose matching fields and priority exactly match flow,
+// without resetting its counters.
+func (o *OpenFlowService) ModFlowsStrict(bridge string, flow *Flow) error {
+	return o.modFlows(bridge, flow, true)
+}
+
+// modFlows modifies flows, optionally using strict matching.
+func (o *OpenFlowService) modFlows(bridge string, flow *Flow, strict bool) error {
+	fb, err := flow.MarshalText()
+	if err != nil {
+		return err
+	}
+
+	args := []string{"mod-flows"}
+	if strict {
+		args = append(args, flagStrict)
+	}
+	args = append(args, o.c.ofctlFlags...)
+	args = append(args, []string{bridge, string(fb)}...)
+
+	_, err = o.exec(args...)
+	return err
+}
+
 // DelFlows removes flows that match MatchFlow from a bridge attached to Open vSwitch.
 //
 // If flow is nil, all flows will be deleted from the specified bridge.
@@ -206,6 +368,305 @@ func (o *OpenFlowService) DelFlows(bridge string, flow *MatchFlow) error {
 	return err
 }
 
+// DelFlowsStrict removes the flow whose matching fields and priority
+// exactly match MatchFlow from a bridge attached to Open vSwitch.
+//
+// Unlike DelFlows, flow must not be nil.
+func (o *OpenFlowService) DelFlowsStrict(bridge string, flow *MatchFlow) error {
+	if flow == nil {
+		return &MatchFlowError{
+			Err: errEmptyMatchFlow,
+		}
+	}
+
+	fb, err := flow.marshalText(true)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"del-flows", flagStrict}
+	args = append(args, o.c.ofctlFlags...)
+	args = append(args, []string{bridge, string(fb)}...)
+
+	_, err = o.exec(args...)
+	return err
+}
+
+// AddGroup adds a Group to a bridge attached to Open vSwitch.
+func (o *OpenFlowService) AddGroup(bridge string, group *Group) error {
+	return o.group("add-group", bridge, group)
+}
+
+// ModGroup modifies an existing Group on a bridge attached to Open vSwitch,
+// replacing its type and buckets.
+func (o *OpenFlowService) ModGroup(bridge string, group *Group) error {
+	return o.group("mod-group", bridge, group)
+}
+
+// DelGroups removes the groups with the specified IDs from a bridge attached
+// to Open vSwitch.
+//
+// If no IDs are specified, all groups will be deleted from the specified bridge.
+func (o *OpenFlowService) DelGroups(bridge string, ids ...int) error {
+	args := []string{"del-groups"}
+	args = append(args, o.c.ofctlFlags...)
+	args = append(args, bridge)
+
+	if len(ids) == 0 {
+		_, err := o.exec(args...)
+		return err
+	}
+
+	for _, id := range ids {
+		if _, err := o.exec(append(args, groupID+"="+strconv.Itoa(id))...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DumpGroups retrieves all groups for the specified bridge.
+func (o *OpenFlowService) DumpGroups(bridge string) ([]*Group, error) {
+	args := []string{"dump-groups", bridge}
+	args = append(args, o.c.ofctlFlags...)
+
+	out, err := o.exec(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []*Group
+	err = parseEachLine(out, dumpGroupsPrefix, func(b []byte) error {
+		g := new(Group)
+		if err := g.UnmarshalText(b); err != nil {
+			return err
+		}
+
+		groups = append(groups, g)
+		return nil
+	})
+
+	return groups, err
+}
+
+// DumpGroupStats retrieves statistics about all groups for the specified
+// bridge.
+func (o *OpenFlowService) DumpGroupStats(bridge string) ([]*GroupStats, error) {
+	args := []string{"dump-group-stats", bridge}
+	args = append(args, o.c.ofctlFlags...)
+
+	out, err := o.exec(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats []*GroupStats
+	err = parseEachLine(out, dumpGroupStatsPrefix, func(b []byte) error {
+		s := new(GroupStats)
+		if err := s.UnmarshalText(b); err != nil {
+			return err
+		}
+
+		stats = append(stats, s)
+		return nil
+	})
+
+	return stats, err
+}
+
+// AddMeter adds a Meter to a bridge attached to Open vSwitch.
+func (o *OpenFlowService) AddMeter(bridge string, meter *Meter) error {
+	return o.meter("add-meter", bridge, meter)
+}
+
+// ModMeter modifies an existing Meter on a bridge attached to Open vSwitch,
+// replacing its flags and bands.
+func (o *OpenFlowService) ModMeter(bridge string, meter *Meter) error {
+	return o.meter("mod-meter", bridge, meter)
+}
+
+// DelMeters removes the meters with the specified IDs from a bridge attached
+// to Open vSwitch.
+//
+// If no IDs are specified, all meters will be deleted from the specified bridge.
+func (o *OpenFlowService) DelMeters(bridge string, ids ...int) error {
+	args := []string{"del-meters"}
+	args = append(args, o.c.ofctlFlags...)
+	args = append(args, bridge)
+
+	if len(ids) == 0 {
+		_, err := o.exec(args...)
+		return err
+	}
+
+	for _, id := range ids {
+		if _, err := o.exec(append(args, meterID+"="+strconv.Itoa(id))...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DumpMeters retrieves all meters for the specified bridge.
+func (o *OpenFlowService) DumpMeters(bridge string) ([]*Meter, error) {
+	args := []string{"dump-meters", bridge}
+	args = append(args, o.c.ofctlFlags...)
+
+	out, err := o.exec(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var meters []*Meter
+	err = parseEachMeter(out, dumpMetersPrefix, meterID+"=", func(b []byte) error {
+		m := new(Meter)
+		if err := m.UnmarshalText(b); err != nil {
+			return err
+		}
+
+		meters = append(meters, m)
+		return nil
+	})
+
+	return meters, err
+}
+
+// MeterStats retrieves statistics about all meters for the specified bridge.
+func (o *OpenFlowService) MeterStats(bridge string) ([]*MeterStats, error) {
+	args := []string{"meter-stats", bridge}
+	args = append(args, o.c.ofctlFlags...)
+
+	out, err := o.exec(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats []*MeterStats
+	err = parseEachMeter(out, meterStatsPrefix, meterID+":", func(b []byte) error {
+		s := new(MeterStats)
+		if err := s.UnmarshalText(b); err != nil {
+			return err
+		}
+
+		stats = append(stats, s)
+		return nil
+	})
+
+	return stats, err
+}
+
+// meter calls 'ovs-ofctl' with a command which accepts a single meter.
+func (o *OpenFlowService) meter(command string, bridge string, meter *Meter) error {
+	mb, err := meter.MarshalText()
+	if err != nil {
+		return err
+	}
+
+	args := []string{command}
+	args = append(args, o.c.ofctlFlags...)
+	args = append(args, []string{bridge, string(mb)}...)
+
+	_, err = o.exec(args...)
+	return err
+}
+
+// group calls 'ovs-ofctl' with a command which accepts a single group.
+func (o *OpenFlowService) group(command string, bridge string, group *Group) error {
+	gb, err := group.MarshalText()
+	if err != nil {
+		return err
+	}
+
+	args := []string{command}
+	args = append(args, o.c.ofctlFlags...)
+	args = append(args, []string{bridge, string(gb)}...)
+
+	_, err = o.exec(args...)
+	return err
+}
+
+// AddTLVMap maps Geneve tunnel options to tun_metadata fields on a bridge
+// attached to Open vSwitch, so that they can be matched using TunnelMetadata.
+func (o *OpenFlowService) AddTLVMap(bridge string, mappings ...TLVMapping) error {
+	if len(mappings) == 0 {
+		return nil
+	}
+
+	return o.tlvMap("add-tlv-map", bridge, mappings)
+}
+
+// DelTLVMap removes mappings of Geneve tunnel options to tun_metadata fields
+// from a bridge attached to Open vSwitch.
+//
+// If no mappings are specified, all mappings will be deleted from the
+// specified bridge.
+func (o *OpenFlowService) DelTLVMap(bridge string, mappings ...TLVMapping) error {
+	return o.tlvMap("del-tlv-map", bridge, mappings)
+}
+
+// DumpTLVMap retrieves the mappings of Geneve tunnel options to tun_metadata
+// fields for the specified bridge.
+func (o *OpenFlowService) DumpTLVMap(bridge string) ([]TLVMapping, error) {
+	args := []string{"dump-tlv-map", bridge}
+	args = append(args, o.c.ofctlFlags...)
+
+	out, err := o.exec(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mappings []TLVMapping
+		table    bool
+	)
+
+	err = parseEachLine(out, dumpTLVMapPrefix, func(b []byte) error {
+		s := strings.TrimSpace(string(b))
+
+		// Skip the option space summary until the mapping table begins,
+		// and skip the table's headers.
+		switch {
+		case s == dumpTLVMapTable:
+			table = true
+			return nil
+		case !table, s == "", strings.HasPrefix(s, "class"), strings.HasPrefix(s, "-"):
+			return nil
+		}
+
+		var m TLVMapping
+		if err := m.UnmarshalText(b); err != nil {
+			return err
+		}
+
+		mappings = append(mappings, m)
+		return nil
+	})
+
+	return mappings, err
+}
+
+// tlvMap runs the specified TLV map command on a bridge with the input
+// mappings, if any.
+func (o *OpenFlowService) tlvMap(command string, bridge string, mappings []TLVMapping) error {
+	args := []string{command}
+	args = append(args, o.c.ofctlFlags...)
+	args = append(args, bridge)
+
+	if len(mappings) > 0 {
+		s, err := marshalTLVMappings(mappings)
+		if err != nil {
+			return err
+		}
+
+		args = append(args, s)
+	}
+
+	_, err := o.exec(args...)
+	return err
+}
+
 // ModPort modifies the specified characteristics for the specified port.
 func (o *OpenFlowService) ModPort(bridge string, port string, action PortAction) error {
 	args := []string{"mod-port"}
@@ -273,6 +734,14 @@ func (o *OpenFlowService) DumpTables(bridge string) ([]*Table, error) {
 // by an incoming packet, it is filtered from the output.
 // We neeed to add a Matchflow to filter the dumpflow results. For example filter based on table, cookie.
 func (o *OpenFlowService) DumpFlowsWithFlowArgs(bridge string, flow *MatchFlow) ([]*Flow, error) {
+	return o.dumpFlows(bridge, flow, func(f *Flow, b []byte) error {
+		return f.unmarshalText(b, o.c.strictFlows)
+	})
+}
+
+// dumpFlows retrieves the flows for the specified bridge which match flow,
+// if provided, using fn to unmarshal each flow.
+func (o *OpenFlowService) dumpFlows(bridge string, flow *MatchFlow, fn func(f *Flow, b []byte) error) ([]*Flow, error) {
 	args := []string{"dump-flows", bridge}
 	args = append(args, o.c.ofctlFlags...)
 	if flow != nil {
@@ -295,7 +764,7 @@ func (o *OpenFlowService) DumpFlowsWithFlowArgs(bridge string, flow *MatchFlow)
 		}
 
 		f := new(Flow)
-		if err := f.UnmarshalText(b); err != nil {
+		if err := fn(f, b); err != nil {
 			return err
 		}
 
@@ -340,6 +809,32 @@ var (
 	// of these.
 	dumpFlowsPrefix = []byte("ST_FLOW reply")
 
+	// dumpGroupsPrefix is a sentinel value returned at the beginning of the
+	// output from 'ovs-ofctl dump-groups'.  The value returned is either
+	// "OFPST_GROUP_DESC reply" or "NXST_GROUP_DESC reply", depending on the
+	// protocol version, so only the common suffix is matched.
+	dumpGroupsPrefix = []byte("ST_GROUP_DESC reply")
+
+	// dumpGroupStatsPrefix is a sentinel value returned at the beginning of
+	// the output from 'ovs-ofctl dump-group-stats'.
+	dumpGroupStatsPrefix = []byte("ST_GROUP reply")
+
+	// dumpMetersPrefix is a sentinel value returned at the beginning of
+	// the output from 'ovs-ofctl dump-meters'.
+	dumpMetersPrefix = []byte("ST_METER_CONFIG reply")
+
+	// meterStatsPrefix is a sentinel value returned at the beginning of
+	// the output from 'ovs-ofctl meter-stats'.
+	meterStatsPrefix = []byte("ST_METER reply")
+
+	// dumpTLVMapPrefix is a sentinel value returned at the beginning of
+	// the output from 'ovs-ofctl dump-tlv-map'.
+	dumpTLVMapPrefix = []byte("TLV_TABLE_REPLY")
+
+	// dumpTLVMapTable is the line which precedes the table of mappings in
+	// the output from 'ovs-ofctl dump-tlv-map'.
+	dumpTLVMapTable = "mapping table:"
+
 	// dumpAggregatePrefix is a sentinel value returned at the beginning of
 	// the output from "ovs-ofctl dump-aggregate"
 	//dumpAggregatePrefix = []byte("NXST_AGGREGATE reply")
@@ -438,6 +933,49 @@ func parseEachLine(in []byte, prefix []byte, fn func(b []byte) error) error {
 	return scanner.Err()
 }
 
+// parseEachMeter parses ovs-ofctl output from the input buffer, ensuring it
+// has the specified prefix, and invoking the input function on each meter's
+// lines.  Each meter begins with a line containing the start prefix, and its
+// bands follow on subsequent lines.
+func parseEachMeter(in []byte, prefix []byte, start string, fn func(b []byte) error) error {
+	var b []byte
+	err := parseEachLine(in, prefix, func(line []byte) error {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			return nil
+		}
+
+		if !bytes.HasPrefix(line, []byte(start)) {
+			// Continuation of the current meter.
+			if b == nil {
+				return io.ErrUnexpectedEOF
+			}
+
+			b = append(b, '\n')
+			b = append(b, line...)
+			return nil
+		}
+
+		if b != nil {
+			if err := fn(b); err != nil {
+				return err
+			}
+		}
+
+		b = line
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if b == nil {
+		return nil
+	}
+
+	return fn(b)
+}
+
 // parseEach parses ovs-ofctl output from the input buffer, ensuring it has the
 // specified prefix, and invoking the input function on each two lines scanned,
 // so more complex structures can be parsed.
@@ -23,6 +23,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestClientOpenFlowAddFlowInvalidFlow(t *testing.T) {
@@ -339,6 +340,781 @@ func TestClientOpenFlowDelFlowsFlushOK(t *testing.T) {
 	}
 }
 
+func TestClientOpenFlowDelFlowsStrictOK(t *testing.T) {
+	bridge := "br0"
+	flow := &MatchFlow{
+		Protocol: ProtocolIPv4,
+		Priority: 100,
+		OutGroup: 2,
+		Table:    AnyTable,
+	}
+
+	// Apply Timeout option to verify arguments
+	c := testClient([]OptionFunc{Timeout(1)}, func(cmd string, args ...string) ([]byte, error) {
+		// Verify correct command and arguments passed, including option flags
+		if want, got := "ovs-ofctl", cmd; want != got {
+			t.Fatalf("incorrect command:\n- want: %v\n-  got: %v",
+				want, got)
+		}
+
+		wantArgs := []string{
+			"--timeout=1",
+			"del-flows",
+			"--strict",
+			string(bridge),
+			"priority=100,ip,out_group=2",
+		}
+		if want, got := wantArgs, args; !reflect.DeepEqual(want, got) {
+			t.Fatalf("incorrect arguments\n- want: %v\n-  got: %v",
+				want, got)
+		}
+
+		return nil, nil
+	})
+
+	if err := c.OpenFlow.DelFlowsStrict(bridge, flow); err != nil {
+		t.Fatalf("unexpected error for Client.OpenFlow.DelFlowsStrict: %v", err)
+	}
+}
+
+func TestClientOpenFlowDelFlowsStrictNilFlow(t *testing.T) {
+	c := testClient(nil, func(cmd string, args ...string) ([]byte, error) {
+		t.Fatalf("no command should be executed")
+		return nil, nil
+	})
+
+	err := c.OpenFlow.DelFlowsStrict("br0", nil)
+	if want, got := (&MatchFlowError{Err: errEmptyMatchFlow}), err; !matchFlowErrorEqual(want, got) {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+			want, got)
+	}
+}
+
+func TestClientOpenFlowModFlowsOK(t *testing.T) {
+	bridge := "br0"
+	flow := &Flow{
+		Priority: 100,
+		Protocol: ProtocolIPv4,
+		Table:    10,
+		Actions:  []Action{Output(2)},
+	}
+
+	var tests = []struct {
+		desc   string
+		strict bool
+		args   []string
+	}{
+		{
+			desc: "non-strict",
+			args: []string{
+				"--timeout=1",
+				"mod-flows",
+				bridge,
+				"priority=100,ip,table=10,idle_timeout=0,actions=output:2",
+			},
+		},
+		{
+			desc:   "strict",
+			strict: true,
+			args: []string{
+				"--timeout=1",
+				"mod-flows",
+				"--strict",
+				bridge,
+				"priority=100,ip,table=10,idle_timeout=0,actions=output:2",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			// Apply Timeout option to verify arguments
+			c := testClient([]OptionFunc{Timeout(1)}, func(cmd string, args ...string) ([]byte, error) {
+				if want, got := "ovs-ofctl", cmd; want != got {
+					t.Fatalf("incorrect command:\n- want: %v\n-  got: %v",
+						want, got)
+				}
+
+				if want, got := tt.args, args; !reflect.DeepEqual(want, got) {
+					t.Fatalf("incorrect arguments\n- want: %v\n-  got: %v",
+						want, got)
+				}
+
+				return nil, nil
+			})
+
+			modFlows := c.OpenFlow.ModFlows
+			if tt.strict {
+				modFlows = c.OpenFlow.ModFlowsStrict
+			}
+
+			if err := modFlows(bridge, flow); err != nil {
+				t.Fatalf("unexpected error for Client.OpenFlow.ModFlows: %v", err)
+			}
+		})
+	}
+}
+
+func TestClientOpenFlowAddGroupOK(t *testing.T) {
+	bridge := "br0"
+	group := &Group{
+		ID:   1,
+		Type: GroupTypeSelect,
+		Buckets: []Bucket{
+			{
+				Weight:  100,
+				Actions: []Action{Output(1)},
+			},
+		},
+	}
+
+	// Apply Timeout option to verify arguments
+	c := testClient([]OptionFunc{Timeout(1), Protocols([]string{ProtocolOpenFlow13})}, func(cmd string, args ...string) ([]byte, error) {
+		// Verify correct command and arguments passed, including option flags
+		if want, got := "ovs-ofctl", cmd; want != got {
+			t.Fatalf("incorrect command:\n- want: %v\n-  got: %v",
+				want, got)
+		}
+
+		wantArgs := []string{
+			"--timeout=1",
+			"add-group",
+			"--protocols=OpenFlow13",
+			string(bridge),
+			"group_id=1,type=select,bucket=weight:100,actions=output:1",
+		}
+		if want, got := wantArgs, args; !reflect.DeepEqual(want, got) {
+			t.Fatalf("incorrect arguments\n- want: %v\n-  got: %v",
+				want, got)
+		}
+
+		return nil, nil
+	})
+
+	if err := c.OpenFlow.AddGroup(bridge, group); err != nil {
+		t.Fatalf("unexpected error for Client.OpenFlow.AddGroup: %v", err)
+	}
+}
+
+func TestClientOpenFlowModGroupInvalidGroup(t *testing.T) {
+	group := &Group{
+		ID:   1,
+		Type: GroupTypeIndirect,
+	}
+
+	c := testClient(nil, func(cmd string, args ...string) ([]byte, error) {
+		t.Fatal("ovs-ofctl should not be executed for an invalid group")
+		return nil, nil
+	})
+
+	err := c.OpenFlow.ModGroup("br0", group)
+	if gerr, ok := err.(*GroupError); !ok || gerr.Err != errGroupNoBuckets {
+		t.Fatalf("unexpected error for Client.OpenFlow.ModGroup: %v", err)
+	}
+}
+
+func TestClientOpenFlowDelGroupsOK(t *testing.T) {
+	tests := []struct {
+		desc string
+		ids  []int
+		want [][]string
+	}{
+		{
+			desc: "all groups",
+			want: [][]string{
+				{"--timeout=1", "del-groups", "br0"},
+			},
+		},
+		{
+			desc: "two groups",
+			ids:  []int{1, 2},
+			want: [][]string{
+				{"--timeout=1", "del-groups", "br0", "group_id=1"},
+				{"--timeout=1", "del-groups", "br0", "group_id=2"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var got [][]string
+			c := testClient([]OptionFunc{Timeout(1)}, func(cmd string, args ...string) ([]byte, error) {
+				if want, got := "ovs-ofctl", cmd; want != got {
+					t.Fatalf("incorrect command:\n- want: %v\n-  got: %v",
+						want, got)
+				}
+
+				got = append(got, args)
+				return nil, nil
+			})
+
+			if err := c.OpenFlow.DelGroups("br0", tt.ids...); err != nil {
+				t.Fatalf("unexpected error for Client.OpenFlow.DelGroups: %v", err)
+			}
+
+			if want := tt.want; !reflect.DeepEqual(want, got) {
+				t.Fatalf("incorrect arguments\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+func TestClientOpenFlowAddTLVMapOK(t *testing.T) {
+	bridge := "br0"
+
+	c := testClient([]OptionFunc{Timeout(1)}, func(cmd string, args ...string) ([]byte, error) {
+		if want, got := "ovs-ofctl", cmd; want != got {
+			t.Fatalf("incorrect command:\n- want: %v\n-  got: %v",
+				want, got)
+		}
+
+		wantArgs := []string{
+			"--timeout=1",
+			"add-tlv-map",
+			bridge,
+			"{class=0xffff,type=0x0,len=4}->tun_metadata0,{class=0xffff,type=0x1,len=8}->tun_metadata1",
+		}
+		if want, got := wantArgs, args; !reflect.DeepEqual(want, got) {
+			t.Fatalf("incorrect arguments\n- want: %v\n-  got: %v",
+				want, got)
+		}
+
+		return nil, nil
+	})
+
+	err := c.OpenFlow.AddTLVMap(bridge,
+		TLVMapping{Class: 0xffff, Type: 0, Length: 4, Field: 0},
+		TLVMapping{Class: 0xffff, Type: 1, Length: 8, Field: 1},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error for Client.OpenFlow.AddTLVMap: %v", err)
+	}
+}
+
+func TestClientOpenFlowAddTLVMapInvalid(t *testing.T) {
+	c := testClient(nil, func(cmd string, args ...string) ([]byte, error) {
+		t.Fatalf("command should not have been executed")
+		return nil, nil
+	})
+
+	err := c.OpenFlow.AddTLVMap("br0", TLVMapping{Class: 0xffff, Length: 3})
+	if want, got := errTLVMappingLength, err; want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+			want, got)
+	}
+}
+
+func TestClientOpenFlowDelTLVMapAll(t *testing.T) {
+	bridge := "br0"
+
+	c := testClient(nil, func(cmd string, args ...string) ([]byte, error) {
+		wantArgs := []string{"del-tlv-map", bridge}
+		if want, got := wantArgs, args; !reflect.DeepEqual(want, got) {
+			t.Fatalf("incorrect arguments\n- want: %v\n-  got: %v",
+				want, got)
+		}
+
+		return nil, nil
+	})
+
+	if err := c.OpenFlow.DelTLVMap(bridge); err != nil {
+		t.Fatalf("unexpected error for Client.OpenFlow.DelTLVMap: %v", err)
+	}
+}
+
+func TestClientOpenFlowDumpTLVMapOK(t *testing.T) {
+	bridge := "br0"
+
+	c := testClient([]OptionFunc{Timeout(1)}, func(cmd string, args ...string) ([]byte, error) {
+		wantArgs := []string{"--timeout=1", "dump-tlv-map", bridge}
+		if want, got := wantArgs, args; !reflect.DeepEqual(want, got) {
+			t.Fatalf("incorrect arguments\n- want: %v\n-  got: %v",
+				want, got)
+		}
+
+		return []byte(`NXT_TLV_TABLE_REPLY (xid=0x4):
+ max option space=256 max fields=64
+ allocated option space=12
+
+ mapping table:
+ class	type	length	match field
+ -----	----	------	-----------
+ 0xffff	0x0	4	tun_metadata0
+ 0xffff	0x1	8	tun_metadata1
+`), nil
+	})
+
+	got, err := c.OpenFlow.DumpTLVMap(bridge)
+	if err != nil {
+		t.Fatalf("unexpected error for Client.OpenFlow.DumpTLVMap: %v", err)
+	}
+
+	want := []TLVMapping{
+		{Class: 0xffff, Type: 0, Length: 4, Field: 0},
+		{Class: 0xffff, Type: 1, Length: 8, Field: 1},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected TLV mappings:\n- want: %v\n-  got: %v",
+			want, got)
+	}
+}
+
+func TestClientOpenFlowDumpTLVMapEmpty(t *testing.T) {
+	c := testClient(nil, func(cmd string, args ...string) ([]byte, error) {
+		return []byte(`NXT_TLV_TABLE_REPLY (xid=0x4):
+ max option space=256 max fields=64
+ allocated option space=0
+
+ mapping table:
+ class	type	length	match field
+ -----	----	------	-----------
+`), nil
+	})
+
+	got, err := c.OpenFlow.DumpTLVMap("br0")
+	if err != nil {
+		t.Fatalf("unexpected error for Client.OpenFlow.DumpTLVMap: %v", err)
+	}
+
+	if len(got) != 0 {
+		t.Fatalf("unexpected TLV mappings: %v", got)
+	}
+}
+
+func TestClientOpenFlowDumpGroupsOK(t *testing.T) {
+	want := []*Group{
+		{
+			ID:   1,
+			Type: GroupTypeSelect,
+			Buckets: []Bucket{
+				{
+					Weight:  100,
+					Actions: []Action{Output(1)},
+				},
+				{
+					Weight:  100,
+					Actions: []Action{Output(2)},
+				},
+			},
+		},
+		{
+			ID:   2,
+			Type: GroupTypeIndirect,
+			Buckets: []Bucket{
+				{
+					Actions: []Action{OutputGroup(1)},
+				},
+			},
+		},
+	}
+
+	bridge := "br0"
+
+	c := testClient([]OptionFunc{Timeout(1)}, func(cmd string, args ...string) ([]byte, error) {
+		// Verify correct command and arguments passed, including option flags
+		if want, got := "ovs-ofctl", cmd; want != got {
+			t.Fatalf("incorrect command:\n- want: %v\n-  got: %v",
+				want, got)
+		}
+
+		wantArgs := []string{"--timeout=1", "dump-groups", string(bridge)}
+		if want, got := wantArgs, args; !reflect.DeepEqual(want, got) {
+			t.Fatalf("incorrect arguments\n- want: %v\n-  got: %v",
+				want, got)
+		}
+
+		return []byte(`
+OFPST_GROUP_DESC reply (OF1.3) (xid=0x2):
+ group_id=1,type=select,bucket=weight:100,actions=output:1,bucket=weight:100,actions=output:2
+ group_id=2,type=indirect,bucket=actions=group:1
+`), nil
+	})
+
+	got, err := c.OpenFlow.DumpGroups(bridge)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want, got := len(want), len(got); want != got {
+		t.Fatalf("unexpected number of groups:\n- want: %d\n-  got: %d",
+			want, got)
+	}
+
+	for i := range want {
+		wb, err := want[i].MarshalText()
+		if err != nil {
+			t.Fatalf("failed to marshal group: %v", err)
+		}
+		gb, err := got[i].MarshalText()
+		if err != nil {
+			t.Fatalf("failed to marshal group: %v", err)
+		}
+
+		if want, got := string(wb), string(gb); want != got {
+			t.Fatalf("[%02d] unexpected group:\n- want: %v\n-  got: %v",
+				i, want, got)
+		}
+	}
+}
+
+func TestClientOpenFlowDumpGroupStatsOK(t *testing.T) {
+	want := []*GroupStats{
+		{
+			GroupID:     1,
+			RefCount:    1,
+			PacketCount: 3,
+			ByteCount:   300,
+			Buckets: []BucketStats{
+				{
+					PacketCount: 1,
+					ByteCount:   100,
+				},
+				{
+					PacketCount: 2,
+					ByteCount:   200,
+				},
+			},
+		},
+		{
+			GroupID: 2,
+			Buckets: []BucketStats{{}},
+		},
+	}
+
+	bridge := "br0"
+
+	c := testClient([]OptionFunc{Timeout(1)}, func(cmd string, args ...string) ([]byte, error) {
+		// Verify correct command and arguments passed, including option flags
+		if want, got := "ovs-ofctl", cmd; want != got {
+			t.Fatalf("incorrect command:\n- want: %v\n-  got: %v",
+				want, got)
+		}
+
+		wantArgs := []string{"--timeout=1", "dump-group-stats", string(bridge)}
+		if want, got := wantArgs, args; !reflect.DeepEqual(want, got) {
+			t.Fatalf("incorrect arguments\n- want: %v\n-  got: %v",
+				want, got)
+		}
+
+		return []byte(`
+OFPST_GROUP reply (OF1.3) (xid=0x2):
+ group_id=1,duration=12.345s,ref_count=1,packet_count=3,byte_count=300,bucket0:packet_count=1,byte_count=100,bucket1:packet_count=2,byte_count=200
+ group_id=2,duration=12.345s,ref_count=0,packet_count=0,byte_count=0,bucket0:packet_count=0,byte_count=0
+`), nil
+	})
+
+	got, err := c.OpenFlow.DumpGroupStats(bridge)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected group stats:\n- want: %+v\n-  got: %+v",
+			want, got)
+	}
+}
+
+func TestClientOpenFlowAddFlowBundleGroupsOK(t *testing.T) {
+	bridge := "br0"
+
+	group := &Group{
+		ID:   1,
+		Type: GroupTypeAll,
+		Buckets: []Bucket{
+			{Actions: []Action{Output(1)}},
+			{Actions: []Action{Output(2)}},
+		},
+	}
+
+	flow := &Flow{
+		Priority: 10,
+		Protocol: ProtocolIPv4,
+		Actions:  []Action{OutputGroup(1)},
+	}
+
+	pipe := Pipe(func(stdin io.Reader, cmd string, args ...string) ([]byte, error) {
+		if want, got := "ovs-ofctl", cmd; want != got {
+			t.Fatalf("incorrect command:\n- want: %v\n-  got: %v",
+				want, got)
+		}
+
+		// Group directives are only accepted by the bundle command.
+		wantArgs := []string{
+			"--timeout=1",
+			"--bundle",
+			"bundle",
+			string(bridge),
+			// Read from stdin.
+			"-",
+		}
+		if want, got := wantArgs, args; !reflect.DeepEqual(want, got) {
+			t.Fatalf("incorrect arguments\n- want: %v\n-  got: %v",
+				want, got)
+		}
+
+		b, err := io.ReadAll(stdin)
+		if err != nil {
+			t.Fatalf("failed to read stdin: %v", err)
+		}
+
+		want := strings.Join([]string{
+			"group add group_id=1,type=all,bucket=actions=output:1,bucket=actions=output:2",
+			"flow add priority=10,ip,table=0,idle_timeout=0,actions=group:1",
+			"group modify group_id=1,type=all,bucket=actions=output:1,bucket=actions=output:2",
+			"flow delete_strict priority=10,ip,table=0",
+			"group delete group_id=2",
+			"",
+		}, "\n")
+
+		if got := string(b); want != got {
+			t.Fatalf("unexpected flow bundle:\n- want: %q\n-  got: %q",
+				want, got)
+		}
+
+		return nil, nil
+	})
+
+	c := testClient([]OptionFunc{Timeout(1), pipe}, nil)
+
+	err := c.OpenFlow.AddFlowBundle(bridge, func(tx *FlowTransaction) error {
+		tx.AddGroups(group)
+		tx.Add(flow)
+		tx.ModGroups(group)
+		tx.DeleteStrict(flow.MatchFlowStrict())
+		tx.DeleteGroups(2)
+
+		return tx.Commit()
+	})
+	if err != nil {
+		t.Fatalf("unexpected error for Client.OpenFlow.AddFlowBundle: %v", err)
+	}
+}
+
+func TestClientOpenFlowAddMeterOK(t *testing.T) {
+	bridge := "br0"
+	meter := &Meter{
+		ID:   1,
+		Kbps: true,
+		Bands: []MeterBand{{
+			Type: MeterBandTypeDrop,
+			Rate: 1000,
+		}},
+	}
+
+	// Apply Timeout option to verify arguments
+	c := testClient([]OptionFunc{Timeout(1), Protocols([]string{ProtocolOpenFlow13})}, func(cmd string, args ...string) ([]byte, error) {
+		// Verify correct command and arguments passed, including option flags
+		if want, got := "ovs-ofctl", cmd; want != got {
+			t.Fatalf("incorrect command:\n- want: %v\n-  got: %v",
+				want, got)
+		}
+
+		wantArgs := []string{
+			"--timeout=1",
+			"add-meter",
+			"--protocols=OpenFlow13",
+			string(bridge),
+			"meter=1,kbps,bands=type=drop rate=1000",
+		}
+		if want, got := wantArgs, args; !reflect.DeepEqual(want, got) {
+			t.Fatalf("incorrect arguments\n- want: %v\n-  got: %v",
+				want, got)
+		}
+
+		return nil, nil
+	})
+
+	if err := c.OpenFlow.AddMeter(bridge, meter); err != nil {
+		t.Fatalf("unexpected error for Client.OpenFlow.AddMeter: %v", err)
+	}
+}
+
+func TestClientOpenFlowModMeterInvalidMeter(t *testing.T) {
+	meter := &Meter{
+		ID: 1,
+	}
+
+	c := testClient(nil, func(cmd string, args ...string) ([]byte, error) {
+		t.Fatal("ovs-ofctl should not be executed for an invalid meter")
+		return nil, nil
+	})
+
+	err := c.OpenFlow.ModMeter("br0", meter)
+	if merr, ok := err.(*MeterError); !ok || merr.Err != errMeterNoBands {
+		t.Fatalf("unexpected error for Client.OpenFlow.ModMeter: %v", err)
+	}
+}
+
+func TestClientOpenFlowDelMetersOK(t *testing.T) {
+	tests := []struct {
+		desc string
+		ids  []int
+		want [][]string
+	}{
+		{
+			desc: "all meters",
+			want: [][]string{
+				{"--timeout=1", "del-meters", "br0"},
+			},
+		},
+		{
+			desc: "two meters",
+			ids:  []int{1, 2},
+			want: [][]string{
+				{"--timeout=1", "del-meters", "br0", "meter=1"},
+				{"--timeout=1", "del-meters", "br0", "meter=2"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var got [][]string
+			c := testClient([]OptionFunc{Timeout(1)}, func(cmd string, args ...string) ([]byte, error) {
+				if want, got := "ovs-ofctl", cmd; want != got {
+					t.Fatalf("incorrect command:\n- want: %v\n-  got: %v",
+						want, got)
+				}
+
+				got = append(got, args)
+				return nil, nil
+			})
+
+			if err := c.OpenFlow.DelMeters("br0", tt.ids...); err != nil {
+				t.Fatalf("unexpected error for Client.OpenFlow.DelMeters: %v", err)
+			}
+
+			if want := tt.want; !reflect.DeepEqual(want, got) {
+				t.Fatalf("incorrect arguments\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+func TestClientOpenFlowDumpMetersOK(t *testing.T) {
+	want := []*Meter{
+		{
+			ID:    1,
+			Kbps:  true,
+			Burst: true,
+			Stats: true,
+			Bands: []MeterBand{{
+				Type:      MeterBandTypeDrop,
+				Rate:      1000,
+				BurstSize: 100,
+			}},
+		},
+		{
+			ID:               2,
+			PacketsPerSecond: true,
+			Bands: []MeterBand{
+				{
+					Type:      MeterBandTypeDSCPRemark,
+					Rate:      100,
+					PrecLevel: 1,
+				},
+				{
+					Type: MeterBandTypeDrop,
+					Rate: 200,
+				},
+			},
+		},
+	}
+
+	bridge := "br0"
+
+	c := testClient([]OptionFunc{Timeout(1)}, func(cmd string, args ...string) ([]byte, error) {
+		// Verify correct command and arguments passed, including option flags
+		if want, got := "ovs-ofctl", cmd; want != got {
+			t.Fatalf("incorrect command:\n- want: %v\n-  got: %v",
+				want, got)
+		}
+
+		wantArgs := []string{"--timeout=1", "dump-meters", string(bridge)}
+		if want, got := wantArgs, args; !reflect.DeepEqual(want, got) {
+			t.Fatalf("incorrect arguments\n- want: %v\n-  got: %v",
+				want, got)
+		}
+
+		return []byte(`
+OFPST_METER_CONFIG reply (OF1.3) (xid=0x2):
+meter=1 kbps burst stats bands=
+type=drop rate=1000 burst_size=100
+
+meter=2 pktps bands=
+type=dscp_remark rate=100 prec_level=1
+type=drop rate=200
+`), nil
+	})
+
+	got, err := c.OpenFlow.DumpMeters(bridge)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected meters:\n- want: %+v\n-  got: %+v",
+			want, got)
+	}
+}
+
+func TestClientOpenFlowMeterStatsOK(t *testing.T) {
+	want := []*MeterStats{
+		{
+			MeterID:       1,
+			FlowCount:     1,
+			PacketInCount: 10,
+			ByteInCount:   1000,
+			Bands: []MeterBandStats{{
+				PacketCount: 2,
+				ByteCount:   200,
+			}},
+		},
+		{
+			MeterID: 2,
+			Bands:   []MeterBandStats{{}, {}},
+		},
+	}
+
+	bridge := "br0"
+
+	c := testClient([]OptionFunc{Timeout(1)}, func(cmd string, args ...string) ([]byte, error) {
+		// Verify correct command and arguments passed, including option flags
+		if want, got := "ovs-ofctl", cmd; want != got {
+			t.Fatalf("incorrect command:\n- want: %v\n-  got: %v",
+				want, got)
+		}
+
+		wantArgs := []string{"--timeout=1", "meter-stats", string(bridge)}
+		if want, got := wantArgs, args; !reflect.DeepEqual(want, got) {
+			t.Fatalf("incorrect arguments\n- want: %v\n-  got: %v",
+				want, got)
+		}
+
+		return []byte(`
+OFPST_METER reply (OF1.3) (xid=0x2):
+meter:1 flow_count:1 packet_in_count:10 byte_in_count:1000 duration:12.345s bands:
+0: packet_count:2 byte_count:200
+
+meter:2 flow_count:0 packet_in_count:0 byte_in_count:0 duration:12.345s bands:
+0: packet_count:0 byte_count:0
+1: packet_count:0 byte_count:0
+`), nil
+	})
+
+	got, err := c.OpenFlow.MeterStats(bridge)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected meter stats:\n- want: %+v\n-  got: %+v",
+			want, got)
+	}
+}
+
 func TestClientOpenSudoOK(t *testing.T) {
 	const bridge = "br0"
 
@@ -865,6 +1641,9 @@ func TestClientOpenFlowDumpFlows(t *testing.T) {
 					Stats: FlowStats{
 						PacketCount: 6,
 						ByteCount:   480,
+						Duration:    9215748 * time.Millisecond,
+						IdleAge:     9206 * time.Second,
+						HardAge:     9215 * time.Second,
 					},
 				},
 			},
@@ -893,6 +1672,9 @@ func TestClientOpenFlowDumpFlows(t *testing.T) {
 					Stats: FlowStats{
 						PacketCount: 6,
 						ByteCount:   480,
+						Duration:    9215748 * time.Millisecond,
+						IdleAge:     9206 * time.Second,
+						HardAge:     9215 * time.Second,
 					},
 				},
 				{
@@ -908,6 +1690,7 @@ func TestClientOpenFlowDumpFlows(t *testing.T) {
 					Stats: FlowStats{
 						PacketCount: 0,
 						ByteCount:   0,
+						Duration:    1121991329 * time.Millisecond,
 					},
 				},
 				{
@@ -927,6 +1710,7 @@ func TestClientOpenFlowDumpFlows(t *testing.T) {
 					Stats: FlowStats{
 						PacketCount: 3,
 						ByteCount:   234,
+						Duration:    83229846 * time.Millisecond,
 					},
 				},
 				{
@@ -944,6 +1728,7 @@ func TestClientOpenFlowDumpFlows(t *testing.T) {
 					Stats: FlowStats{
 						PacketCount: 0,
 						ByteCount:   0,
+						Duration:    1381314983 * time.Millisecond,
 					},
 				},
 				{
@@ -963,6 +1748,9 @@ func TestClientOpenFlowDumpFlows(t *testing.T) {
 					Stats: FlowStats{
 						PacketCount: 0,
 						ByteCount:   0,
+						Duration:    13265 * time.Millisecond,
+						IdleAge:     13 * time.Second,
+						HardAge:     13 * time.Second,
 					},
 				},
 			},
@@ -992,6 +1780,9 @@ NXST_FLOW reply (xid=0x4):
 					Stats: FlowStats{
 						PacketCount: 6,
 						ByteCount:   480,
+						Duration:    9215748 * time.Millisecond,
+						IdleAge:     9206 * time.Second,
+						HardAge:     9215 * time.Second,
 					},
 				},
 				{
@@ -1007,6 +1798,7 @@ NXST_FLOW reply (xid=0x4):
 					Stats: FlowStats{
 						PacketCount: 0,
 						ByteCount:   0,
+						Duration:    1121991329 * time.Millisecond,
 					},
 				},
 				{
@@ -1026,6 +1818,7 @@ NXST_FLOW reply (xid=0x4):
 					Stats: FlowStats{
 						PacketCount: 3,
 						ByteCount:   234,
+						Duration:    83229846 * time.Millisecond,
 					},
 				},
 				{
@@ -1043,6 +1836,7 @@ NXST_FLOW reply (xid=0x4):
 					Stats: FlowStats{
 						PacketCount: 0,
 						ByteCount:   0,
+						Duration:    1381314983 * time.Millisecond,
 					},
 				},
 				{
@@ -1062,6 +1856,9 @@ NXST_FLOW reply (xid=0x4):
 					Stats: FlowStats{
 						PacketCount: 0,
 						ByteCount:   0,
+						Duration:    13265 * time.Millisecond,
+						IdleAge:     13 * time.Second,
+						HardAge:     13 * time.Second,
 					},
 				},
 			},
@@ -1102,6 +1899,32 @@ NXST_FLOW reply (xid=0x4):
 	}
 }
 
+func TestClientOpenFlowDumpFlowsStrict(t *testing.T) {
+	flows := `NXST_FLOW reply (xid=0x4):
+ cookie=0x0, duration=9215.748s, table=0, n_packets=6, n_bytes=480, idle_age=9206, priority=820,pkt_mark=0x1 actions=output:1
+`
+
+	exec := func(cmd string, args ...string) ([]byte, error) {
+		return []byte(flows), nil
+	}
+
+	got, err := testClient(nil, exec).OpenFlow.DumpFlows("br0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []Match{FieldMatch("pkt_mark", "0x1")}
+	if len(got) != 1 || !reflect.DeepEqual(want, got[0].Matches) {
+		t.Fatalf("unexpected flows: %#v", got)
+	}
+
+	_, err = testClient([]OptionFunc{StrictFlowParsing()}, exec).OpenFlow.DumpFlows("br0")
+	if want, got := (&FlowError{Str: "pkt_mark=0x1", Err: ErrUnknownMatch}), err; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+			want, got)
+	}
+}
+
 func TestClientOpenFlowDumpFlowsWithFlowArgs(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -1136,6 +1959,9 @@ func TestClientOpenFlowDumpFlowsWithFlowArgs(t *testing.T) {
 					Stats: FlowStats{
 						PacketCount: 6,
 						ByteCount:   480,
+						Duration:    9215748 * time.Millisecond,
+						IdleAge:     9206 * time.Second,
+						HardAge:     9215 * time.Second,
 					},
 				},
 			},
@@ -1165,6 +1991,9 @@ func TestClientOpenFlowDumpFlowsWithFlowArgs(t *testing.T) {
 					Stats: FlowStats{
 						PacketCount: 6,
 						ByteCount:   480,
+						Duration:    9215748 * time.Millisecond,
+						IdleAge:     9206 * time.Second,
+						HardAge:     9215 * time.Second,
 					},
 				},
 				{
@@ -1180,6 +2009,7 @@ func TestClientOpenFlowDumpFlowsWithFlowArgs(t *testing.T) {
 					Stats: FlowStats{
 						PacketCount: 0,
 						ByteCount:   0,
+						Duration:    1121991329 * time.Millisecond,
 					},
 				},
 			},
@@ -1252,6 +2082,9 @@ func TestClientOpenFlowDumpFlows15(t *testing.T) {
 					Stats: FlowStats{
 						PacketCount: 1127501,
 						ByteCount:   1595250938,
+						Duration:    82301183 * time.Millisecond,
+						IdleAge:     0 * time.Second,
+						HardAge:     82301 * time.Second,
 					},
 				},
 			},
@@ -1279,6 +2112,9 @@ func TestClientOpenFlowDumpFlows15(t *testing.T) {
 					Stats: FlowStats{
 						PacketCount: 1127501,
 						ByteCount:   1595250938,
+						Duration:    82301183 * time.Millisecond,
+						IdleAge:     0 * time.Second,
+						HardAge:     82301 * time.Second,
 					},
 				},
 				{
@@ -1291,6 +2127,9 @@ func TestClientOpenFlowDumpFlows15(t *testing.T) {
 					Stats: FlowStats{
 						PacketCount: 7370490,
 						ByteCount:   893401420,
+						Duration:    82301183 * time.Millisecond,
+						IdleAge:     0 * time.Second,
+						HardAge:     82301 * time.Second,
 					},
 				},
 				{
@@ -1307,6 +2146,9 @@ func TestClientOpenFlowDumpFlows15(t *testing.T) {
 					Stats: FlowStats{
 						PacketCount: 1068,
 						ByteCount:   388186,
+						Duration:    82301183 * time.Millisecond,
+						IdleAge:     3191 * time.Second,
+						HardAge:     82301 * time.Second,
 					},
 				},
 			},
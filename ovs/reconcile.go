@@ -0,0 +1,273 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// errFlowScopeEmpty is returned when ReconcileFlows is called with a
+	// FlowScope which would match flows with any cookie.
+	errFlowScopeEmpty = errors.New("flow scope cookie and cookie mask must not both be zero")
+
+	// errFlowNotInScope is returned when ReconcileFlows is called with a
+	// desired flow whose cookie is not within the FlowScope.
+	errFlowNotInScope = errors.New("flow cookie is not within flow scope")
+
+	// errFlowDuplicate is returned when ReconcileFlows is called with more
+	// than one desired flow with the same table, priority and matches.
+	errFlowDuplicate = errors.New("flow has the same table, priority and matches as another flow")
+
+	// errFlowScopeCollision is returned when ReconcileFlows is called with
+	// a desired flow which would replace a flow outside of the FlowScope.
+	errFlowScopeCollision = errors.New("flow has the same table, priority and matches as a flow outside of flow scope")
+)
+
+// defaultFlowPriority is the priority of flows added to Open vSwitch without
+// a priority.
+const defaultFlowPriority = 32768
+
+// A FlowScope determines the flows on a bridge which are managed by
+// ReconcileFlows, using their cookies.  Flows outside of the scope are never
+// modified or deleted.
+type FlowScope struct {
+	// Cookie and CookieMask select flows whose cookie, masked with
+	// CookieMask, is equal to Cookie masked with CookieMask.  If CookieMask
+	// is not set, Cookie will be matched exactly.  Cookie and CookieMask
+	// must not both be zero.
+	Cookie     uint64
+	CookieMask uint64
+}
+
+// contains determines if a cookie is within the FlowScope.
+func (s FlowScope) contains(cookie uint64) bool {
+	mask := s.CookieMask
+	if mask == 0 {
+		mask = ^uint64(0)
+	}
+
+	return cookie&mask == s.Cookie&mask
+}
+
+// FlowChanges are the changes made to the flows on a bridge by
+// ReconcileFlows.
+type FlowChanges struct {
+	// Added are desired flows which were not present on the bridge.
+	Added []*Flow
+
+	// Modified are desired flows which changed a flow on the bridge with
+	// the same table, priority and matches, but with different actions or
+	// other attributes.
+	Modified []*Flow
+
+	// Deleted are flows within the FlowScope which were present on the
+	// bridge, but were not desired.
+	Deleted []*Flow
+}
+
+// Empty determines if no changes were made.
+func (c *FlowChanges) Empty() bool {
+	return len(c.Added) == 0 && len(c.Modified) == 0 && len(c.Deleted) == 0
+}
+
+// ReconcileFlows makes the flows within scope on a bridge attached to
+// Open vSwitch equal to the desired flows, which must all have cookies within
+// scope.  Flows are compared semantically using Flow.Key and Flow.Equal, so
+// that only flows which differ from the desired flows are changed.  All
+// changes are applied atomically using AddFlowBundle, and are returned so
+// that they may be reported.  No changes are made if a desired flow has the
+// same table, priority and matches as a flow outside of scope, as adding the
+// desired flow would replace that flow.
+//
+// Modified flows which differ only in their actions or cookie are changed
+// using strict modification, which retains the statistics of the flow.
+// Open vSwitch does not modify the timeouts, importance or flags of a flow,
+// so modified flows which differ in those are instead replaced by adding the
+// desired flow, which resets the statistics of the flow.  Flows are deleted
+// using strict matching, so that other flows with more specific matches are
+// not affected.
+func (o *OpenFlowService) ReconcileFlows(bridge string, scope FlowScope, desired []*Flow) (*FlowChanges, error) {
+	changes, replace, err := o.diffFlows(bridge, scope, desired)
+	if err != nil {
+		return nil, err
+	}
+
+	if changes.Empty() {
+		return changes, nil
+	}
+
+	deleted := make([]*MatchFlow, 0, len(changes.Deleted))
+	for _, f := range changes.Deleted {
+		deleted = append(deleted, f.MatchFlowStrict())
+	}
+
+	var modified, replaced []*Flow
+	for _, f := range changes.Modified {
+		if replace[f] {
+			replaced = append(replaced, f)
+			continue
+		}
+
+		modified = append(modified, f)
+	}
+
+	err = o.AddFlowBundle(bridge, func(tx *FlowTransaction) error {
+		tx.DeleteStrict(deleted...)
+		tx.Add(changes.Added...)
+		tx.ModifyStrict(modified...)
+		tx.Add(replaced...)
+		return tx.Commit()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// diffFlows computes the changes required to make the flows within scope on
+// a bridge equal to the desired flows.  It also returns the modified flows
+// which cannot be changed using strict modification, and must be replaced.
+func (o *OpenFlowService) diffFlows(bridge string, scope FlowScope, desired []*Flow) (*FlowChanges, map[*Flow]bool, error) {
+	if scope.Cookie == 0 && scope.CookieMask == 0 {
+		return nil, nil, errFlowScopeEmpty
+	}
+
+	want := make(map[string]*Flow, len(desired))
+	keys := make([]string, 0, len(desired))
+	for _, f := range desired {
+		key, err := f.Key()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !scope.contains(f.Cookie) {
+			return nil, nil, &FlowError{
+				Str: key,
+				Err: errFlowNotInScope,
+			}
+		}
+		if _, ok := want[key]; ok {
+			return nil, nil, &FlowError{
+				Str: key,
+				Err: errFlowDuplicate,
+			}
+		}
+
+		want[key] = f
+		keys = append(keys, key)
+	}
+
+	// Flows outside of the scope are also dumped, to detect desired flows
+	// which would replace them.
+	current, err := o.dumpFlows(bridge, nil, func(f *Flow, b []byte) error {
+		return unmarshalDumpedFlow(f, b, o.c.strictFlows)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changes := &FlowChanges{}
+	replace := make(map[*Flow]bool)
+	have := make(map[string]*Flow, len(current))
+	for _, f := range current {
+		key, err := f.Key()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Flows owned by others are never modified or deleted.
+		if !scope.contains(f.Cookie) {
+			if _, ok := want[key]; ok {
+				return nil, nil, &FlowError{
+					Str: key,
+					Err: errFlowScopeCollision,
+				}
+			}
+
+			continue
+		}
+
+		have[key] = f
+		if _, ok := want[key]; !ok {
+			changes.Deleted = append(changes.Deleted, f)
+		}
+	}
+
+	for _, key := range keys {
+		f := want[key]
+
+		h, ok := have[key]
+		switch {
+		case !ok:
+			changes.Added = append(changes.Added, f)
+		case !f.Equal(h):
+			changes.Modified = append(changes.Modified, f)
+			if !modifiable(f, h) {
+				replace[f] = true
+			}
+		}
+	}
+
+	return changes, replace, nil
+}
+
+// modifiable determines if flow have can be changed into flow want using
+// strict modification, which only changes the actions and cookie of a flow.
+func modifiable(want, have *Flow) bool {
+	wf, _, err := want.canonicalFields()
+	if err != nil {
+		return false
+	}
+	hf, _, err := have.canonicalFields()
+	if err != nil {
+		return false
+	}
+
+	return fieldsWithoutCookie(wf) == fieldsWithoutCookie(hf)
+}
+
+// fieldsWithoutCookie joins canonical flow fields other than the cookie.
+func fieldsWithoutCookie(fields []string) string {
+	out := make([]string, 0, len(fields))
+	for _, s := range fields {
+		if !strings.HasPrefix(s, cookie+"=") {
+			out = append(out, s)
+		}
+	}
+
+	return strings.Join(out, ",")
+}
+
+// unmarshalDumpedFlow unmarshals a flow from a flow dump.  Open vSwitch omits
+// the priority of flows with the default priority from flow dumps, so it is
+// set here to allow comparison with the desired flows.
+func unmarshalDumpedFlow(f *Flow, b []byte, strict bool) error {
+	if err := f.unmarshalText(b, strict); err != nil {
+		return err
+	}
+
+	matchers := strings.SplitN(string(b), keyActions+"=", 2)[0]
+	for _, s := range splitFlowFields(matchers) {
+		if strings.HasPrefix(s, priorityString) {
+			return nil
+		}
+	}
+
+	f.Priority = defaultFlowPriority
+	return nil
+}
@@ -0,0 +1,412 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+const reconcileDump = `NXST_FLOW reply (xid=0x4):
+ cookie=0xab01, duration=10.1s, table=0, n_packets=6, n_bytes=480, idle_age=2, priority=100,tcp,in_port=1,tcp_dst=80 actions=output:2
+ cookie=0xab01, duration=10.1s, table=0, n_packets=0, n_bytes=0, idle_age=2, priority=100,udp,in_port=1 actions=output:2
+ cookie=0xab02, duration=10.1s, table=1, n_packets=0, n_bytes=0, idle_age=2, ip,nw_dst=192.0.2.0/24 actions=drop
+ cookie=0xab04, duration=10.1s, table=2, n_packets=0, n_bytes=0, idle_timeout=30, idle_age=2, priority=100,ip actions=drop
+ cookie=0x1, duration=10.1s, table=0, n_packets=0, n_bytes=0, idle_age=2, priority=10 actions=drop
+`
+
+func TestClientOpenFlowReconcileFlowsOK(t *testing.T) {
+	const bridge = "br0"
+
+	unchanged := &Flow{
+		Priority: 100,
+		Protocol: ProtocolTCPv4,
+		InPort:   1,
+		Matches:  []Match{TransportDestinationPort(80)},
+		Cookie:   0xab01,
+		Actions:  []Action{Output(2)},
+	}
+	modified := &Flow{
+		Priority: 100,
+		Protocol: ProtocolUDPv4,
+		InPort:   1,
+		Cookie:   0xab01,
+		Actions:  []Action{Output(3)},
+	}
+	added := &Flow{
+		Priority: 200,
+		Protocol: ProtocolARP,
+		Cookie:   0xab03,
+		Actions:  []Action{Output(4)},
+	}
+	replaced := &Flow{
+		Priority: 100,
+		Protocol: ProtocolIPv4,
+		Table:    2,
+		Cookie:   0xab04,
+		Actions:  []Action{Drop()},
+	}
+
+	exec := func(cmd string, args ...string) ([]byte, error) {
+		wantArgs := []string{
+			"--timeout=1",
+			"dump-flows",
+			bridge,
+		}
+		if want, got := wantArgs, args; !reflect.DeepEqual(want, got) {
+			t.Fatalf("incorrect arguments\n- want: %v\n-  got: %v",
+				want, got)
+		}
+
+		return []byte(reconcileDump), nil
+	}
+
+	var piped bool
+	pipe := Pipe(func(stdin io.Reader, cmd string, args ...string) ([]byte, error) {
+		piped = true
+
+		wantArgs := []string{"--timeout=1", "--bundle", "add-flow", bridge, "-"}
+		if want, got := wantArgs, args; !reflect.DeepEqual(want, got) {
+			t.Fatalf("incorrect arguments\n- want: %v\n-  got: %v",
+				want, got)
+		}
+
+		b, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			t.Fatalf("failed to read stdin: %v", err)
+		}
+
+		want := "delete_strict priority=32768,ip,nw_dst=192.0.2.0/24,cookie=0x000000000000ab02/-1,table=1\n" +
+			"add priority=200,arp,table=0,idle_timeout=0,cookie=0x000000000000ab03,actions=output:4\n" +
+			"modify_strict priority=100,udp,in_port=1,table=0,idle_timeout=0,cookie=0x000000000000ab01,actions=output:3\n" +
+			"add priority=100,ip,table=2,idle_timeout=0,cookie=0x000000000000ab04,actions=drop\n"
+		if got := string(b); want != got {
+			t.Fatalf("unexpected flow bundle:\n- want: %q\n-  got: %q",
+				want, got)
+		}
+
+		return nil, nil
+	})
+
+	c := testClient([]OptionFunc{Timeout(1), pipe}, exec)
+
+	changes, err := c.OpenFlow.ReconcileFlows(bridge, FlowScope{
+		Cookie:     0xab00,
+		CookieMask: 0xff00,
+	}, []*Flow{unchanged, modified, added, replaced})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !piped {
+		t.Fatal("flow bundle was not added")
+	}
+
+	if want, got := []*Flow{added}, changes.Added; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected added flows:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := []*Flow{modified, replaced}, changes.Modified; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected modified flows:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := 1, len(changes.Deleted); want != got {
+		t.Fatalf("unexpected number of deleted flows:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := uint64(0xab02), changes.Deleted[0].Cookie; want != got {
+		t.Fatalf("unexpected deleted flow cookie:\n- want: %#x\n-  got: %#x", want, got)
+	}
+}
+
+func TestClientOpenFlowReconcileFlowsNoChanges(t *testing.T) {
+	exec := func(cmd string, args ...string) ([]byte, error) {
+		return []byte(`NXST_FLOW reply (xid=0x4):
+ cookie=0xab01, duration=10.1s, table=0, n_packets=6, n_bytes=480, idle_age=2, priority=100,tcp,in_port=1,tcp_dst=80 actions=output:2
+`), nil
+	}
+
+	pipe := Pipe(func(stdin io.Reader, cmd string, args ...string) ([]byte, error) {
+		t.Fatal("flow bundle should not be added without changes")
+		return nil, nil
+	})
+
+	c := testClient([]OptionFunc{pipe}, exec)
+
+	changes, err := c.OpenFlow.ReconcileFlows("br0", FlowScope{Cookie: 0xab01}, []*Flow{{
+		Priority: 100,
+		Protocol: ProtocolTCPv4,
+		InPort:   1,
+		Matches:  []Match{TransportDestinationPort(80)},
+		Cookie:   0xab01,
+		Actions:  []Action{Output(2)},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !changes.Empty() {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+}
+
+func TestClientOpenFlowReconcileFlowsInvalid(t *testing.T) {
+	flow := &Flow{
+		Priority: 100,
+		Cookie:   0xab01,
+		Actions:  []Action{Drop()},
+	}
+
+	tests := []struct {
+		name    string
+		scope   FlowScope
+		desired []*Flow
+		err     error
+	}{
+		{
+			name:    "empty scope",
+			desired: []*Flow{flow},
+			err:     errFlowScopeEmpty,
+		},
+		{
+			name:    "flow not in scope",
+			scope:   FlowScope{Cookie: 0xac00, CookieMask: 0xff00},
+			desired: []*Flow{flow},
+			err:     errFlowNotInScope,
+		},
+		{
+			name:  "duplicate flows",
+			scope: FlowScope{Cookie: 0xab00, CookieMask: 0xff00},
+			desired: []*Flow{flow, {
+				Priority: 100,
+				Cookie:   0xab02,
+				Actions:  []Action{Output(1)},
+			}},
+			err: errFlowDuplicate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testClient(nil, func(cmd string, args ...string) ([]byte, error) {
+				t.Fatal("flows should not be dumped")
+				return nil, nil
+			})
+
+			_, err := c.OpenFlow.ReconcileFlows("br0", tt.scope, tt.desired)
+			if fe, ok := err.(*FlowError); ok {
+				err = fe.Err
+			}
+
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+func TestClientOpenFlowReconcileFlowsScopeCollision(t *testing.T) {
+	exec := func(cmd string, args ...string) ([]byte, error) {
+		return []byte(reconcileDump), nil
+	}
+
+	pipe := Pipe(func(stdin io.Reader, cmd string, args ...string) ([]byte, error) {
+		t.Fatal("flow bundle should not be added with a collision")
+		return nil, nil
+	})
+
+	c := testClient([]OptionFunc{pipe}, exec)
+
+	// The desired flow has the same key as the flow with cookie 0x1.
+	_, err := c.OpenFlow.ReconcileFlows("br0", FlowScope{
+		Cookie:     0xab00,
+		CookieMask: 0xff00,
+	}, []*Flow{{
+		Priority: 10,
+		Cookie:   0xab05,
+		Actions:  []Action{Output(1)},
+	}})
+	if fe, ok := err.(*FlowError); ok {
+		err = fe.Err
+	}
+
+	if want, got := errFlowScopeCollision, err; want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+			want, got)
+	}
+}
+
+func TestFlowScopeContains(t *testing.T) {
+	tests := []struct {
+		name   string
+		scope  FlowScope
+		cookie uint64
+		ok     bool
+	}{
+		{
+			name:   "exact match",
+			scope:  FlowScope{Cookie: 0xab01},
+			cookie: 0xab01,
+			ok:     true,
+		},
+		{
+			name:   "exact mismatch",
+			scope:  FlowScope{Cookie: 0xab01},
+			cookie: 0xab02,
+		},
+		{
+			name:   "masked match",
+			scope:  FlowScope{Cookie: 0xab00, CookieMask: 0xff00},
+			cookie: 0xab02,
+			ok:     true,
+		},
+		{
+			name:   "masked mismatch",
+			scope:  FlowScope{Cookie: 0xab00, CookieMask: 0xff00},
+			cookie: 0xac02,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if want, got := tt.ok, tt.scope.contains(tt.cookie); want != got {
+				t.Fatalf("unexpected scope result:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
+
+func Test_unmarshalDumpedFlow(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		priority int
+	}{
+		{
+			name:     "priority",
+			s:        " cookie=0x1, table=0, priority=100,ip actions=drop",
+			priority: 100,
+		},
+		{
+			name:     "priority zero",
+			s:        " cookie=0x1, table=0, priority=0 actions=drop",
+			priority: 0,
+		},
+		{
+			name:     "no priority",
+			s:        " cookie=0x1, table=0, ip actions=drop",
+			priority: defaultFlowPriority,
+		},
+		{
+			name:     "no priority with skb_priority match",
+			s:        " cookie=0x1, table=0, skb_priority=0x1 actions=drop",
+			priority: defaultFlowPriority,
+		},
+		{
+			name:     "no priority with learned flow priority",
+			s:        " cookie=0x1, table=0, ip actions=learn(table=2,priority=10,NXM_OF_IP_SRC[])",
+			priority: defaultFlowPriority,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := new(Flow)
+			if err := unmarshalDumpedFlow(f, []byte(tt.s), false); err != nil {
+				t.Fatalf("failed to unmarshal flow: %v", err)
+			}
+
+			if want, got := tt.priority, f.Priority; want != got {
+				t.Fatalf("unexpected priority:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+
+	// Flow.UnmarshalText does not apply the default priority.
+	f := new(Flow)
+	if err := f.UnmarshalText([]byte(" cookie=0x1, table=0, ip actions=drop")); err != nil {
+		t.Fatalf("failed to unmarshal flow: %v", err)
+	}
+	if f.Priority != 0 {
+		t.Fatalf("unexpected priority from UnmarshalText: %d", f.Priority)
+	}
+}
+
+func Test_modifiable(t *testing.T) {
+	have := &Flow{
+		Priority: 100,
+		Protocol: ProtocolIPv4,
+		Cookie:   0xab01,
+		Actions:  []Action{Drop()},
+	}
+
+	tests := []struct {
+		name string
+		want *Flow
+		ok   bool
+	}{
+		{
+			name: "actions",
+			want: &Flow{
+				Priority: 100,
+				Protocol: ProtocolIPv4,
+				Cookie:   0xab01,
+				Actions:  []Action{Output(1)},
+			},
+			ok: true,
+		},
+		{
+			name: "cookie",
+			want: &Flow{
+				Priority: 100,
+				Protocol: ProtocolIPv4,
+				Cookie:   0xab02,
+				Actions:  []Action{Drop()},
+			},
+			ok: true,
+		},
+		{
+			name: "idle timeout",
+			want: &Flow{
+				Priority:    100,
+				Protocol:    ProtocolIPv4,
+				IdleTimeout: 30,
+				Cookie:      0xab01,
+				Actions:     []Action{Drop()},
+			},
+		},
+		{
+			name: "flags",
+			want: &Flow{
+				Priority: 100,
+				Protocol: ProtocolIPv4,
+				Flags:    []FlowFlag{FlowFlagSendFlowRemoved},
+				Cookie:   0xab01,
+				Actions:  []Action{Drop()},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if want, got := tt.ok, modifiable(tt.want, have); want != got {
+				t.Fatalf("unexpected modifiable result:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,315 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	// errRegisterIndex is returned when a register index is out of range
+	// for its type of register.
+	errRegisterIndex = errors.New("register index out of range")
+)
+
+// A Uint128 is a 128-bit unsigned integer, used as the value and mask of
+// the 128-bit xxreg registers.
+type Uint128 struct {
+	Hi, Lo uint64
+}
+
+// String returns the hexadecimal representation of a Uint128.
+func (u Uint128) String() string {
+	if u.Hi == 0 {
+		return fmt.Sprintf("%#x", u.Lo)
+	}
+
+	return fmt.Sprintf("%#x%016x", u.Hi, u.Lo)
+}
+
+// GoString returns the Go syntax representation of a Uint128.
+func (u Uint128) GoString() string {
+	return fmt.Sprintf("ovs.Uint128{Hi: %#x, Lo: %#x}", u.Hi, u.Lo)
+}
+
+// isZero indicates if a Uint128 is zero.
+func (u Uint128) isZero() bool {
+	return u.Hi == 0 && u.Lo == 0
+}
+
+// A registerKind is a type of NXM register.
+type registerKind int
+
+// Possible registerKind values.
+const (
+	kindReg registerKind = iota
+	kindXReg
+	kindXXReg
+)
+
+// registerKinds contains the properties of each registerKind, indexed by
+// the registerKind.
+var registerKinds = []struct {
+	// The name of the register in a match, such as "reg".
+	name string
+	// The name of the register in a subfield, such as "NXM_NX_REG".
+	field string
+	// The name of the package's Go functions, such as "Reg".
+	fn string
+	// The number of registers and the number of bits in each.
+	count, bits int
+}{
+	kindReg:   {name: "reg", field: "NXM_NX_REG", fn: "Reg", count: 16, bits: 32},
+	kindXReg:  {name: "xreg", field: "OXM_OF_PKT_REG", fn: "XReg", count: 8, bits: 64},
+	kindXXReg: {name: "xxreg", field: "NXM_NX_XXREG", fn: "XXReg", count: 4, bits: 128},
+}
+
+// A RegisterField is an NXM register, or a range of bits within one, in the
+// subfield notation accepted by actions such as Load, SetField, Move and
+// OutputField, and by the matches in a LearnedFlow.  For example:
+//
+//	Load("0x1", RegField(0).Bits(0, 15).String())
+//
+// produces the action "load:0x1->NXM_NX_REG0[0..15]".
+type RegisterField struct {
+	kind       registerKind
+	index      int
+	start, end int
+	slice      bool
+}
+
+// RegField returns a RegisterField for the 32-bit register regN, where N is
+// in the range 0-15.
+func RegField(n int) RegisterField {
+	return RegisterField{kind: kindReg, index: n}
+}
+
+// XRegField returns a RegisterField for the 64-bit register xregN, where N
+// is in the range 0-7.
+func XRegField(n int) RegisterField {
+	return RegisterField{kind: kindXReg, index: n}
+}
+
+// XXRegField returns a RegisterField for the 128-bit register xxregN, where
+// N is in the range 0-3.
+func XXRegField(n int) RegisterField {
+	return RegisterField{kind: kindXXReg, index: n}
+}
+
+// Bits returns a RegisterField for bits start through end, inclusive, of
+// the register specified by f.
+func (f RegisterField) Bits(start, end int) RegisterField {
+	f.start, f.end, f.slice = start, end, true
+	return f
+}
+
+// String returns the subfield notation for a RegisterField, such as
+// "NXM_NX_REG0[]" or "NXM_NX_REG0[0..15]".
+func (f RegisterField) String() string {
+	name := registerKinds[f.kind].field + strconv.Itoa(f.index)
+
+	switch {
+	case !f.slice:
+		return name + "[]"
+	case f.start == f.end:
+		return fmt.Sprintf("%s[%d]", name, f.start)
+	default:
+		return fmt.Sprintf("%s[%d..%d]", name, f.start, f.end)
+	}
+}
+
+// GoString returns the Go syntax representation of a RegisterField.
+func (f RegisterField) GoString() string {
+	s := fmt.Sprintf("ovs.%sField(%d)", registerKinds[f.kind].fn, f.index)
+	if f.slice {
+		s += fmt.Sprintf(".Bits(%d, %d)", f.start, f.end)
+	}
+
+	return s
+}
+
+// RegMatch matches packets whose 32-bit register regN, where N is in the
+// range 0-15, is equal to value.
+func RegMatch(n int, value uint32) Match {
+	return &registerMatch{
+		kind:  kindReg,
+		index: n,
+		value: Uint128{Lo: uint64(value)},
+	}
+}
+
+// RegMatchWithMask matches packets whose 32-bit register regN, where N is
+// in the range 0-15, is equal to value in the bits set in mask.
+func RegMatchWithMask(n int, value, mask uint32) Match {
+	return &registerMatch{
+		kind:   kindReg,
+		index:  n,
+		value:  Uint128{Lo: uint64(value)},
+		mask:   Uint128{Lo: uint64(mask)},
+		masked: true,
+	}
+}
+
+// XRegMatch matches packets whose 64-bit register xregN, where N is in the
+// range 0-7, is equal to value.
+func XRegMatch(n int, value uint64) Match {
+	return &registerMatch{
+		kind:  kindXReg,
+		index: n,
+		value: Uint128{Lo: value},
+	}
+}
+
+// XRegMatchWithMask matches packets whose 64-bit register xregN, where N is
+// in the range 0-7, is equal to value in the bits set in mask.
+func XRegMatchWithMask(n int, value, mask uint64) Match {
+	return &registerMatch{
+		kind:   kindXReg,
+		index:  n,
+		value:  Uint128{Lo: value},
+		mask:   Uint128{Lo: mask},
+		masked: true,
+	}
+}
+
+// XXRegMatch matches packets whose 128-bit register xxregN, where N is in
+// the range 0-3, is equal to value.
+func XXRegMatch(n int, value Uint128) Match {
+	return &registerMatch{
+		kind:  kindXXReg,
+		index: n,
+		value: value,
+	}
+}
+
+// XXRegMatchWithMask matches packets whose 128-bit register xxregN, where N
+// is in the range 0-3, is equal to value in the bits set in mask.
+func XXRegMatchWithMask(n int, value, mask Uint128) Match {
+	return &registerMatch{
+		kind:   kindXXReg,
+		index:  n,
+		value:  value,
+		mask:   mask,
+		masked: true,
+	}
+}
+
+var _ Match = &registerMatch{}
+
+// A registerMatch is a Match returned by RegMatch, XRegMatch, XXRegMatch
+// and their WithMask variants.
+type registerMatch struct {
+	kind   registerKind
+	index  int
+	value  Uint128
+	mask   Uint128
+	masked bool
+}
+
+// MarshalText implements Match.
+func (m *registerMatch) MarshalText() ([]byte, error) {
+	if m.index < 0 || m.index >= registerKinds[m.kind].count {
+		return nil, errRegisterIndex
+	}
+
+	name := registerKinds[m.kind].name + strconv.Itoa(m.index)
+	if !m.masked {
+		return bprintf("%s=%s", name, m.value), nil
+	}
+
+	return bprintf("%s=%s/%s", name, m.value, m.mask), nil
+}
+
+// GoString implements Match.
+func (m *registerMatch) GoString() string {
+	fn := registerKinds[m.kind].fn
+	switch {
+	case m.kind == kindXXReg && m.masked:
+		return fmt.Sprintf("ovs.XXRegMatchWithMask(%d, %#v, %#v)", m.index, m.value, m.mask)
+	case m.kind == kindXXReg:
+		return fmt.Sprintf("ovs.XXRegMatch(%d, %#v)", m.index, m.value)
+	case m.masked:
+		return fmt.Sprintf("ovs.%sMatchWithMask(%d, %#x, %#x)", fn, m.index, m.value.Lo, m.mask.Lo)
+	default:
+		return fmt.Sprintf("ovs.%sMatch(%d, %#x)", fn, m.index, m.value.Lo)
+	}
+}
+
+// parseRegisterMatch parses a register Match from the input key and value.
+// It returns false if key is not the name of a register.
+func parseRegisterMatch(key string, value string) (Match, bool, error) {
+	// Check the longest names first, as "reg" is a suffix of the others.
+	kind := -1
+	for _, k := range []registerKind{kindXXReg, kindXReg, kindReg} {
+		if strings.HasPrefix(key, registerKinds[k].name) {
+			kind = int(k)
+			break
+		}
+	}
+	if kind == -1 {
+		return nil, false, nil
+	}
+
+	rk := registerKinds[kind]
+	index, err := strconv.Atoi(key[len(rk.name):])
+	if err != nil || index < 0 || index >= rk.count {
+		// Not a register name, such as "regfoo".
+		return nil, false, nil
+	}
+
+	ss := strings.Split(value, "/")
+	if len(ss) > 2 {
+		return nil, true, fmt.Errorf("invalid %s match: %q", key, value)
+	}
+
+	var vals [2]Uint128
+	for i, s := range ss {
+		v, err := parseUint128(s, rk.bits)
+		if err != nil {
+			return nil, true, err
+		}
+		vals[i] = v
+	}
+
+	return &registerMatch{
+		kind:   registerKind(kind),
+		index:  index,
+		value:  vals[0],
+		mask:   vals[1],
+		masked: len(ss) == 2,
+	}, true, nil
+}
+
+// parseUint128 parses a decimal or hexadecimal integer of at most the
+// specified number of bits into a Uint128.
+func parseUint128(s string, bits int) (Uint128, error) {
+	i, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return Uint128{}, fmt.Errorf("invalid integer: %q", s)
+	}
+	if i.Sign() < 0 || i.BitLen() > bits {
+		return Uint128{}, fmt.Errorf("integer %q does not fit in %d bits", s, bits)
+	}
+
+	lo := new(big.Int).And(i, new(big.Int).SetUint64(^uint64(0)))
+	return Uint128{
+		Hi: new(big.Int).Rsh(i, 64).Uint64(),
+		Lo: lo.Uint64(),
+	}, nil
+}
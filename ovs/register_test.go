@@ -0,0 +1,238 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"testing"
+)
+
+func TestRegisterField(t *testing.T) {
+	var tests = []struct {
+		desc string
+		f    RegisterField
+		s    string
+		gs   string
+	}{
+		{
+			desc: "reg",
+			f:    RegField(0),
+			s:    "NXM_NX_REG0[]",
+			gs:   "ovs.RegField(0)",
+		},
+		{
+			desc: "reg bits",
+			f:    RegField(15).Bits(0, 15),
+			s:    "NXM_NX_REG15[0..15]",
+			gs:   "ovs.RegField(15).Bits(0, 15)",
+		},
+		{
+			desc: "reg bit",
+			f:    RegField(1).Bits(3, 3),
+			s:    "NXM_NX_REG1[3]",
+			gs:   "ovs.RegField(1).Bits(3, 3)",
+		},
+		{
+			desc: "xreg bits",
+			f:    XRegField(4).Bits(32, 47),
+			s:    "OXM_OF_PKT_REG4[32..47]",
+			gs:   "ovs.XRegField(4).Bits(32, 47)",
+		},
+		{
+			desc: "xxreg",
+			f:    XXRegField(0).Bits(96, 127),
+			s:    "NXM_NX_XXREG0[96..127]",
+			gs:   "ovs.XXRegField(0).Bits(96, 127)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if want, got := tt.s, tt.f.String(); want != got {
+				t.Fatalf("unexpected field:\n- want: %q\n-  got: %q",
+					want, got)
+			}
+
+			if want, got := tt.gs, tt.f.GoString(); want != got {
+				t.Fatalf("unexpected Go syntax:\n- want: %q\n-  got: %q",
+					want, got)
+			}
+		})
+	}
+}
+
+func TestRegisterFieldActions(t *testing.T) {
+	var tests = []struct {
+		a Action
+		s string
+	}{
+		{
+			a: Load("0x1", RegField(0).Bits(0, 15).String()),
+			s: "load:0x1->NXM_NX_REG0[0..15]",
+		},
+		{
+			a: SetField("0x1", RegField(5).String()),
+			s: "set_field:0x1->NXM_NX_REG5[]",
+		},
+		{
+			a: Move("NXM_OF_IN_PORT[]", XRegField(1).Bits(0, 15).String()),
+			s: "move:NXM_OF_IN_PORT[]->OXM_OF_PKT_REG1[0..15]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			b, err := tt.a.MarshalText()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if want, got := tt.s, string(b); want != got {
+				t.Fatalf("unexpected action:\n- want: %q\n-  got: %q",
+					want, got)
+			}
+
+			// The action must be parsed back to an identical action.
+			a, err := parseAction(tt.s)
+			if err != nil {
+				t.Fatalf("failed to parse action: %v", err)
+			}
+
+			if want, got := tt.a.GoString(), a.GoString(); want != got {
+				t.Fatalf("unexpected parsed action:\n- want: %q\n-  got: %q",
+					want, got)
+			}
+		})
+	}
+}
+
+func TestRegisterMatch(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		m       Match
+		s       string
+		gs      string
+		invalid bool
+	}{
+		{
+			desc: "reg",
+			m:    RegMatch(0, 0x10),
+			s:    "reg0=0x10",
+			gs:   "ovs.RegMatch(0, 0x10)",
+		},
+		{
+			desc: "reg with mask",
+			m:    RegMatchWithMask(3, 0x10, 0xff),
+			s:    "reg3=0x10/0xff",
+			gs:   "ovs.RegMatchWithMask(3, 0x10, 0xff)",
+		},
+		{
+			desc: "reg with zero mask",
+			m:    RegMatchWithMask(3, 0x10, 0),
+			s:    "reg3=0x10/0x0",
+			gs:   "ovs.RegMatchWithMask(3, 0x10, 0x0)",
+		},
+		{
+			desc:    "reg out of range",
+			m:       RegMatch(16, 0x10),
+			invalid: true,
+		},
+		{
+			desc: "xreg",
+			m:    XRegMatch(7, 0x100000000),
+			s:    "xreg7=0x100000000",
+			gs:   "ovs.XRegMatch(7, 0x100000000)",
+		},
+		{
+			desc: "xreg with mask",
+			m:    XRegMatchWithMask(7, 0x100000000, 0xffffffff00000000),
+			s:    "xreg7=0x100000000/0xffffffff00000000",
+			gs:   "ovs.XRegMatchWithMask(7, 0x100000000, 0xffffffff00000000)",
+		},
+		{
+			desc:    "xreg out of range",
+			m:       XRegMatch(8, 0x10),
+			invalid: true,
+		},
+		{
+			desc: "xxreg",
+			m:    XXRegMatch(1, Uint128{Hi: 0x1, Lo: 0x2}),
+			s:    "xxreg1=0x10000000000000002",
+			gs:   "ovs.XXRegMatch(1, ovs.Uint128{Hi: 0x1, Lo: 0x2})",
+		},
+		{
+			desc: "xxreg with mask",
+			m:    XXRegMatchWithMask(1, Uint128{Hi: 0x1, Lo: 0x2}, Uint128{Hi: ^uint64(0)}),
+			s:    "xxreg1=0x10000000000000002/0xffffffffffffffff0000000000000000",
+			gs:   "ovs.XXRegMatchWithMask(1, ovs.Uint128{Hi: 0x1, Lo: 0x2}, ovs.Uint128{Hi: 0xffffffffffffffff, Lo: 0x0})",
+		},
+		{
+			desc:    "xxreg out of range",
+			m:       XXRegMatch(4, Uint128{}),
+			invalid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			b, err := tt.m.MarshalText()
+			if err != nil && !tt.invalid {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.invalid {
+				if err == nil {
+					t.Fatal("expected an error, but none occurred")
+				}
+
+				return
+			}
+
+			if want, got := tt.s, string(b); want != got {
+				t.Fatalf("unexpected match:\n- want: %q\n-  got: %q",
+					want, got)
+			}
+
+			if want, got := tt.gs, tt.m.GoString(); want != got {
+				t.Fatalf("unexpected Go syntax:\n- want: %q\n-  got: %q",
+					want, got)
+			}
+		})
+	}
+}
+
+func TestRegisterFieldLearn(t *testing.T) {
+	a := Learn(&LearnedFlow{
+		Priority: 10,
+		Table:    20,
+		Matches: []Match{
+			RegMatch(0, 0x1),
+			FieldMatch(RegField(1).Bits(0, 15).String(), "NXM_OF_IN_PORT[]"),
+		},
+		Actions: []Action{
+			Load(RegField(2).String(), XXRegField(0).Bits(0, 31).String()),
+			OutputField(RegField(3).Bits(0, 15).String()),
+		},
+	})
+
+	b, err := a.MarshalText()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "learn(priority=10,reg0=0x1,NXM_NX_REG1[0..15]=NXM_OF_IN_PORT[],table=20,idle_timeout=0,fin_hard_timeout=0,hard_timeout=0,load:NXM_NX_REG2[]->NXM_NX_XXREG0[0..31],output:NXM_NX_REG3[0..15])"
+	if got := string(b); want != got {
+		t.Fatalf("unexpected learn action:\n- want: %q\n-  got: %q",
+			want, got)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// errSampleProbability is returned when a SampleSpec does not specify
+	// a sampling probability.
+	errSampleProbability = errors.New("sample probability must be between 1 and 65535")
+)
+
+// A SampleSpec configures the sample action created by Sample.
+type SampleSpec struct {
+	// Probability is the number of packets out of 65535 to sample, so
+	// 65535 samples every packet.  Probability must be non-zero.
+	Probability uint16
+
+	// CollectorSetID identifies the IPFIX collector set to which sampled
+	// packets are sent.
+	CollectorSetID uint32
+
+	// ObsDomainID and ObsPointID are the IPFIX observation domain and
+	// observation point IDs reported with each sampled packet.
+	ObsDomainID uint32
+	ObsPointID  uint32
+}
+
+// Sample samples packets and sends them to an IPFIX or sFlow collector,
+// using the options specified in spec.
+func Sample(spec SampleSpec) Action {
+	return &sampleAction{
+		spec: spec,
+	}
+}
+
+// A sampleAction is an Action which is used by Sample.
+type sampleAction struct {
+	spec SampleSpec
+}
+
+// Constants used repeatedly while marshaling and unmarshaling sample
+// actions.
+const (
+	patSample = "sample(probability=%d,collector_set_id=%d,obs_domain_id=%d,obs_point_id=%d)"
+
+	sampleArgProbability    = "probability"
+	sampleArgCollectorSetID = "collector_set_id"
+	sampleArgObsDomainID    = "obs_domain_id"
+	sampleArgObsPointID     = "obs_point_id"
+)
+
+// MarshalText implements Action.  All arguments are marshaled, in the order
+// used by Open vSwitch.
+func (a *sampleAction) MarshalText() ([]byte, error) {
+	s := a.spec
+	if s.Probability == 0 {
+		return nil, errSampleProbability
+	}
+
+	return bprintf(patSample, s.Probability, s.CollectorSetID, s.ObsDomainID, s.ObsPointID), nil
+}
+
+// GoString implements Action.
+func (a *sampleAction) GoString() string {
+	s := a.spec
+
+	var fields []string
+	if s.Probability != 0 {
+		fields = append(fields, fmt.Sprintf("Probability: %d", s.Probability))
+	}
+	if s.CollectorSetID != 0 {
+		fields = append(fields, fmt.Sprintf("CollectorSetID: %d", s.CollectorSetID))
+	}
+	if s.ObsDomainID != 0 {
+		fields = append(fields, fmt.Sprintf("ObsDomainID: %d", s.ObsDomainID))
+	}
+	if s.ObsPointID != 0 {
+		fields = append(fields, fmt.Sprintf("ObsPointID: %d", s.ObsPointID))
+	}
+
+	return "ovs.Sample(ovs.SampleSpec{" + strings.Join(fields, ", ") + "})"
+}
+
+// parseSample parses the arguments of a sample action of the form
+// "sample(key=value,...)".  Arguments which are not supported by Sample,
+// such as sampling_port or an obs_point_id loaded from a field, result in
+// a RawAction.
+func parseSample(args string, raw string) (Action, error) {
+	var spec SampleSpec
+	for _, f := range splitFields(args) {
+		kv := strings.SplitN(f, "=", 2)
+		if len(kv) != 2 {
+			return RawAction(raw), nil
+		}
+
+		bits := 32
+		var v *uint32
+		switch kv[0] {
+		case sampleArgProbability:
+			bits = 16
+		case sampleArgCollectorSetID:
+			v = &spec.CollectorSetID
+		case sampleArgObsDomainID:
+			v = &spec.ObsDomainID
+		case sampleArgObsPointID:
+			v = &spec.ObsPointID
+		default:
+			return RawAction(raw), nil
+		}
+
+		n, err := strconv.ParseUint(kv[1], 10, bits)
+		if err != nil {
+			// Values which are not numbers, such as NXM_NX_REG0[], cannot
+			// be represented by SampleSpec.
+			if nerr, ok := err.(*strconv.NumError); ok && nerr.Err == strconv.ErrSyntax {
+				return RawAction(raw), nil
+			}
+
+			return nil, err
+		}
+
+		if v == nil {
+			spec.Probability = uint16(n)
+			continue
+		}
+		*v = uint32(n)
+	}
+
+	return Sample(spec), nil
+}
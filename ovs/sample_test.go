@@ -0,0 +1,72 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"testing"
+)
+
+func TestSample(t *testing.T) {
+	var tests = []struct {
+		desc   string
+		a      Action
+		action string
+		err    error
+	}{
+		{
+			desc: "probability only",
+			a: Sample(SampleSpec{
+				Probability: 65535,
+			}),
+			action: "sample(probability=65535,collector_set_id=0,obs_domain_id=0,obs_point_id=0)",
+		},
+		{
+			desc: "all options",
+			a: Sample(SampleSpec{
+				Probability:    100,
+				CollectorSetID: 1,
+				ObsDomainID:    2,
+				ObsPointID:     3,
+			}),
+			action: "sample(probability=100,collector_set_id=1,obs_domain_id=2,obs_point_id=3)",
+		},
+		{
+			desc: "no probability",
+			a: Sample(SampleSpec{
+				CollectorSetID: 1,
+			}),
+			err: errSampleProbability,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			action, err := tt.a.MarshalText()
+
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if err != nil {
+				return
+			}
+
+			if want, got := tt.action, string(action); want != got {
+				t.Fatalf("unexpected Action:\n- want: %q\n-  got: %q",
+					want, got)
+			}
+		})
+	}
+}
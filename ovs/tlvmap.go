@@ -0,0 +1,148 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidTLVMapping is returned when TLV mappings from
+	// 'ovs-ofctl dump-tlv-map' do not match the expected output format.
+	ErrInvalidTLVMapping = errors.New("invalid TLV mapping")
+
+	// errTLVMappingLength is returned when a TLVMapping's length is not a
+	// multiple of 4 between 4 and 124.
+	errTLVMappingLength = errors.New("TLV mapping length must be a multiple of 4 between 4 and 124")
+)
+
+// A TLVMapping maps a Geneve tunnel option, identified by its class and
+// type, to a tun_metadata field so that it can be matched using
+// TunnelMetadata.  It can be marshaled to and from its textual form for
+// use with Open vSwitch.
+type TLVMapping struct {
+	Class uint16
+	Type  uint8
+
+	// Length is the length of the option in bytes.
+	Length int
+
+	// Field is N in the tun_metadataN field the option is mapped to.
+	Field int
+}
+
+// MarshalText marshals a TLVMapping into its textual form, such as
+// "{class=0xffff,type=0x0,len=4}->tun_metadata0".
+func (m *TLVMapping) MarshalText() ([]byte, error) {
+	if m.Length < 4 || m.Length > tunMetadataMaxLen || m.Length%4 != 0 {
+		return nil, errTLVMappingLength
+	}
+	if m.Field < 0 || m.Field >= tunMetadataFields {
+		return nil, errInvalidTunnelMetadata
+	}
+
+	return bprintf("{class=%#x,type=%#x,len=%d}->%s%d",
+		m.Class, m.Type, m.Length, tunMetadata, m.Field), nil
+}
+
+// UnmarshalText unmarshals a TLVMapping from either its textual form, or
+// a row of the mapping table produced by 'ovs-ofctl dump-tlv-map'.
+func (m *TLVMapping) UnmarshalText(b []byte) error {
+	s := strings.TrimSpace(string(b))
+
+	var class, typ, length, field string
+	if strings.HasPrefix(s, "{") {
+		ss := strings.SplitN(s, "}->", 2)
+		if len(ss) != 2 {
+			return ErrInvalidTLVMapping
+		}
+
+		field = ss[1]
+		for _, kv := range strings.Split(strings.TrimPrefix(ss[0], "{"), ",") {
+			kvs := strings.SplitN(kv, "=", 2)
+			if len(kvs) != 2 {
+				return ErrInvalidTLVMapping
+			}
+
+			switch kvs[0] {
+			case "class":
+				class = kvs[1]
+			case "type":
+				typ = kvs[1]
+			case "len":
+				length = kvs[1]
+			default:
+				return ErrInvalidTLVMapping
+			}
+		}
+	} else {
+		// A row of the mapping table, such as:
+		//  0xffff	0x0	4	tun_metadata0
+		ss := strings.Fields(s)
+		if len(ss) != 4 {
+			return ErrInvalidTLVMapping
+		}
+
+		class, typ, length, field = ss[0], ss[1], ss[2], ss[3]
+	}
+
+	c, err := strconv.ParseUint(class, 0, 16)
+	if err != nil {
+		return err
+	}
+	t, err := strconv.ParseUint(typ, 0, 8)
+	if err != nil {
+		return err
+	}
+	l, err := strconv.Atoi(length)
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(field, tunMetadata) {
+		return ErrInvalidTLVMapping
+	}
+	f, err := strconv.Atoi(strings.TrimPrefix(field, tunMetadata))
+	if err != nil {
+		return err
+	}
+
+	*m = TLVMapping{
+		Class:  uint16(c),
+		Type:   uint8(t),
+		Length: l,
+		Field:  f,
+	}
+
+	return nil
+}
+
+// marshalTLVMappings marshals TLVMappings into the comma-separated form
+// accepted by 'ovs-ofctl add-tlv-map' and 'ovs-ofctl del-tlv-map'.
+func marshalTLVMappings(mappings []TLVMapping) (string, error) {
+	ss := make([]string, 0, len(mappings))
+	for _, m := range mappings {
+		b, err := m.MarshalText()
+		if err != nil {
+			return "", err
+		}
+
+		ss = append(ss, string(b))
+	}
+
+	return strings.Join(ss, ","), nil
+}
@@ -0,0 +1,160 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTLVMappingMarshalText(t *testing.T) {
+	var tests = []struct {
+		desc string
+		m    TLVMapping
+		s    string
+		err  error
+	}{
+		{
+			desc: "OK",
+			m: TLVMapping{
+				Class:  0xffff,
+				Type:   0,
+				Length: 4,
+				Field:  0,
+			},
+			s: "{class=0xffff,type=0x0,len=4}->tun_metadata0",
+		},
+		{
+			desc: "length not a multiple of 4",
+			m: TLVMapping{
+				Class:  0xffff,
+				Length: 3,
+			},
+			err: errTLVMappingLength,
+		},
+		{
+			desc: "length too large",
+			m: TLVMapping{
+				Class:  0xffff,
+				Length: 128,
+			},
+			err: errTLVMappingLength,
+		},
+		{
+			desc: "field out of range",
+			m: TLVMapping{
+				Class:  0xffff,
+				Length: 4,
+				Field:  64,
+			},
+			err: errInvalidTunnelMetadata,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			b, err := tt.m.MarshalText()
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("unexpected error:\n- want: %v\n-  got: %v",
+					want, got)
+			}
+			if err != nil {
+				return
+			}
+
+			if want, got := tt.s, string(b); want != got {
+				t.Fatalf("unexpected TLV mapping:\n- want: %q\n-  got: %q",
+					want, got)
+			}
+		})
+	}
+}
+
+func TestTLVMappingUnmarshalText(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		s       string
+		m       TLVMapping
+		invalid bool
+	}{
+		{
+			desc: "textual form",
+			s:    "{class=0xffff,type=0x1,len=8}->tun_metadata2",
+			m: TLVMapping{
+				Class:  0xffff,
+				Type:   1,
+				Length: 8,
+				Field:  2,
+			},
+		},
+		{
+			desc: "mapping table row",
+			s:    " 0x102\t0x80\t124\ttun_metadata63",
+			m: TLVMapping{
+				Class:  0x102,
+				Type:   0x80,
+				Length: 124,
+				Field:  63,
+			},
+		},
+		{
+			desc:    "unknown key",
+			s:       "{class=0xffff,type=0x1,foo=8}->tun_metadata2",
+			invalid: true,
+		},
+		{
+			desc:    "missing field",
+			s:       "{class=0xffff,type=0x1,len=8}",
+			invalid: true,
+		},
+		{
+			desc:    "not tun_metadata",
+			s:       "0xffff 0x0 4 reg0",
+			invalid: true,
+		},
+		{
+			desc:    "class too large",
+			s:       "0x10000 0x0 4 tun_metadata0",
+			invalid: true,
+		},
+		{
+			desc:    "too few fields",
+			s:       "0xffff 0x0 4",
+			invalid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var m TLVMapping
+			err := m.UnmarshalText([]byte(tt.s))
+			if err != nil && !tt.invalid {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.invalid {
+				if err == nil {
+					t.Fatal("expected an error, but none occurred")
+				}
+
+				return
+			}
+
+			if want, got := tt.m, m; !reflect.DeepEqual(want, got) {
+				t.Fatalf("unexpected TLV mapping:\n- want: %#v\n-  got: %#v",
+					want, got)
+			}
+		})
+	}
+}
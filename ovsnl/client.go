@@ -22,6 +22,7 @@ import (
 
 	"github.com/digitalocean/go-openvswitch/ovsnl/internal/ovsh"
 	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/netlink"
 )
 
 // Sizes of various structures, used in unsafe casts.
@@ -30,13 +31,31 @@ const (
 
 	sizeofDPStats         = int(unsafe.Sizeof(ovsh.DPStats{}))
 	sizeofDPMegaflowStats = int(unsafe.Sizeof(ovsh.DPMegaflowStats{}))
+	sizeofFlowStats       = int(unsafe.Sizeof(ovsh.FlowStats{}))
+	sizeofVportStats      = int(unsafe.Sizeof(ovsh.VportStats{}))
+	sizeofZoneLimit       = int(unsafe.Sizeof(ovsh.ZoneLimit{}))
 )
 
 // A Client is a Linux Open vSwitch generic netlink client.
 type Client struct {
+	// CTLimit provides access to CTLimitService methods.
+	CTLimit *CTLimitService
+
 	// Datapath provides access to DatapathService methods.
 	Datapath *DatapathService
 
+	// Flow provides access to FlowService methods.
+	Flow *FlowService
+
+	// Meter provides access to MeterService methods.
+	Meter *MeterService
+
+	// Packet provides access to PacketService methods.
+	Packet *PacketService
+
+	// Vport provides access to VportService methods.
+	Vport *VportService
+
 	c *genetlink.Conn
 }
 
@@ -53,6 +72,14 @@ func New() (*Client, error) {
 	return newClient(c)
 }
 
+// NewFromConn creates a new Open vSwitch generic netlink client using an
+// existing generic netlink connection, such as one created by package
+// ovsnltest.  The Client takes ownership of the connection and closes it
+// when the Client is closed, or when an error is returned.
+func NewFromConn(c *genetlink.Conn) (*Client, error) {
+	return newClient(c)
+}
+
 // newClient is the internal Client constructor, used in tests.
 func newClient(c *genetlink.Conn) (*Client, error) {
 	// Must ensure that the generic netlink connection is closed on any errors
@@ -105,18 +132,65 @@ func (c *Client) init(families []genetlink.Family) error {
 // initFamily initializes a single generic netlink family service.
 func (c *Client) initFamily(f genetlink.Family) error {
 	switch f.Name {
+	case ovsh.CtLimitFamily:
+		c.CTLimit = &CTLimitService{
+			f: f,
+			c: c,
+		}
+		return nil
 	case ovsh.DatapathFamily:
 		c.Datapath = &DatapathService{
 			f: f,
 			c: c,
 		}
 		return nil
+	case ovsh.FlowFamily:
+		c.Flow = &FlowService{
+			f: f,
+			c: c,
+		}
+		return nil
+	case ovsh.MeterFamily:
+		c.Meter = &MeterService{
+			f: f,
+			c: c,
+		}
+		return nil
+	case ovsh.PacketFamily:
+		c.Packet = &PacketService{
+			f: f,
+			c: c,
+		}
+		return nil
+	case ovsh.VportFamily:
+		c.Vport = &VportService{
+			f: f,
+			c: c,
+		}
+		return nil
 	default:
 		// Unknown OVS netlink family, nothing we can do.
 		return fmt.Errorf("unknown OVS generic netlink family: %q", f.Name)
 	}
 }
 
+// execute executes a generic netlink request for a command in family f,
+// prepending an OVS header with the specified datapath interface index to
+// the netlink attributes in attrs.
+func (c *Client) execute(f genetlink.Family, cmd uint8, datapath int, attrs []byte, flags netlink.HeaderFlags) ([]genetlink.Message, error) {
+	req := genetlink.Message{
+		Header: genetlink.Header{
+			Command: cmd,
+			Version: uint8(f.Version),
+		},
+		Data: append(headerBytes(ovsh.Header{
+			Ifindex: int32(datapath),
+		}), attrs...),
+	}
+
+	return c.c.Execute(req, f.ID, flags)
+}
+
 // headerBytes converts an ovsh.Header into a byte slice.
 func headerBytes(h ovsh.Header) []byte {
 	b := *(*[sizeofHeader]byte)(unsafe.Pointer(&h))
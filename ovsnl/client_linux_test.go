@@ -118,6 +118,7 @@ func ovsFamilies(fn genltest.Func) genltest.Func {
 	return func(greq genetlink.Message, nreq netlink.Message) ([]genetlink.Message, error) {
 		if nreq.Header.Type == unix.GENL_ID_CTRL && greq.Header.Command == unix.CTRL_CMD_GETFAMILY {
 			return familyMessages([]string{
+				ovsh.CtLimitFamily,
 				ovsh.DatapathFamily,
 				ovsh.FlowFamily,
 				ovsh.PacketFamily,
@@ -138,3 +139,12 @@ func mustMarshalAttributes(attrs []netlink.Attribute) []byte {
 
 	return b
 }
+
+func mustEncode(ae *netlink.AttributeEncoder) []byte {
+	b, err := ae.Encode()
+	if err != nil {
+		panic(fmt.Sprintf("failed to encode attributes: %v", err))
+	}
+
+	return b
+}
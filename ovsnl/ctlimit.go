@@ -0,0 +1,171 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovsnl
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"github.com/digitalocean/go-openvswitch/ovsnl/internal/ovsh"
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/netlink"
+)
+
+// A CTLimitService provides access to methods which interact with the
+// "ovs_ct_limit" generic netlink family.
+type CTLimitService struct {
+	c *Client
+	f genetlink.Family
+}
+
+// CTDefaultZone is the zone number used in a CTZoneLimit to refer to the
+// default connection limit, which applies to zones without their own limit.
+const CTDefaultZone = ovsh.ZoneLimitDefaultZone
+
+// A CTZoneLimit is a limit on the number of conntrack connections in
+// a zone.
+type CTZoneLimit struct {
+	// Zone is a conntrack zone number, or CTDefaultZone.
+	Zone int
+	// Limit is the maximum number of connections, or zero if unlimited.
+	Limit uint32
+	// Count is the current number of connections.  It is ignored by Set.
+	Count uint32
+}
+
+// Get retrieves the conntrack limits of the specified zones in the datapath
+// with the specified interface index.  If no zones are specified, the default
+// limit and the limits of all zones with their own limit are returned.
+func (s *CTLimitService) Get(datapath int, zones ...int) ([]CTZoneLimit, error) {
+	var ab []byte
+	if len(zones) > 0 {
+		limits := make([]CTZoneLimit, 0, len(zones))
+		for _, z := range zones {
+			limits = append(limits, CTZoneLimit{Zone: z})
+		}
+
+		b, err := marshalZoneLimits(limits)
+		if err != nil {
+			return nil, err
+		}
+		ab = b
+	}
+
+	msgs, err := s.c.execute(s.f, ovsh.CtLimitCmdGet, datapath, ab, netlink.Request)
+	if err != nil {
+		return nil, err
+	}
+
+	if l := len(msgs); l != 1 {
+		return nil, fmt.Errorf("expected one conntrack limit reply, but got: %d", l)
+	}
+
+	return parseZoneLimits(msgs[0])
+}
+
+// Set sets one or more conntrack limits in the datapath with the specified
+// interface index.
+func (s *CTLimitService) Set(datapath int, limits ...CTZoneLimit) error {
+	ab, err := marshalZoneLimits(limits)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.c.execute(s.f, ovsh.CtLimitCmdSet, datapath, ab, netlink.Request)
+	return err
+}
+
+// Delete deletes the conntrack limits of the specified zones in the datapath
+// with the specified interface index.  Deleting the limit of CTDefaultZone
+// makes the default unlimited.
+func (s *CTLimitService) Delete(datapath int, zones ...int) error {
+	limits := make([]CTZoneLimit, 0, len(zones))
+	for _, z := range zones {
+		limits = append(limits, CTZoneLimit{Zone: z})
+	}
+
+	ab, err := marshalZoneLimits(limits)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.c.execute(s.f, ovsh.CtLimitCmdDel, datapath, ab, netlink.Request)
+	return err
+}
+
+// marshalZoneLimits packs CTZoneLimits into a zone limit netlink attribute.
+func marshalZoneLimits(limits []CTZoneLimit) ([]byte, error) {
+	if len(limits) == 0 {
+		return nil, errors.New("at least one zone must be specified")
+	}
+
+	b := make([]byte, 0, sizeofZoneLimit*len(limits))
+	for _, l := range limits {
+		if l.Zone < CTDefaultZone || l.Zone > 0xffff {
+			return nil, fmt.Errorf("invalid conntrack zone: %d", l.Zone)
+		}
+
+		zl := ovsh.ZoneLimit{
+			Zone_id: int32(l.Zone),
+			Limit:   l.Limit,
+		}
+
+		zb := *(*[sizeofZoneLimit]byte)(unsafe.Pointer(&zl))
+		b = append(b, zb[:]...)
+	}
+
+	return netlink.MarshalAttributes([]netlink.Attribute{{
+		Type: ovsh.CtLimitAttrZoneLimit,
+		Data: b,
+	}})
+}
+
+// parseZoneLimits parses CTZoneLimits from a generic netlink message.
+func parseZoneLimits(m genetlink.Message) ([]CTZoneLimit, error) {
+	// Skip the header to parse attributes.
+	if _, err := parseHeader(m.Data); err != nil {
+		return nil, err
+	}
+
+	attrs, err := netlink.UnmarshalAttributes(m.Data[sizeofHeader:])
+	if err != nil {
+		return nil, err
+	}
+
+	var limits []CTZoneLimit
+	for _, a := range attrs {
+		if a.Type != ovsh.CtLimitAttrZoneLimit {
+			continue
+		}
+
+		// Verify that the attribute contains whole structures before doing
+		// unsafe casts.
+		if l := len(a.Data); l%sizeofZoneLimit != 0 {
+			return nil, fmt.Errorf("unexpected zone limit structure size, want multiple of %d, got %d", sizeofZoneLimit, l)
+		}
+
+		for i := 0; i < len(a.Data); i += sizeofZoneLimit {
+			zl := *(*ovsh.ZoneLimit)(unsafe.Pointer(&a.Data[i]))
+			limits = append(limits, CTZoneLimit{
+				Zone:  int(zl.Zone_id),
+				Limit: zl.Limit,
+				Count: zl.Count,
+			})
+		}
+	}
+
+	return limits, nil
+}
@@ -0,0 +1,211 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build linux
+
+package ovsnl
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/digitalocean/go-openvswitch/ovsnl/internal/ovsh"
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/genetlink/genltest"
+	"github.com/mdlayher/netlink"
+)
+
+func TestClientCTLimitGetBadZoneLimits(t *testing.T) {
+	conn := genltest.Dial(ovsFamilies(func(greq genetlink.Message, nreq netlink.Message) ([]genetlink.Message, error) {
+		// Valid header; not enough data for ovsh.ZoneLimit.
+		return []genetlink.Message{{
+			Data: append(
+				// ovsh.Header.
+				[]byte{0xff, 0xff, 0xff, 0xff},
+				// netlink attributes.
+				mustMarshalAttributes([]netlink.Attribute{{
+					Type: ovsh.CtLimitAttrZoneLimit,
+					Data: []byte{0xff},
+				}})...,
+			),
+		}}, nil
+	}))
+
+	c, err := newClient(conn)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	_, err = c.CTLimit.Get(1)
+	if err == nil {
+		t.Fatalf("expected an error, but none occurred")
+	}
+
+	t.Logf("OK error: %v", err)
+}
+
+func TestClientCTLimitGetOK(t *testing.T) {
+	want := []CTZoneLimit{
+		{
+			Zone:  CTDefaultZone,
+			Limit: 1000,
+		},
+		{
+			Zone:  2,
+			Limit: 200,
+			Count: 10,
+		},
+	}
+
+	conn := genltest.Dial(ovsFamilies(func(greq genetlink.Message, nreq netlink.Message) ([]genetlink.Message, error) {
+		if diff := cmp.Diff(ovsh.CtLimitCmdGet, int(greq.Header.Command)); diff != "" {
+			t.Fatalf("unexpected generic netlink command (-want +got):\n%s", diff)
+		}
+
+		got, err := parseZoneLimits(greq)
+		if err != nil {
+			t.Fatalf("failed to parse zone limits: %v", err)
+		}
+
+		zones := []CTZoneLimit{{Zone: CTDefaultZone}, {Zone: 2}}
+		if diff := cmp.Diff(zones, got); diff != "" {
+			t.Fatalf("unexpected requested zones (-want +got):\n%s", diff)
+		}
+
+		return []genetlink.Message{{
+			Data: mustMarshalZoneLimits(want),
+		}}, nil
+	}))
+
+	c, err := newClient(conn)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	limits, err := c.CTLimit.Get(1, CTDefaultZone, 2)
+	if err != nil {
+		t.Fatalf("failed to get conntrack limits: %v", err)
+	}
+
+	if diff := cmp.Diff(want, limits); diff != "" {
+		t.Fatalf("unexpected conntrack limits (-want +got):\n%s", diff)
+	}
+}
+
+func TestClientCTLimitSetBadZone(t *testing.T) {
+	conn := genltest.Dial(ovsFamilies(func(greq genetlink.Message, nreq netlink.Message) ([]genetlink.Message, error) {
+		t.Fatal("no request should be sent")
+		return nil, nil
+	}))
+
+	c, err := newClient(conn)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	err = c.CTLimit.Set(1, CTZoneLimit{Zone: 0x10000, Limit: 10})
+	if err == nil {
+		t.Fatalf("expected an error, but none occurred")
+	}
+
+	t.Logf("OK error: %v", err)
+}
+
+func TestClientCTLimitSetDeleteOK(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  int
+		want []CTZoneLimit
+		fn   func(c *Client) error
+	}{
+		{
+			name: "set",
+			cmd:  ovsh.CtLimitCmdSet,
+			want: []CTZoneLimit{
+				{Zone: CTDefaultZone, Limit: 1000},
+				{Zone: 3, Limit: 300},
+			},
+			fn: func(c *Client) error {
+				return c.CTLimit.Set(1,
+					CTZoneLimit{Zone: CTDefaultZone, Limit: 1000},
+					// Count should be ignored.
+					CTZoneLimit{Zone: 3, Limit: 300, Count: 10},
+				)
+			},
+		},
+		{
+			name: "delete",
+			cmd:  ovsh.CtLimitCmdDel,
+			want: []CTZoneLimit{{Zone: 3}},
+			fn: func(c *Client) error {
+				return c.CTLimit.Delete(1, 3)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := genltest.Dial(ovsFamilies(func(greq genetlink.Message, nreq netlink.Message) ([]genetlink.Message, error) {
+				if diff := cmp.Diff(tt.cmd, int(greq.Header.Command)); diff != "" {
+					t.Fatalf("unexpected generic netlink command (-want +got):\n%s", diff)
+				}
+
+				got, err := parseZoneLimits(greq)
+				if err != nil {
+					t.Fatalf("failed to parse zone limits: %v", err)
+				}
+
+				if diff := cmp.Diff(tt.want, got); diff != "" {
+					t.Fatalf("unexpected zone limits (-want +got):\n%s", diff)
+				}
+
+				return []genetlink.Message{{
+					Data: headerBytes(ovsh.Header{Ifindex: 1}),
+				}}, nil
+			}))
+
+			c, err := newClient(conn)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+			defer c.Close()
+
+			if err := tt.fn(c); err != nil {
+				t.Fatalf("failed to execute command: %v", err)
+			}
+		})
+	}
+}
+
+func mustMarshalZoneLimits(limits []CTZoneLimit) []byte {
+	b := make([]byte, 0)
+	for _, l := range limits {
+		zl := ovsh.ZoneLimit{
+			Zone_id: int32(l.Zone),
+			Limit:   l.Limit,
+			Count:   l.Count,
+		}
+
+		b = append(b, (*(*[sizeofZoneLimit]byte)(unsafe.Pointer(&zl)))[:]...)
+	}
+
+	return append(headerBytes(ovsh.Header{Ifindex: 1}), mustMarshalAttributes([]netlink.Attribute{{
+		Type: ovsh.CtLimitAttrZoneLimit,
+		Data: b,
+	}})...)
+}
@@ -38,6 +38,9 @@ type Datapath struct {
 	Features      DatapathFeatures
 	Stats         DatapathStats
 	MegaflowStats DatapathMegaflowStats
+	// MasksCacheSize is the size of the per-CPU megaflow mask cache, or
+	// zero if it is not reported by the kernel.
+	MasksCacheSize uint32
 }
 
 // DatapathFeatures is a set of bit flags that specify features for a datapath.
@@ -45,8 +48,10 @@ type DatapathFeatures uint32
 
 // Possible DatapathFeatures flag values.
 const (
-	DatapathFeaturesUnaligned DatapathFeatures = ovsh.DpFUnaligned
-	DatapathFeaturesVPortPIDs DatapathFeatures = ovsh.DpFVportPids
+	DatapathFeaturesUnaligned            DatapathFeatures = ovsh.DpFUnaligned
+	DatapathFeaturesVPortPIDs            DatapathFeatures = ovsh.DpFVportPids
+	DatapathFeaturesTCRecircSharing      DatapathFeatures = ovsh.DpFTcRecircSharing
+	DatapathFeaturesDispatchUpcallPerCPU DatapathFeatures = ovsh.DpFDispatchUpcallPerCpu
 )
 
 // String returns the string representation of a DatapathFeatures.
@@ -54,6 +59,8 @@ func (f DatapathFeatures) String() string {
 	names := []string{
 		"unaligned",
 		"vportpids",
+		"tcrecircsharing",
+		"dispatchupcallpercpu",
 	}
 
 	var s string
@@ -118,51 +125,122 @@ func (s *DatapathService) List() ([]Datapath, error) {
 	return parseDatapaths(msgs)
 }
 
+// SetPerCPUUpcallPortIDs sets the netlink port IDs which receive upcalls for
+// the datapath with the specified interface index, indexed by CPU, and
+// enables DatapathFeaturesDispatchUpcallPerCPU while retaining the
+// datapath's other features.  Without that feature, upcalls are sent to the
+// port IDs registered on each vport using VportService.SetUpcallPortIDs.
+//
+// The kernel only reads a datapath's own upcall port ID when the datapath is
+// created, so it cannot be changed here.  Instead, set the upcall port IDs of
+// the datapath's local vport, which is always port number 0.
+func (s *DatapathService) SetPerCPUUpcallPortIDs(index int, pids ...uint32) error {
+	// The kernel clears any features which are not specified when setting
+	// a datapath, and ignores the per-CPU port IDs unless per-CPU dispatch
+	// is enabled, so the current features must be sent with the port IDs.
+	dp, err := s.get(index)
+	if err != nil {
+		return err
+	}
+
+	pb := make([]byte, 0, 4*len(pids))
+	for _, pid := range pids {
+		pb = append(pb, nlenc.Uint32Bytes(pid)...)
+	}
+
+	ab, err := netlink.MarshalAttributes([]netlink.Attribute{
+		{
+			Type: ovsh.DpAttrUserFeatures,
+			Data: nlenc.Uint32Bytes(uint32(dp.Features | DatapathFeaturesDispatchUpcallPerCPU)),
+		},
+		{
+			Type: ovsh.DpAttrPerCpuPids,
+			Data: pb,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.c.execute(s.f, ovsh.DpCmdSet, index, ab, netlink.Request)
+	return err
+}
+
+// get retrieves the Datapath with the specified interface index.
+func (s *DatapathService) get(index int) (Datapath, error) {
+	msgs, err := s.c.execute(s.f, ovsh.DpCmdGet, index, nil, netlink.Request)
+	if err != nil {
+		return Datapath{}, err
+	}
+
+	dps, err := parseDatapaths(msgs)
+	if err != nil {
+		return Datapath{}, err
+	}
+	if len(dps) != 1 {
+		return Datapath{}, fmt.Errorf("expected 1 datapath, but got %d", len(dps))
+	}
+
+	return dps[0], nil
+}
+
 // parseDatapaths parses a slice of Datapaths from a slice of generic netlink
 // messages.
 func parseDatapaths(msgs []genetlink.Message) ([]Datapath, error) {
 	dps := make([]Datapath, 0, len(msgs))
 
 	for _, m := range msgs {
-		// Fetch the header at the beginning of the message.
-		h, err := parseHeader(m.Data)
+		dp, err := parseDatapath(m)
 		if err != nil {
 			return nil, err
 		}
 
-		dp := Datapath{
-			Index: int(h.Ifindex),
-		}
+		dps = append(dps, dp)
+	}
 
-		// Skip the header to parse attributes.
-		attrs, err := netlink.UnmarshalAttributes(m.Data[sizeofHeader:])
-		if err != nil {
-			return nil, err
-		}
+	return dps, nil
+}
 
-		for _, a := range attrs {
-			switch a.Type {
-			case ovsh.DpAttrName:
-				dp.Name = nlenc.String(a.Data)
-			case ovsh.DpAttrUserFeatures:
-				dp.Features = DatapathFeatures(nlenc.Uint32(a.Data))
-			case ovsh.DpAttrStats:
-				dp.Stats, err = parseDPStats(a.Data)
-				if err != nil {
-					return nil, err
-				}
-			case ovsh.DpAttrMegaflowStats:
-				dp.MegaflowStats, err = parseDPMegaflowStats(a.Data)
-				if err != nil {
-					return nil, err
-				}
+// parseDatapath parses a Datapath from a generic netlink message.
+func parseDatapath(m genetlink.Message) (Datapath, error) {
+	// Fetch the header at the beginning of the message.
+	h, err := parseHeader(m.Data)
+	if err != nil {
+		return Datapath{}, err
+	}
+
+	dp := Datapath{
+		Index: int(h.Ifindex),
+	}
+
+	// Skip the header to parse attributes.
+	attrs, err := netlink.UnmarshalAttributes(m.Data[sizeofHeader:])
+	if err != nil {
+		return Datapath{}, err
+	}
+
+	for _, a := range attrs {
+		switch a.Type {
+		case ovsh.DpAttrName:
+			dp.Name = nlenc.String(a.Data)
+		case ovsh.DpAttrUserFeatures:
+			dp.Features = DatapathFeatures(nlenc.Uint32(a.Data))
+		case ovsh.DpAttrStats:
+			dp.Stats, err = parseDPStats(a.Data)
+			if err != nil {
+				return Datapath{}, err
+			}
+		case ovsh.DpAttrMegaflowStats:
+			dp.MegaflowStats, err = parseDPMegaflowStats(a.Data)
+			if err != nil {
+				return Datapath{}, err
 			}
+		case ovsh.DpAttrMasksCacheSize:
+			dp.MasksCacheSize = nlenc.Uint32(a.Data)
 		}
-
-		dps = append(dps, dp)
 	}
 
-	return dps, nil
+	return dp, nil
 }
 
 // parseDPStats converts a byte slice into DatapathStats.
@@ -217,3 +217,77 @@ func mustMarshalDatapath(dp Datapath) []byte {
 
 	return append(hb[:], ab...)
 }
+
+func TestClientDatapathSetPerCPUUpcallPortIDsOK(t *testing.T) {
+	const features = DatapathFeaturesUnaligned | DatapathFeaturesVPortPIDs
+
+	var set bool
+	conn := genltest.Dial(ovsFamilies(func(greq genetlink.Message, nreq netlink.Message) ([]genetlink.Message, error) {
+		h, err := parseHeader(greq.Data)
+		if err != nil {
+			t.Fatalf("failed to parse OvS generic netlink header: %v", err)
+		}
+
+		if diff := cmp.Diff(1, int(h.Ifindex)); diff != "" {
+			t.Fatalf("unexpected datapath ID (-want +got):\n%s", diff)
+		}
+
+		reply := []genetlink.Message{{
+			Data: mustMarshalDatapath(Datapath{
+				Name:     "ovs-system",
+				Index:    1,
+				Features: features,
+			}),
+		}}
+
+		// The current features are retrieved before setting the port IDs.
+		if greq.Header.Command == ovsh.DpCmdGet {
+			return reply, nil
+		}
+
+		if diff := cmp.Diff(ovsh.DpCmdSet, int(greq.Header.Command)); diff != "" {
+			t.Fatalf("unexpected generic netlink command (-want +got):\n%s", diff)
+		}
+		set = true
+
+		attrs, err := netlink.UnmarshalAttributes(greq.Data[sizeofHeader:])
+		if err != nil {
+			t.Fatalf("failed to unmarshal attributes: %v", err)
+		}
+
+		// The kernel clears features which are not specified, and ignores
+		// the per-CPU port IDs unless per-CPU dispatch is enabled.
+		want := []netlink.Attribute{
+			{
+				Length: 8,
+				Type:   ovsh.DpAttrUserFeatures,
+				Data:   nlenc.Uint32Bytes(uint32(features | DatapathFeaturesDispatchUpcallPerCPU)),
+			},
+			{
+				Length: 12,
+				Type:   ovsh.DpAttrPerCpuPids,
+				Data:   append(nlenc.Uint32Bytes(100), nlenc.Uint32Bytes(200)...),
+			},
+		}
+
+		if diff := cmp.Diff(want, attrs); diff != "" {
+			t.Fatalf("unexpected attributes (-want +got):\n%s", diff)
+		}
+
+		return reply, nil
+	}))
+
+	c, err := newClient(conn)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	if err := c.Datapath.SetPerCPUUpcallPortIDs(1, 100, 200); err != nil {
+		t.Fatalf("failed to set per-CPU upcall port IDs: %v", err)
+	}
+
+	if !set {
+		t.Fatal("per-CPU upcall port IDs were not set")
+	}
+}
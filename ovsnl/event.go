@@ -0,0 +1,228 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovsnl
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/digitalocean/go-openvswitch/ovsnl/internal/ovsh"
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/netlink"
+)
+
+// An EventType indicates the kind of change described by an Event.
+type EventType int
+
+// Possible EventType values.
+const (
+	// EventNew indicates that an object was created.
+	EventNew EventType = iota + 1
+	// EventDelete indicates that an object was deleted.
+	EventDelete
+	// EventChange indicates that an object's configuration was changed.
+	EventChange
+)
+
+// String returns the string representation of an EventType.
+func (t EventType) String() string {
+	switch t {
+	case EventNew:
+		return "new"
+	case EventDelete:
+		return "delete"
+	case EventChange:
+		return "change"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(t))
+	}
+}
+
+// An Event is a notification of a change to an Open vSwitch kernel object.
+// Events are either a *DatapathEvent or a *VportEvent.
+type Event interface {
+	isEvent()
+}
+
+// A DatapathEvent is an Event which describes a change to a Datapath.
+type DatapathEvent struct {
+	Type     EventType
+	Datapath Datapath
+}
+
+// A VportEvent is an Event which describes a change to a Vport.
+type VportEvent struct {
+	Type  EventType
+	Vport Vport
+}
+
+func (*DatapathEvent) isEvent() {}
+func (*VportEvent) isEvent()    {}
+
+// Subscribe opens a new generic netlink socket which joins the datapath and
+// vport multicast groups, to receive Events when datapaths and vports are
+// created, deleted, or changed.
+func (c *Client) Subscribe() (*EventListener, error) {
+	if c.Datapath == nil || c.Vport == nil {
+		return nil, errors.New("datapath and vport generic netlink families are required for events")
+	}
+
+	conn, err := genetlink.Dial(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, g := range []struct {
+		f    genetlink.Family
+		name string
+	}{
+		{f: c.Datapath.f, name: ovsh.DatapathMcgroup},
+		{f: c.Vport.f, name: ovsh.VportMcgroup},
+	} {
+		if err := joinGroup(conn, g.f, g.name); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+
+	return newEventListener(conn, c.Datapath.f.ID, c.Vport.f.ID), nil
+}
+
+// joinGroup joins the multicast group with the specified name in family f.
+func joinGroup(c *genetlink.Conn, f genetlink.Family, name string) error {
+	for _, g := range f.Groups {
+		if g.Name == name {
+			return c.JoinGroup(g.ID)
+		}
+	}
+
+	return fmt.Errorf("multicast group %q not found in family %q", name, f.Name)
+}
+
+// An EventListener receives Events from the kernel on a dedicated generic
+// netlink socket.
+type EventListener struct {
+	c          *genetlink.Conn
+	datapathID uint16
+	vportID    uint16
+}
+
+// newEventListener is the internal EventListener constructor, used in tests.
+func newEventListener(c *genetlink.Conn, datapathID, vportID uint16) *EventListener {
+	return &EventListener{
+		c:          c,
+		datapathID: datapathID,
+		vportID:    vportID,
+	}
+}
+
+// Close closes the EventListener's generic netlink socket.
+func (l *EventListener) Close() error {
+	return l.c.Close()
+}
+
+// SetReadDeadline sets the read deadline for calls to Receive.
+func (l *EventListener) SetReadDeadline(t time.Time) error {
+	return l.c.SetReadDeadline(t)
+}
+
+// Receive blocks until one or more Events are received by the EventListener.
+// Messages which are not datapath or vport notifications are ignored.
+func (l *EventListener) Receive() ([]Event, error) {
+	msgs, nmsgs, err := l.c.Receive()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, 0, len(msgs))
+	for i, m := range msgs {
+		var (
+			e   Event
+			err error
+		)
+
+		switch nmsgs[i].Header.Type {
+		case netlink.HeaderType(l.datapathID):
+			e, err = parseDatapathEvent(m)
+		case netlink.HeaderType(l.vportID):
+			e, err = parseVportEvent(m)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if e == nil {
+			continue
+		}
+
+		events = append(events, e)
+	}
+
+	return events, nil
+}
+
+// parseDatapathEvent parses a DatapathEvent from a generic netlink message.
+// If the message is not a datapath notification, it returns nil.
+func parseDatapathEvent(m genetlink.Message) (Event, error) {
+	var typ EventType
+	switch m.Header.Command {
+	case ovsh.DpCmdNew:
+		typ = EventNew
+	case ovsh.DpCmdDel:
+		typ = EventDelete
+	case ovsh.DpCmdSet:
+		typ = EventChange
+	default:
+		return nil, nil
+	}
+
+	dp, err := parseDatapath(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DatapathEvent{
+		Type:     typ,
+		Datapath: dp,
+	}, nil
+}
+
+// parseVportEvent parses a VportEvent from a generic netlink message.
+// If the message is not a vport notification, it returns nil.
+func parseVportEvent(m genetlink.Message) (Event, error) {
+	var typ EventType
+	switch m.Header.Command {
+	case ovsh.VportCmdNew:
+		typ = EventNew
+	case ovsh.VportCmdDel:
+		typ = EventDelete
+	case ovsh.VportCmdSet:
+		typ = EventChange
+	default:
+		return nil, nil
+	}
+
+	vp, err := parseVport(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VportEvent{
+		Type:  typ,
+		Vport: vp,
+	}, nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build linux
+
+package ovsnl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/digitalocean/go-openvswitch/ovsnl/internal/ovsh"
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nltest"
+)
+
+const (
+	testDatapathFamilyID = 10
+	testVportFamilyID    = 11
+)
+
+func TestEventListenerReceiveBadVport(t *testing.T) {
+	l := testEventListener(t, []netlink.Message{
+		mustMarshalEvent(testVportFamilyID, genetlink.Message{
+			Header: genetlink.Header{
+				Command: ovsh.VportCmdNew,
+			},
+			// Not enough data for ovsh.Header.
+			Data: []byte{0xff},
+		}),
+	})
+	defer l.Close()
+
+	_, err := l.Receive()
+	if err == nil {
+		t.Fatalf("expected an error, but none occurred")
+	}
+
+	t.Logf("OK error: %v", err)
+}
+
+func TestEventListenerReceiveOK(t *testing.T) {
+	dp := Datapath{
+		Name:     "ovs-system",
+		Index:    1,
+		Features: DatapathFeaturesUnaligned,
+	}
+
+	vp := Vport{
+		DatapathIndex: 1,
+		PortNo:        2,
+		Type:          VportTypeInternal,
+		Name:          "ovsbr0",
+		Ifindex:       3,
+		UpcallPortIDs: []uint32{100},
+	}
+
+	l := testEventListener(t, []netlink.Message{
+		mustMarshalEvent(testDatapathFamilyID, genetlink.Message{
+			Header: genetlink.Header{
+				Command: ovsh.DpCmdNew,
+			},
+			Data: mustMarshalDatapath(dp),
+		}),
+		mustMarshalEvent(testVportFamilyID, genetlink.Message{
+			Header: genetlink.Header{
+				Command: ovsh.VportCmdNew,
+			},
+			Data: mustMarshalVport(vp),
+		}),
+		// Unknown families and commands are ignored.
+		mustMarshalEvent(testVportFamilyID+1, genetlink.Message{
+			Header: genetlink.Header{
+				Command: ovsh.VportCmdNew,
+			},
+		}),
+		mustMarshalEvent(testVportFamilyID, genetlink.Message{
+			Header: genetlink.Header{
+				Command: ovsh.VportCmdGet,
+			},
+			Data: mustMarshalVport(vp),
+		}),
+		mustMarshalEvent(testVportFamilyID, genetlink.Message{
+			Header: genetlink.Header{
+				Command: ovsh.VportCmdDel,
+			},
+			Data: mustMarshalVport(vp),
+		}),
+	})
+	defer l.Close()
+
+	events, err := l.Receive()
+	if err != nil {
+		t.Fatalf("failed to receive events: %v", err)
+	}
+
+	want := []Event{
+		&DatapathEvent{
+			Type:     EventNew,
+			Datapath: dp,
+		},
+		&VportEvent{
+			Type:  EventNew,
+			Vport: vp,
+		},
+		&VportEvent{
+			Type:  EventDelete,
+			Vport: vp,
+		},
+	}
+
+	if diff := cmp.Diff(want, events); diff != "" {
+		t.Fatalf("unexpected events (-want +got):\n%s", diff)
+	}
+}
+
+func testEventListener(t *testing.T, msgs []netlink.Message) *EventListener {
+	t.Helper()
+
+	conn := nltest.Dial(func(req []netlink.Message) ([]netlink.Message, error) {
+		// Multicast messages are received without a request.
+		if len(req) != 0 {
+			t.Fatalf("unexpected request: %+v", req)
+		}
+
+		return msgs, nil
+	})
+
+	return newEventListener(genetlink.NewConn(conn), testDatapathFamilyID, testVportFamilyID)
+}
+
+func mustMarshalEvent(family uint16, m genetlink.Message) netlink.Message {
+	b, err := m.MarshalBinary()
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal generic netlink message: %v", err))
+	}
+
+	return netlink.Message{
+		Header: netlink.Header{
+			Type: netlink.HeaderType(family),
+		},
+		Data: b,
+	}
+}
@@ -0,0 +1,348 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovsnl
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/digitalocean/go-openvswitch/ovsnl/internal/ovsh"
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nlenc"
+)
+
+// A FlowService provides access to methods which interact with the
+// "ovs_flow" generic netlink family.
+type FlowService struct {
+	c *Client
+	f genetlink.Family
+}
+
+// A Flow is an Open vSwitch datapath flow, also known as a megaflow.
+type Flow struct {
+	// DatapathIndex is the interface index of the flow's datapath.
+	DatapathIndex int
+
+	// Key and Mask describe the packets matched by the flow.  Bits which
+	// are zero in Mask are wildcarded.
+	Key  FlowKey
+	Mask FlowKey
+
+	// UFID is the flow's unique flow identifier, if one was set.
+	UFID []byte
+
+	// Actions are the netlink-encoded datapath actions for the flow.
+	Actions []byte
+
+	Stats FlowStats
+
+	// Used is the time at which the flow last matched a packet, measured
+	// on the system's monotonic clock, or zero if the flow has never been
+	// used.
+	Used time.Duration
+
+	// TCPFlags is the union of the TCP flags seen by the flow.
+	TCPFlags uint8
+}
+
+// FlowStats contains statistics about packets that have matched a Flow.
+type FlowStats struct {
+	Packets uint64
+	Bytes   uint64
+}
+
+// MaskSignature returns a string which identifies the Flow's mask.  Flows
+// with identical masks share a single megaflow mask in the datapath.
+func (f Flow) MaskSignature() string {
+	attrs := make([]KeyAttr, 0, len(f.Mask))
+	for t, v := range f.Mask {
+		if isZero(v) {
+			// Fully wildcarded attributes do not affect classification.
+			continue
+		}
+
+		attrs = append(attrs, t)
+	}
+
+	sort.Slice(attrs, func(i, j int) bool {
+		return attrs[i] < attrs[j]
+	})
+
+	ss := make([]string, 0, len(attrs))
+	for _, t := range attrs {
+		ss = append(ss, fmt.Sprintf("%s(%s)", t, hex.EncodeToString(f.Mask[t])))
+	}
+
+	return strings.Join(ss, ",")
+}
+
+// List dumps all of the Flows in the datapath with the specified interface
+// index.
+func (s *FlowService) List(datapath int) ([]Flow, error) {
+	flags := netlink.Request | netlink.Dump
+	msgs, err := s.c.execute(s.f, ovsh.FlowCmdGet, datapath, nil, flags)
+	if err != nil {
+		return nil, err
+	}
+
+	flows := make([]Flow, 0, len(msgs))
+	for _, m := range msgs {
+		f, err := parseFlow(m)
+		if err != nil {
+			return nil, err
+		}
+
+		flows = append(flows, f)
+	}
+
+	return flows, nil
+}
+
+// Summarize dumps all of the Flows in the datapath with the specified
+// interface index and summarizes them using SummarizeFlows.
+func (s *FlowService) Summarize(datapath int, opts FlowSummaryOptions) (FlowSummary, error) {
+	flows, err := s.List(datapath)
+	if err != nil {
+		return FlowSummary{}, err
+	}
+
+	now, err := monotonicNow()
+	if err != nil {
+		return FlowSummary{}, err
+	}
+
+	return SummarizeFlows(flows, now, opts), nil
+}
+
+// FlowSummaryOptions configures the output of SummarizeFlows.
+type FlowSummaryOptions struct {
+	// TopN is the maximum number of flows reported in the top flows by
+	// packets and by bytes.  If zero, no top flows are reported.
+	TopN int
+
+	// IdleTimeout is the minimum time since a flow was last used for it
+	// to be reported as idle.  Flows which have never been used are always
+	// reported as idle.
+	IdleTimeout time.Duration
+}
+
+// A FlowSummary summarizes the Flows in a datapath.
+type FlowSummary struct {
+	// Flows is the total number of flows.
+	Flows int
+
+	// Packets and Bytes are the totals of the statistics of all flows.
+	Packets uint64
+	Bytes   uint64
+
+	// Masks summarizes flows grouped by mask, ordered by decreasing
+	// number of flows.
+	Masks []MaskSummary
+
+	// FlowsPerMask is the average number of flows produced by each mask.
+	FlowsPerMask float64
+
+	// TopByPackets and TopByBytes are the flows which matched the most
+	// packets and bytes, in decreasing order.
+	TopByPackets []Flow
+	TopByBytes   []Flow
+
+	// Idle are the flows which have not been used within the idle
+	// timeout, ordered from least to most recently used.
+	Idle []IdleFlow
+}
+
+// A MaskSummary summarizes the Flows which share a megaflow mask.
+type MaskSummary struct {
+	// Signature is the mask's signature, as returned by Flow.MaskSignature.
+	Signature string
+
+	// Mask is the mask shared by the flows.
+	Mask FlowKey
+
+	// Flows is the number of flows which use the mask.
+	Flows int
+
+	// Packets and Bytes are the totals of the statistics of the flows
+	// which use the mask.
+	Packets uint64
+	Bytes   uint64
+}
+
+// An IdleFlow is a Flow which has not been used within an idle timeout.
+type IdleFlow struct {
+	Flow Flow
+
+	// Idle is the time since the flow was last used, or -1 if the flow
+	// has never been used.
+	Idle time.Duration
+}
+
+// SummarizeFlows summarizes flows dumped from a datapath.  now is the
+// current time on the system's monotonic clock, and is used to determine
+// how long flows have been idle.
+func SummarizeFlows(flows []Flow, now time.Duration, opts FlowSummaryOptions) FlowSummary {
+	s := FlowSummary{
+		Flows: len(flows),
+	}
+
+	masks := make(map[string]int)
+	for _, f := range flows {
+		s.Packets += f.Stats.Packets
+		s.Bytes += f.Stats.Bytes
+
+		sig := f.MaskSignature()
+		i, ok := masks[sig]
+		if !ok {
+			i = len(s.Masks)
+			masks[sig] = i
+			s.Masks = append(s.Masks, MaskSummary{
+				Signature: sig,
+				Mask:      f.Mask,
+			})
+		}
+
+		ms := &s.Masks[i]
+		ms.Flows++
+		ms.Packets += f.Stats.Packets
+		ms.Bytes += f.Stats.Bytes
+
+		switch {
+		case f.Used == 0:
+			s.Idle = append(s.Idle, IdleFlow{Flow: f, Idle: -1})
+		case now-f.Used >= opts.IdleTimeout:
+			s.Idle = append(s.Idle, IdleFlow{Flow: f, Idle: now - f.Used})
+		}
+	}
+
+	if len(s.Masks) > 0 {
+		s.FlowsPerMask = float64(s.Flows) / float64(len(s.Masks))
+	}
+
+	sort.SliceStable(s.Masks, func(i, j int) bool {
+		return s.Masks[i].Flows > s.Masks[j].Flows
+	})
+
+	sort.SliceStable(s.Idle, func(i, j int) bool {
+		a, b := s.Idle[i].Idle, s.Idle[j].Idle
+		switch {
+		case a == -1:
+			return b != -1
+		case b == -1:
+			return false
+		default:
+			return a > b
+		}
+	})
+
+	s.TopByPackets = topFlows(flows, opts.TopN, func(f Flow) uint64 {
+		return f.Stats.Packets
+	})
+	s.TopByBytes = topFlows(flows, opts.TopN, func(f Flow) uint64 {
+		return f.Stats.Bytes
+	})
+
+	return s
+}
+
+// topFlows returns up to n flows with the largest values returned by fn,
+// in decreasing order.
+func topFlows(flows []Flow, n int, fn func(f Flow) uint64) []Flow {
+	if n <= 0 || len(flows) == 0 {
+		return nil
+	}
+
+	top := make([]Flow, len(flows))
+	copy(top, flows)
+
+	sort.SliceStable(top, func(i, j int) bool {
+		return fn(top[i]) > fn(top[j])
+	})
+
+	if len(top) > n {
+		top = top[:n]
+	}
+
+	return top
+}
+
+// parseFlow parses a Flow from a generic netlink message.
+func parseFlow(m genetlink.Message) (Flow, error) {
+	// Fetch the header at the beginning of the message.
+	h, err := parseHeader(m.Data)
+	if err != nil {
+		return Flow{}, err
+	}
+
+	f := Flow{
+		DatapathIndex: int(h.Ifindex),
+	}
+
+	// Skip the header to parse attributes.
+	attrs, err := netlink.UnmarshalAttributes(m.Data[sizeofHeader:])
+	if err != nil {
+		return Flow{}, err
+	}
+
+	for _, a := range attrs {
+		switch a.Type &^ netlink.Nested {
+		case ovsh.FlowAttrKey:
+			f.Key, err = parseFlowKey(a.Data)
+			if err != nil {
+				return Flow{}, err
+			}
+		case ovsh.FlowAttrMask:
+			f.Mask, err = parseFlowKey(a.Data)
+			if err != nil {
+				return Flow{}, err
+			}
+		case ovsh.FlowAttrUfid:
+			f.UFID = a.Data
+		case ovsh.FlowAttrActions:
+			f.Actions = a.Data
+		case ovsh.FlowAttrStats:
+			s, err := parseFlowStats(a.Data)
+			if err != nil {
+				return Flow{}, err
+			}
+
+			f.Stats = FlowStats{
+				Packets: s.Packets,
+				Bytes:   s.Bytes,
+			}
+		case ovsh.FlowAttrUsed:
+			f.Used = time.Duration(nlenc.Uint64(a.Data)) * time.Millisecond
+		case ovsh.FlowAttrTcpFlags:
+			f.TCPFlags = nlenc.Uint8(a.Data)
+		}
+	}
+
+	return f, nil
+}
+
+// isZero reports whether every byte in b is zero.
+func isZero(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,163 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build linux
+
+package ovsnl_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/digitalocean/go-openvswitch/ovsnl"
+	"github.com/digitalocean/go-openvswitch/ovsnl/ovsnltest"
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/netlink/nlenc"
+)
+
+func TestClientFlowListSummarize(t *testing.T) {
+	k := ovsnltest.New()
+	dp := k.AddDatapath("ovs-system")
+
+	// Two flows which match on in_port only, and one which also matches
+	// on the Ethertype.
+	portMask := ovsnl.FlowKey{
+		ovsnl.KeyAttrInPort:    nlenc.Uint32Bytes(0xffffffff),
+		ovsnl.KeyAttrEthertype: {0x00, 0x00},
+	}
+	typeMask := ovsnl.FlowKey{
+		ovsnl.KeyAttrInPort:    nlenc.Uint32Bytes(0xffffffff),
+		ovsnl.KeyAttrEthertype: {0xff, 0xff},
+	}
+
+	key := func(port uint32) ovsnl.FlowKey {
+		return ovsnl.FlowKey{
+			ovsnl.KeyAttrInPort:    nlenc.Uint32Bytes(port),
+			ovsnl.KeyAttrEthertype: {0x08, 0x00},
+		}
+	}
+
+	for _, f := range []ovsnltest.Flow{
+		{
+			Key:     key(1),
+			Mask:    portMask,
+			Packets: 10,
+			Bytes:   1000,
+			Used:    9000,
+		},
+		{
+			Key:     key(2),
+			Mask:    portMask,
+			Packets: 1,
+			Bytes:   5000,
+			Used:    1000,
+		},
+		{
+			Key:  key(3),
+			Mask: typeMask,
+		},
+	} {
+		if err := k.AddFlow(dp, f); err != nil {
+			t.Fatalf("failed to add flow: %v", err)
+		}
+	}
+
+	c, err := k.Client()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	flows, err := c.Flow.List(dp)
+	if err != nil {
+		t.Fatalf("failed to list flows: %v", err)
+	}
+
+	want := []ovsnl.Flow{
+		{
+			DatapathIndex: dp,
+			Key:           key(1),
+			Mask:          portMask,
+			Actions:       []byte{},
+			Stats: ovsnl.FlowStats{
+				Packets: 10,
+				Bytes:   1000,
+			},
+			Used: 9 * time.Second,
+		},
+		{
+			DatapathIndex: dp,
+			Key:           key(2),
+			Mask:          portMask,
+			Actions:       []byte{},
+			Stats: ovsnl.FlowStats{
+				Packets: 1,
+				Bytes:   5000,
+			},
+			Used: 1 * time.Second,
+		},
+		{
+			DatapathIndex: dp,
+			Key:           key(3),
+			Mask:          typeMask,
+			Actions:       []byte{},
+		},
+	}
+
+	if diff := cmp.Diff(want, flows); diff != "" {
+		t.Fatalf("unexpected flows (-want +got):\n%s", diff)
+	}
+
+	summary := ovsnl.SummarizeFlows(flows, 10*time.Second, ovsnl.FlowSummaryOptions{
+		TopN:        1,
+		IdleTimeout: 5 * time.Second,
+	})
+
+	wantSummary := ovsnl.FlowSummary{
+		Flows:   3,
+		Packets: 11,
+		Bytes:   6000,
+		Masks: []ovsnl.MaskSummary{
+			{
+				Signature: "in_port(ffffffff)",
+				Mask:      portMask,
+				Flows:     2,
+				Packets:   11,
+				Bytes:     6000,
+			},
+			{
+				Signature: "in_port(ffffffff),eth_type(ffff)",
+				Mask:      typeMask,
+				Flows:     1,
+			},
+		},
+		FlowsPerMask: 1.5,
+		TopByPackets: want[:1],
+		TopByBytes:   want[1:2],
+		Idle: []ovsnl.IdleFlow{
+			{
+				Flow: want[2],
+				Idle: -1,
+			},
+			{
+				Flow: want[1],
+				Idle: 9 * time.Second,
+			},
+		},
+	}
+
+	if diff := cmp.Diff(wantSummary, summary); diff != "" {
+		t.Fatalf("unexpected summary (-want +got):\n%s", diff)
+	}
+}
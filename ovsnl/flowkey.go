@@ -0,0 +1,138 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovsnl
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/digitalocean/go-openvswitch/ovsnl/internal/ovsh"
+	"github.com/mdlayher/netlink"
+)
+
+// A KeyAttr is the type of a datapath flow key attribute.
+type KeyAttr uint16
+
+// Possible KeyAttr values.
+const (
+	KeyAttrEncap           KeyAttr = ovsh.KeyAttrEncap
+	KeyAttrPriority        KeyAttr = ovsh.KeyAttrPriority
+	KeyAttrInPort          KeyAttr = ovsh.KeyAttrInPort
+	KeyAttrEthernet        KeyAttr = ovsh.KeyAttrEthernet
+	KeyAttrVLAN            KeyAttr = ovsh.KeyAttrVlan
+	KeyAttrEthertype       KeyAttr = ovsh.KeyAttrEthertype
+	KeyAttrIPv4            KeyAttr = ovsh.KeyAttrIpv4
+	KeyAttrIPv6            KeyAttr = ovsh.KeyAttrIpv6
+	KeyAttrTCP             KeyAttr = ovsh.KeyAttrTcp
+	KeyAttrUDP             KeyAttr = ovsh.KeyAttrUdp
+	KeyAttrICMP            KeyAttr = ovsh.KeyAttrIcmp
+	KeyAttrICMPv6          KeyAttr = ovsh.KeyAttrIcmpv6
+	KeyAttrARP             KeyAttr = ovsh.KeyAttrArp
+	KeyAttrND              KeyAttr = ovsh.KeyAttrNd
+	KeyAttrSKBMark         KeyAttr = ovsh.KeyAttrSkbMark
+	KeyAttrTunnel          KeyAttr = ovsh.KeyAttrTunnel
+	KeyAttrSCTP            KeyAttr = ovsh.KeyAttrSctp
+	KeyAttrTCPFlags        KeyAttr = ovsh.KeyAttrTcpFlags
+	KeyAttrDPHash          KeyAttr = ovsh.KeyAttrDpHash
+	KeyAttrRecircID        KeyAttr = ovsh.KeyAttrRecircId
+	KeyAttrMPLS            KeyAttr = ovsh.KeyAttrMpls
+	KeyAttrCTState         KeyAttr = ovsh.KeyAttrCtState
+	KeyAttrCTZone          KeyAttr = ovsh.KeyAttrCtZone
+	KeyAttrCTMark          KeyAttr = ovsh.KeyAttrCtMark
+	KeyAttrCTLabels        KeyAttr = ovsh.KeyAttrCtLabels
+	KeyAttrCTOrigTupleIPv4 KeyAttr = ovsh.KeyAttrCtOrigTupleIpv4
+	KeyAttrCTOrigTupleIPv6 KeyAttr = ovsh.KeyAttrCtOrigTupleIpv6
+	KeyAttrNSH             KeyAttr = ovsh.KeyAttrNsh
+)
+
+// String returns the string representation of a KeyAttr, using the names
+// found in ovs-dpctl output where possible.
+func (a KeyAttr) String() string {
+	names := []string{
+		"unspec",
+		"encap",
+		"skb_priority",
+		"in_port",
+		"eth",
+		"vlan",
+		"eth_type",
+		"ipv4",
+		"ipv6",
+		"tcp",
+		"udp",
+		"icmp",
+		"icmpv6",
+		"arp",
+		"nd",
+		"skb_mark",
+		"tunnel",
+		"sctp",
+		"tcp_flags",
+		"dp_hash",
+		"recirc_id",
+		"mpls",
+		"ct_state",
+		"ct_zone",
+		"ct_mark",
+		"ct_label",
+		"ct_tuple4",
+		"ct_tuple6",
+		"nsh",
+	}
+
+	if int(a) < len(names) {
+		return names[a]
+	}
+
+	return fmt.Sprintf("key(%d)", uint16(a))
+}
+
+// A FlowKey is a datapath flow key: a set of netlink attributes, indexed by
+// KeyAttr, which describe the headers and metadata of a packet.  Attribute
+// values are left in their kernel encoding.
+type FlowKey map[KeyAttr][]byte
+
+// parseFlowKey parses a FlowKey from a byte slice of netlink attributes.
+func parseFlowKey(b []byte) (FlowKey, error) {
+	attrs, err := netlink.UnmarshalAttributes(b)
+	if err != nil {
+		return nil, err
+	}
+
+	k := make(FlowKey, len(attrs))
+	for _, a := range attrs {
+		k[KeyAttr(a.Type)] = a.Data
+	}
+
+	return k, nil
+}
+
+// marshalFlowKey converts a FlowKey into a byte slice of netlink attributes,
+// sorted by KeyAttr.
+func marshalFlowKey(k FlowKey) ([]byte, error) {
+	attrs := make([]netlink.Attribute, 0, len(k))
+	for t, v := range k {
+		attrs = append(attrs, netlink.Attribute{
+			Type: uint16(t),
+			Data: v,
+		})
+	}
+
+	sort.Slice(attrs, func(i, j int) bool {
+		return attrs[i].Type < attrs[j].Type
+	})
+
+	return netlink.MarshalAttributes(attrs)
+}
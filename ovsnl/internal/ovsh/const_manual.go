@@ -0,0 +1,87 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovsh
+
+// Constants and types which were added to openvswitch.h after const.go and
+// struct.go were generated.  They can be removed once the generated files
+// are refreshed.
+
+const (
+	// DpFTcRecircSharing as defined in openvswitch.h.
+	DpFTcRecircSharing = (1 << 2)
+	// DpFDispatchUpcallPerCpu as defined in openvswitch.h.
+	DpFDispatchUpcallPerCpu = (1 << 3)
+)
+
+// ovsDatapathAttr enumeration additions from openvswitch.h
+const (
+	DpAttrMasksCacheSize = 7
+	DpAttrPerCpuPids     = 8
+)
+
+// ovsActionAttr enumeration additions from openvswitch.h
+const (
+	ActionAttrClone       = 20
+	ActionAttrCheckPktLen = 21
+	ActionAttrAddMpls     = 22
+	ActionAttrDecTtl      = 23
+)
+
+// ovsCheckPktLenAttr enumeration from openvswitch.h
+const (
+	CheckPktLenAttrUnspec             = iota
+	CheckPktLenAttrPktLen             = 1
+	CheckPktLenAttrActionsIfGreater   = 2
+	CheckPktLenAttrActionsIfLessEqual = 3
+)
+
+// ovsDecTtlAttr enumeration from openvswitch.h
+const (
+	DecTtlAttrUnspec = iota
+	DecTtlAttrAction = 1
+)
+
+const (
+	// CtLimitFamily as defined in openvswitch.h.
+	CtLimitFamily = "ovs_ct_limit"
+	// CtLimitMcgroup as defined in openvswitch.h.
+	CtLimitMcgroup = "ovs_ct_limit"
+	// CtLimitVersion as defined in openvswitch.h.
+	CtLimitVersion = 0x1
+	// ZoneLimitDefaultZone as defined in openvswitch.h.
+	ZoneLimitDefaultZone = -1
+)
+
+// ovsCtLimitCmd enumeration from openvswitch.h
+const (
+	CtLimitCmdUnspec = iota
+	CtLimitCmdSet    = 1
+	CtLimitCmdDel    = 2
+	CtLimitCmdGet    = 3
+)
+
+// ovsCtLimitAttr enumeration from openvswitch.h
+const (
+	CtLimitAttrUnspec    = iota
+	CtLimitAttrZoneLimit = 1
+	__CtLimitAttrMax     = 2
+)
+
+// ZoneLimit is struct ovs_zone_limit from openvswitch.h.
+type ZoneLimit struct {
+	Zone_id int32
+	Limit   uint32
+	Count   uint32
+}
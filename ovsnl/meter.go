@@ -0,0 +1,323 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovsnl
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"github.com/digitalocean/go-openvswitch/ovsnl/internal/ovsh"
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/netlink"
+)
+
+// A MeterService provides access to methods which interact with the
+// "ovs_meter" generic netlink family.
+type MeterService struct {
+	c *Client
+	f genetlink.Family
+}
+
+// A MeterBandType is the type of a MeterBand.
+type MeterBandType uint32
+
+// Possible MeterBandType values.
+const (
+	// MeterBandTypeDrop drops packets which exceed the band's rate.
+	MeterBandTypeDrop MeterBandType = ovsh.MeterBandTypeDrop
+)
+
+// String returns the string representation of a MeterBandType.
+func (t MeterBandType) String() string {
+	switch t {
+	case MeterBandTypeDrop:
+		return "drop"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint32(t))
+	}
+}
+
+// MeterFeatures describes the meter capabilities of a datapath.
+type MeterFeatures struct {
+	// Maximum number of meters supported by the datapath.
+	MaxMeters uint32
+	// Maximum number of bands per meter.
+	MaxBands uint32
+	// Band types supported by the datapath.
+	BandTypes []MeterBandType
+}
+
+// A Meter is a datapath meter which limits the rate of packets passing
+// through it.
+type Meter struct {
+	// ID is the datapath's identifier for the meter.
+	ID uint32
+	// Kbps specifies that band rates are in kilobits per second.  Otherwise,
+	// band rates are in packets per second.
+	Kbps bool
+	// Bands are the rate limits applied by the meter.
+	Bands []MeterBand
+}
+
+// A MeterBand is a single rate limit within a Meter.
+type MeterBand struct {
+	Type MeterBandType
+	// Rate in kilobits or packets per second, depending on the Meter.
+	Rate uint32
+	// Burst size in kilobits or packets, depending on the Meter.
+	Burst uint32
+}
+
+// MeterStats contains statistics about packets that have passed through
+// a Meter.
+type MeterStats struct {
+	// ID is the datapath's identifier for the meter.
+	ID uint32
+	// Number of packets processed by the meter.
+	Packets uint64
+	// Number of bytes processed by the meter.
+	Bytes uint64
+	// Statistics for each of the meter's bands, in the same order as
+	// Meter.Bands.
+	Bands []MeterBandStats
+}
+
+// MeterBandStats contains statistics about packets that exceeded the rate
+// of a MeterBand.
+type MeterBandStats struct {
+	Packets uint64
+	Bytes   uint64
+}
+
+// errMeterNotFound is returned when a meter reply contains no meter.
+var errMeterNotFound = errors.New("no meter found in reply")
+
+// Features retrieves the meter features of the datapath with the specified
+// interface index.
+func (s *MeterService) Features(datapath int) (MeterFeatures, error) {
+	msgs, err := s.c.execute(s.f, ovsh.MeterCmdFeatures, datapath, nil, netlink.Request)
+	if err != nil {
+		return MeterFeatures{}, err
+	}
+
+	if l := len(msgs); l != 1 {
+		return MeterFeatures{}, fmt.Errorf("expected one meter features reply, but got: %d", l)
+	}
+
+	return parseMeterFeatures(msgs[0])
+}
+
+// Set adds a Meter to the datapath with the specified interface index, or
+// replaces the existing Meter with the same ID.  Replacing a Meter resets
+// its statistics.
+func (s *MeterService) Set(datapath int, m Meter) error {
+	if len(m.Bands) == 0 {
+		return errors.New("meter must have at least one band")
+	}
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Uint32(ovsh.MeterAttrId, m.ID)
+	ae.Flag(ovsh.MeterAttrKbps, m.Kbps)
+	ae.Nested(ovsh.MeterAttrBands, func(nae *netlink.AttributeEncoder) error {
+		for _, b := range m.Bands {
+			b := b
+			nae.Nested(ovsh.BandAttrUnspec, func(bae *netlink.AttributeEncoder) error {
+				bae.Uint32(ovsh.BandAttrType, uint32(b.Type))
+				bae.Uint32(ovsh.BandAttrRate, b.Rate)
+				bae.Uint32(ovsh.BandAttrBurst, b.Burst)
+				return nil
+			})
+		}
+
+		return nil
+	})
+
+	ab, err := ae.Encode()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.c.execute(s.f, ovsh.MeterCmdSet, datapath, ab, netlink.Request)
+	return err
+}
+
+// Delete deletes the Meter with the specified ID from the datapath with the
+// specified interface index.
+func (s *MeterService) Delete(datapath int, id uint32) error {
+	_, err := s.meter(ovsh.MeterCmdDel, datapath, id)
+	return err
+}
+
+// Stats retrieves the MeterStats for the Meter with the specified ID in the
+// datapath with the specified interface index.
+func (s *MeterService) Stats(datapath int, id uint32) (MeterStats, error) {
+	msgs, err := s.meter(ovsh.MeterCmdGet, datapath, id)
+	if err != nil {
+		return MeterStats{}, err
+	}
+
+	if len(msgs) == 0 {
+		return MeterStats{}, errMeterNotFound
+	}
+
+	return parseMeterStats(msgs[0])
+}
+
+// meter executes a meter command which operates on a single meter ID.
+func (s *MeterService) meter(cmd uint8, datapath int, id uint32) ([]genetlink.Message, error) {
+	ae := netlink.NewAttributeEncoder()
+	ae.Uint32(ovsh.MeterAttrId, id)
+
+	ab, err := ae.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.c.execute(s.f, cmd, datapath, ab, netlink.Request)
+}
+
+// parseMeterFeatures parses MeterFeatures from a generic netlink message.
+func parseMeterFeatures(m genetlink.Message) (MeterFeatures, error) {
+	// Skip the header to parse attributes.
+	if _, err := parseHeader(m.Data); err != nil {
+		return MeterFeatures{}, err
+	}
+
+	ad, err := netlink.NewAttributeDecoder(m.Data[sizeofHeader:])
+	if err != nil {
+		return MeterFeatures{}, err
+	}
+
+	var f MeterFeatures
+	for ad.Next() {
+		switch ad.Type() {
+		case ovsh.MeterAttrMaxMeters:
+			f.MaxMeters = ad.Uint32()
+		case ovsh.MeterAttrMaxBands:
+			f.MaxBands = ad.Uint32()
+		case ovsh.MeterAttrBands:
+			ad.Nested(func(nad *netlink.AttributeDecoder) error {
+				for nad.Next() {
+					nad.Nested(func(bad *netlink.AttributeDecoder) error {
+						for bad.Next() {
+							if bad.Type() == ovsh.BandAttrType {
+								f.BandTypes = append(f.BandTypes, MeterBandType(bad.Uint32()))
+							}
+						}
+
+						return nil
+					})
+				}
+
+				return nil
+			})
+		}
+	}
+
+	if err := ad.Err(); err != nil {
+		return MeterFeatures{}, err
+	}
+
+	return f, nil
+}
+
+// parseMeterStats parses MeterStats from a generic netlink message.
+func parseMeterStats(m genetlink.Message) (MeterStats, error) {
+	// Skip the header to parse attributes.
+	if _, err := parseHeader(m.Data); err != nil {
+		return MeterStats{}, err
+	}
+
+	ad, err := netlink.NewAttributeDecoder(m.Data[sizeofHeader:])
+	if err != nil {
+		return MeterStats{}, err
+	}
+
+	var (
+		ms MeterStats
+		ok bool
+	)
+
+	for ad.Next() {
+		switch ad.Type() {
+		case ovsh.MeterAttrId:
+			ms.ID = ad.Uint32()
+			ok = true
+		case ovsh.MeterAttrStats:
+			ad.Do(func(b []byte) error {
+				s, err := parseFlowStats(b)
+				if err != nil {
+					return err
+				}
+
+				ms.Packets, ms.Bytes = s.Packets, s.Bytes
+				return nil
+			})
+		case ovsh.MeterAttrBands:
+			ad.Nested(func(nad *netlink.AttributeDecoder) error {
+				for nad.Next() {
+					nad.Nested(func(bad *netlink.AttributeDecoder) error {
+						var bs MeterBandStats
+						for bad.Next() {
+							if bad.Type() != ovsh.BandAttrStats {
+								continue
+							}
+
+							bad.Do(func(b []byte) error {
+								s, err := parseFlowStats(b)
+								if err != nil {
+									return err
+								}
+
+								bs = MeterBandStats{
+									Packets: s.Packets,
+									Bytes:   s.Bytes,
+								}
+								return nil
+							})
+						}
+
+						ms.Bands = append(ms.Bands, bs)
+						return nil
+					})
+				}
+
+				return nil
+			})
+		}
+	}
+
+	if err := ad.Err(); err != nil {
+		return MeterStats{}, err
+	}
+
+	if !ok {
+		return MeterStats{}, errMeterNotFound
+	}
+
+	return ms, nil
+}
+
+// parseFlowStats converts a byte slice into ovsh.FlowStats.
+func parseFlowStats(b []byte) (ovsh.FlowStats, error) {
+	// Verify that the byte slice is the correct length before doing
+	// unsafe casts.
+	if want, got := sizeofFlowStats, len(b); want != got {
+		return ovsh.FlowStats{}, fmt.Errorf("unexpected flow stats structure size, want %d, got %d", want, got)
+	}
+
+	return *(*ovsh.FlowStats)(unsafe.Pointer(&b[0])), nil
+}
@@ -0,0 +1,254 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build linux
+
+package ovsnl
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/digitalocean/go-openvswitch/ovsnl/internal/ovsh"
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/genetlink/genltest"
+	"github.com/mdlayher/netlink"
+)
+
+func TestClientMeterFeaturesOK(t *testing.T) {
+	want := MeterFeatures{
+		MaxMeters: 1024,
+		MaxBands:  1,
+		BandTypes: []MeterBandType{MeterBandTypeDrop},
+	}
+
+	conn := genltest.Dial(ovsFamilies(func(greq genetlink.Message, nreq netlink.Message) ([]genetlink.Message, error) {
+		if diff := cmp.Diff(ovsh.MeterCmdFeatures, int(greq.Header.Command)); diff != "" {
+			t.Fatalf("unexpected generic netlink command (-want +got):\n%s", diff)
+		}
+
+		ae := netlink.NewAttributeEncoder()
+		ae.Uint32(ovsh.MeterAttrMaxMeters, want.MaxMeters)
+		ae.Uint32(ovsh.MeterAttrMaxBands, want.MaxBands)
+		ae.Nested(ovsh.MeterAttrBands, func(nae *netlink.AttributeEncoder) error {
+			nae.Nested(ovsh.BandAttrUnspec, func(bae *netlink.AttributeEncoder) error {
+				bae.Uint32(ovsh.BandAttrType, ovsh.MeterBandTypeDrop)
+				return nil
+			})
+			return nil
+		})
+
+		return []genetlink.Message{{
+			Data: append(headerBytes(ovsh.Header{Ifindex: 1}), mustEncode(ae)...),
+		}}, nil
+	}))
+
+	c, err := newClient(conn)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	f, err := c.Meter.Features(1)
+	if err != nil {
+		t.Fatalf("failed to get meter features: %v", err)
+	}
+
+	if diff := cmp.Diff(want, f); diff != "" {
+		t.Fatalf("unexpected meter features (-want +got):\n%s", diff)
+	}
+}
+
+func TestClientMeterSetNoBands(t *testing.T) {
+	conn := genltest.Dial(ovsFamilies(func(greq genetlink.Message, nreq netlink.Message) ([]genetlink.Message, error) {
+		t.Fatal("no request should be sent")
+		return nil, nil
+	}))
+
+	c, err := newClient(conn)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	if err := c.Meter.Set(1, Meter{ID: 1}); err == nil {
+		t.Fatalf("expected an error, but none occurred")
+	}
+}
+
+func TestClientMeterSetOK(t *testing.T) {
+	m := Meter{
+		ID:   10,
+		Kbps: true,
+		Bands: []MeterBand{{
+			Type:  MeterBandTypeDrop,
+			Rate:  1000,
+			Burst: 100,
+		}},
+	}
+
+	conn := genltest.Dial(ovsFamilies(func(greq genetlink.Message, nreq netlink.Message) ([]genetlink.Message, error) {
+		if diff := cmp.Diff(ovsh.MeterCmdSet, int(greq.Header.Command)); diff != "" {
+			t.Fatalf("unexpected generic netlink command (-want +got):\n%s", diff)
+		}
+
+		ad, err := netlink.NewAttributeDecoder(greq.Data[sizeofHeader:])
+		if err != nil {
+			t.Fatalf("failed to create attribute decoder: %v", err)
+		}
+
+		var got Meter
+		for ad.Next() {
+			switch ad.Type() {
+			case ovsh.MeterAttrId:
+				got.ID = ad.Uint32()
+			case ovsh.MeterAttrKbps:
+				got.Kbps = ad.Flag()
+			case ovsh.MeterAttrBands:
+				ad.Nested(func(nad *netlink.AttributeDecoder) error {
+					for nad.Next() {
+						nad.Nested(func(bad *netlink.AttributeDecoder) error {
+							var b MeterBand
+							for bad.Next() {
+								switch bad.Type() {
+								case ovsh.BandAttrType:
+									b.Type = MeterBandType(bad.Uint32())
+								case ovsh.BandAttrRate:
+									b.Rate = bad.Uint32()
+								case ovsh.BandAttrBurst:
+									b.Burst = bad.Uint32()
+								}
+							}
+
+							got.Bands = append(got.Bands, b)
+							return nil
+						})
+					}
+
+					return nil
+				})
+			}
+		}
+
+		if err := ad.Err(); err != nil {
+			t.Fatalf("failed to decode attributes: %v", err)
+		}
+
+		if diff := cmp.Diff(m, got); diff != "" {
+			t.Fatalf("unexpected meter (-want +got):\n%s", diff)
+		}
+
+		return []genetlink.Message{{
+			Data: greq.Data,
+		}}, nil
+	}))
+
+	c, err := newClient(conn)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	if err := c.Meter.Set(1, m); err != nil {
+		t.Fatalf("failed to set meter: %v", err)
+	}
+}
+
+func TestClientMeterStatsBadStats(t *testing.T) {
+	conn := genltest.Dial(ovsFamilies(func(greq genetlink.Message, nreq netlink.Message) ([]genetlink.Message, error) {
+		// Valid header; not enough data for ovsh.FlowStats.
+		ae := netlink.NewAttributeEncoder()
+		ae.Uint32(ovsh.MeterAttrId, 10)
+		ae.Bytes(ovsh.MeterAttrStats, []byte{0xff})
+
+		return []genetlink.Message{{
+			Data: append(headerBytes(ovsh.Header{Ifindex: 1}), mustEncode(ae)...),
+		}}, nil
+	}))
+
+	c, err := newClient(conn)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	_, err = c.Meter.Stats(1, 10)
+	if err == nil {
+		t.Fatalf("expected an error, but none occurred")
+	}
+
+	t.Logf("OK error: %v", err)
+}
+
+func TestClientMeterStatsOK(t *testing.T) {
+	want := MeterStats{
+		ID:      10,
+		Packets: 100,
+		Bytes:   1000,
+		Bands: []MeterBandStats{{
+			Packets: 5,
+			Bytes:   50,
+		}},
+	}
+
+	conn := genltest.Dial(ovsFamilies(func(greq genetlink.Message, nreq netlink.Message) ([]genetlink.Message, error) {
+		if diff := cmp.Diff(ovsh.MeterCmdGet, int(greq.Header.Command)); diff != "" {
+			t.Fatalf("unexpected generic netlink command (-want +got):\n%s", diff)
+		}
+
+		ae := netlink.NewAttributeEncoder()
+		ae.Uint32(ovsh.MeterAttrId, want.ID)
+		ae.Bytes(ovsh.MeterAttrStats, flowStatsBytes(want.Packets, want.Bytes))
+		ae.Nested(ovsh.MeterAttrBands, func(nae *netlink.AttributeEncoder) error {
+			for _, b := range want.Bands {
+				b := b
+				nae.Nested(ovsh.BandAttrUnspec, func(bae *netlink.AttributeEncoder) error {
+					bae.Bytes(ovsh.BandAttrStats, flowStatsBytes(b.Packets, b.Bytes))
+					return nil
+				})
+			}
+			return nil
+		})
+
+		return []genetlink.Message{{
+			Data: append(headerBytes(ovsh.Header{Ifindex: 1}), mustEncode(ae)...),
+		}}, nil
+	}))
+
+	c, err := newClient(conn)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	ms, err := c.Meter.Stats(1, 10)
+	if err != nil {
+		t.Fatalf("failed to get meter stats: %v", err)
+	}
+
+	if diff := cmp.Diff(want, ms); diff != "" {
+		t.Fatalf("unexpected meter stats (-want +got):\n%s", diff)
+	}
+}
+
+func flowStatsBytes(packets, bytes uint64) []byte {
+	s := ovsh.FlowStats{
+		Packets: packets,
+		Bytes:   bytes,
+	}
+
+	b := *(*[sizeofFlowStats]byte)(unsafe.Pointer(&s))
+	return b[:]
+}
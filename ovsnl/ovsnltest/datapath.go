@@ -0,0 +1,218 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build linux
+
+package ovsnltest
+
+import (
+	"fmt"
+	"sort"
+	"unsafe"
+
+	"github.com/digitalocean/go-openvswitch/ovsnl"
+	"github.com/digitalocean/go-openvswitch/ovsnl/internal/ovsh"
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/genetlink/genltest"
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nlenc"
+	"golang.org/x/sys/unix"
+)
+
+// A datapath is the state of a fake datapath.
+type datapath struct {
+	ovsnl.Datapath
+
+	upcallPID  uint32
+	perCPUPIDs []uint32
+	vports     map[uint32]*ovsnl.Vport
+	flows      []*flow
+	meters     map[uint32]*meter
+}
+
+// PerCPUUpcallPortIDs returns the per-CPU upcall port IDs of the datapath
+// with the specified interface index.
+func (k *Kernel) PerCPUUpcallPortIDs(datapath int) ([]uint32, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	dp, ok := k.datapaths[datapath]
+	if !ok {
+		return nil, fmt.Errorf("ovsnltest: datapath %d not found", datapath)
+	}
+
+	return dp.perCPUPIDs, nil
+}
+
+// AddDatapath adds a datapath with the specified name to the Kernel, along
+// with its internal local vport, and returns the datapath's interface index.
+func (k *Kernel) AddDatapath(name string) int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.addDatapath(name, 0, 0)
+}
+
+// addDatapath adds a datapath to the Kernel.  The caller must hold k.mu.
+func (k *Kernel) addDatapath(name string, pid uint32, features ovsnl.DatapathFeatures) int {
+	index := k.nextIfindex
+	k.nextIfindex++
+
+	k.datapaths[index] = &datapath{
+		Datapath: ovsnl.Datapath{
+			Index:          index,
+			Name:           name,
+			Features:       features,
+			MasksCacheSize: 256,
+		},
+		upcallPID: pid,
+		vports: map[uint32]*ovsnl.Vport{
+			// The local port shares the datapath's name and interface index.
+			0: {
+				DatapathIndex: index,
+				PortNo:        0,
+				Type:          ovsnl.VportTypeInternal,
+				Name:          name,
+				Ifindex:       index,
+				UpcallPortIDs: []uint32{pid},
+			},
+		},
+		meters: make(map[uint32]*meter),
+	}
+
+	return index
+}
+
+// datapathCommand serves requests for the "ovs_datapath" family.
+func (k *Kernel) datapathCommand(c command) ([]genetlink.Message, error) {
+	switch c.cmd {
+	case ovsh.DpCmdNew:
+		b, ok := c.attrs[ovsh.DpAttrName]
+		if !ok || !c.has(ovsh.DpAttrUpcallPid) {
+			return nil, genltest.Error(int(unix.EINVAL))
+		}
+
+		name := nlenc.String(b)
+		for _, dp := range k.datapaths {
+			if dp.Name == name {
+				return nil, genltest.Error(int(unix.EEXIST))
+			}
+		}
+
+		var features ovsnl.DatapathFeatures
+		if b, ok := c.attrs[ovsh.DpAttrUserFeatures]; ok {
+			features = ovsnl.DatapathFeatures(nlenc.Uint32(b))
+		}
+
+		index := k.addDatapath(name, nlenc.Uint32(c.attrs[ovsh.DpAttrUpcallPid]), features)
+		return datapathReply(c, k.datapaths[index])
+	case ovsh.DpCmdDel:
+		dp, err := k.lookupDatapath(c, ovsh.DpAttrName)
+		if err != nil {
+			return nil, err
+		}
+
+		delete(k.datapaths, dp.Index)
+		return datapathReply(c, dp)
+	case ovsh.DpCmdGet:
+		if c.dump {
+			indices := make([]int, 0, len(k.datapaths))
+			for i := range k.datapaths {
+				indices = append(indices, i)
+			}
+			sort.Ints(indices)
+
+			var msgs []genetlink.Message
+			for _, i := range indices {
+				m, err := datapathReply(c, k.datapaths[i])
+				if err != nil {
+					return nil, err
+				}
+
+				msgs = append(msgs, m...)
+			}
+
+			return msgs, nil
+		}
+
+		dp, err := k.lookupDatapath(c, ovsh.DpAttrName)
+		if err != nil {
+			return nil, err
+		}
+
+		return datapathReply(c, dp)
+	case ovsh.DpCmdSet:
+		dp, err := k.lookupDatapath(c, ovsh.DpAttrName)
+		if err != nil {
+			return nil, err
+		}
+
+		// As with the kernel, the datapath's upcall port ID can only be
+		// set when it is created, and is ignored here.  Features which are
+		// not specified are cleared, and the per-CPU port IDs are only used
+		// with per-CPU dispatch.
+		var features ovsnl.DatapathFeatures
+		if b, ok := c.attrs[ovsh.DpAttrUserFeatures]; ok {
+			features = ovsnl.DatapathFeatures(nlenc.Uint32(b))
+		}
+		dp.Features = features
+
+		if b, ok := c.attrs[ovsh.DpAttrPerCpuPids]; ok && features&ovsnl.DatapathFeaturesDispatchUpcallPerCPU != 0 {
+			pids, err := parsePortIDs(b)
+			if err != nil {
+				return nil, err
+			}
+
+			dp.perCPUPIDs = pids
+		}
+
+		return datapathReply(c, dp)
+	default:
+		return nil, genltest.Error(int(unix.EOPNOTSUPP))
+	}
+}
+
+// datapathReply builds a reply message containing a datapath.
+func datapathReply(c command, dp *datapath) ([]genetlink.Message, error) {
+	masks := make(map[string]struct{})
+	for _, f := range dp.flows {
+		masks[string(f.mask)] = struct{}{}
+	}
+
+	s := ovsh.DPStats{
+		Hit:    dp.Stats.Hit,
+		Missed: dp.Stats.Missed,
+		Lost:   dp.Stats.Lost,
+		Flows:  uint64(len(dp.flows)),
+	}
+
+	ms := ovsh.DPMegaflowStats{
+		Mask_hit: dp.MegaflowStats.MaskHits,
+		Masks:    uint32(len(masks)),
+	}
+
+	ae := netlink.NewAttributeEncoder()
+	ae.String(ovsh.DpAttrName, dp.Name)
+	ae.Uint32(ovsh.DpAttrUserFeatures, uint32(dp.Features))
+	ae.Bytes(ovsh.DpAttrStats, (*(*[unsafe.Sizeof(s)]byte)(unsafe.Pointer(&s)))[:])
+	ae.Bytes(ovsh.DpAttrMegaflowStats, (*(*[unsafe.Sizeof(ms)]byte)(unsafe.Pointer(&ms)))[:])
+	ae.Uint32(ovsh.DpAttrMasksCacheSize, dp.MasksCacheSize)
+
+	m, err := reply(c, dp.Index, ae)
+	if err != nil {
+		return nil, err
+	}
+
+	return []genetlink.Message{m}, nil
+}
@@ -0,0 +1,32 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ovsnltest provides an in-memory fake of the Linux Open vSwitch
+// generic netlink families, for testing code which uses package ovsnl
+// without root privileges or the openvswitch kernel module.
+//
+// A Kernel keeps the state of its datapaths, vports, flows, and meters, and
+// answers generic netlink requests through a genltest connection:
+//
+//	k := ovsnltest.New()
+//	dp := k.AddDatapath("ovs-system")
+//
+//	c, err := ovsnl.NewFromConn(k.Dial())
+//	if err != nil {
+//		// Handle error.
+//	}
+//	defer c.Close()
+//
+//	vports, err := c.Vport.List(dp)
+package ovsnltest
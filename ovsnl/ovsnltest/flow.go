@@ -0,0 +1,422 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build linux
+
+package ovsnltest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"unsafe"
+
+	"github.com/digitalocean/go-openvswitch/ovsnl"
+	"github.com/digitalocean/go-openvswitch/ovsnl/internal/ovsh"
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/genetlink/genltest"
+	"github.com/mdlayher/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// A Flow is a datapath flow which can be added to a Kernel using AddFlow.
+type Flow struct {
+	// Key and Mask describe the packets matched by the flow.  If Mask is
+	// nil, every bit of Key must match.
+	Key  ovsnl.FlowKey
+	Mask ovsnl.FlowKey
+	// Actions are netlink-encoded datapath actions.
+	Actions []byte
+	// UFID is an optional unique flow identifier.
+	UFID []byte
+
+	// Statistics reported for the flow.
+	Packets  uint64
+	Bytes    uint64
+	Used     uint64
+	TCPFlags uint8
+}
+
+// A flow is the state of a fake datapath flow.
+type flow struct {
+	Flow
+
+	// Canonical netlink encodings of the key and mask.
+	key, mask []byte
+}
+
+// AddFlow adds a Flow to the datapath with the specified interface index.
+func (k *Kernel) AddFlow(datapath int, f Flow) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	dp, ok := k.datapaths[datapath]
+	if !ok {
+		return fmt.Errorf("ovsnltest: datapath %d not found", datapath)
+	}
+
+	nf, err := newFlow(f)
+	if err != nil {
+		return err
+	}
+
+	if dp.flowByKey(nf.key) != nil {
+		return fmt.Errorf("ovsnltest: flow already exists in datapath %d", datapath)
+	}
+
+	dp.flows = append(dp.flows, nf)
+	return nil
+}
+
+// Flows returns a copy of the Flows in the datapath with the specified
+// interface index.
+func (k *Kernel) Flows(datapath int) []Flow {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	dp, ok := k.datapaths[datapath]
+	if !ok {
+		return nil
+	}
+
+	fs := make([]Flow, 0, len(dp.flows))
+	for _, f := range dp.flows {
+		fs = append(fs, f.Flow)
+	}
+
+	return fs
+}
+
+// newFlow creates a flow with canonical key and mask encodings.
+func newFlow(f Flow) (*flow, error) {
+	if len(f.Key) == 0 {
+		return nil, fmt.Errorf("ovsnltest: flow key must not be empty")
+	}
+
+	if f.Mask == nil {
+		// Exact match on every key attribute.
+		f.Mask = make(ovsnl.FlowKey, len(f.Key))
+		for t, v := range f.Key {
+			f.Mask[t] = bytes.Repeat([]byte{0xff}, len(v))
+		}
+	}
+
+	kb, err := marshalKey(f.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	mb, err := marshalKey(f.Mask)
+	if err != nil {
+		return nil, err
+	}
+
+	return &flow{
+		Flow: f,
+		key:  kb,
+		mask: mb,
+	}, nil
+}
+
+// flowByKey finds a flow with the canonical key encoding b.
+func (dp *datapath) flowByKey(b []byte) *flow {
+	for _, f := range dp.flows {
+		if bytes.Equal(f.key, b) {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// lookupFlow finds the flow referenced by a command's UFID or key.
+func (dp *datapath) lookupFlow(c command) (*flow, error) {
+	if id, ok := c.attrs[ovsh.FlowAttrUfid]; ok {
+		for _, f := range dp.flows {
+			if f.UFID != nil && bytes.Equal(f.UFID, id) {
+				return f, nil
+			}
+		}
+
+		return nil, genltest.Error(int(unix.ENOENT))
+	}
+
+	b, ok := c.attrs[ovsh.FlowAttrKey]
+	if !ok {
+		return nil, genltest.Error(int(unix.EINVAL))
+	}
+
+	key, err := parseKey(b)
+	if err != nil {
+		return nil, genltest.Error(int(unix.EINVAL))
+	}
+
+	kb, err := marshalKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f := dp.flowByKey(kb)
+	if f == nil {
+		return nil, genltest.Error(int(unix.ENOENT))
+	}
+
+	return f, nil
+}
+
+// removeFlow removes a flow from a datapath.
+func (dp *datapath) removeFlow(rf *flow) {
+	for i, f := range dp.flows {
+		if f == rf {
+			dp.flows = append(dp.flows[:i], dp.flows[i+1:]...)
+			return
+		}
+	}
+}
+
+// flowCommand serves requests for the "ovs_flow" family.
+func (k *Kernel) flowCommand(c command) ([]genetlink.Message, error) {
+	dp, ok := k.datapaths[c.datapath]
+	if !ok {
+		return nil, genltest.Error(int(unix.ENODEV))
+	}
+
+	switch c.cmd {
+	case ovsh.FlowCmdNew:
+		kb, ok := c.attrs[ovsh.FlowAttrKey]
+		if !ok || !c.has(ovsh.FlowAttrActions) {
+			return nil, genltest.Error(int(unix.EINVAL))
+		}
+
+		key, err := parseKey(kb)
+		if err != nil {
+			return nil, genltest.Error(int(unix.EINVAL))
+		}
+
+		if !k.validFlow(key, c.attrs[ovsh.FlowAttrActions]) {
+			return nil, genltest.Error(int(unix.EINVAL))
+		}
+
+		var mask ovsnl.FlowKey
+		if mb, ok := c.attrs[ovsh.FlowAttrMask]; ok {
+			if mask, err = parseKey(mb); err != nil {
+				return nil, genltest.Error(int(unix.EINVAL))
+			}
+		}
+
+		nf, err := newFlow(Flow{
+			Key:     key,
+			Mask:    mask,
+			Actions: c.attrs[ovsh.FlowAttrActions],
+			UFID:    c.attrs[ovsh.FlowAttrUfid],
+		})
+		if err != nil {
+			return nil, genltest.Error(int(unix.EINVAL))
+		}
+
+		if f := dp.flowByKey(nf.key); f != nil {
+			// Like the kernel, only update an existing flow's actions
+			// when exclusive creation is not requested.
+			if c.flags&netlink.Excl != 0 {
+				return nil, genltest.Error(int(unix.EEXIST))
+			}
+
+			f.Actions = nf.Actions
+			return flowReply(c, dp.Index, f)
+		}
+
+		dp.flows = append(dp.flows, nf)
+		return flowReply(c, dp.Index, nf)
+	case ovsh.FlowCmdSet:
+		f, err := dp.lookupFlow(c)
+		if err != nil {
+			return nil, err
+		}
+
+		if b, ok := c.attrs[ovsh.FlowAttrActions]; ok {
+			if !k.validFlow(f.Key, b) {
+				return nil, genltest.Error(int(unix.EINVAL))
+			}
+
+			f.Actions = b
+		}
+		if c.has(ovsh.FlowAttrClear) {
+			f.Packets, f.Bytes, f.Used, f.TCPFlags = 0, 0, 0, 0
+		}
+
+		return flowReply(c, dp.Index, f)
+	case ovsh.FlowCmdDel:
+		if !c.has(ovsh.FlowAttrKey) && !c.has(ovsh.FlowAttrUfid) {
+			// No flow specified; flush all flows without a reply.
+			dp.flows = nil
+			return nil, io.EOF
+		}
+
+		f, err := dp.lookupFlow(c)
+		if err != nil {
+			return nil, err
+		}
+
+		dp.removeFlow(f)
+		return flowReply(c, dp.Index, f)
+	case ovsh.FlowCmdGet:
+		if c.dump {
+			var msgs []genetlink.Message
+			for _, f := range dp.flows {
+				m, err := flowReply(c, dp.Index, f)
+				if err != nil {
+					return nil, err
+				}
+
+				msgs = append(msgs, m...)
+			}
+
+			return msgs, nil
+		}
+
+		f, err := dp.lookupFlow(c)
+		if err != nil {
+			return nil, err
+		}
+
+		return flowReply(c, dp.Index, f)
+	default:
+		return nil, genltest.Error(int(unix.EOPNOTSUPP))
+	}
+}
+
+// validFlow reports whether the Kernel supports a flow's key attributes
+// and actions.
+func (k *Kernel) validFlow(key ovsnl.FlowKey, actions []byte) bool {
+	for t := range key {
+		if t > k.MaxKeyAttr {
+			return false
+		}
+	}
+
+	attrs, err := netlink.UnmarshalAttributes(actions)
+	if err != nil {
+		return false
+	}
+
+	for _, a := range attrs {
+		var ok bool
+		switch a.Type &^ netlink.Nested {
+		case ovsh.ActionAttrCt:
+			ok = k.Actions.CT && (k.Actions.NAT || !hasAttr(a.Data, ovsh.CtAttrNat))
+		case ovsh.ActionAttrMeter:
+			ok = k.Actions.Meter
+		case ovsh.ActionAttrClone:
+			ok = k.Actions.Clone
+		case ovsh.ActionAttrCheckPktLen:
+			ok = k.Actions.CheckPktLen
+		case ovsh.ActionAttrDecTtl:
+			ok = k.Actions.DecTTL
+		default:
+			ok = a.Type&^netlink.Nested < ovsh.ActionAttrClone
+		}
+
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasAttr reports whether the netlink attributes in b contain an attribute
+// of type t.
+func hasAttr(b []byte, t uint16) bool {
+	attrs, err := netlink.UnmarshalAttributes(b)
+	if err != nil {
+		return false
+	}
+
+	for _, a := range attrs {
+		if a.Type&^netlink.Nested == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// flowReply builds a reply message containing a flow.
+func flowReply(c command, datapath int, f *flow) ([]genetlink.Message, error) {
+	ae := netlink.NewAttributeEncoder()
+	ae.Bytes(ovsh.FlowAttrKey, f.key)
+	if f.UFID != nil {
+		ae.Bytes(ovsh.FlowAttrUfid, f.UFID)
+	}
+	ae.Bytes(ovsh.FlowAttrMask, f.mask)
+
+	// Like the kernel, only report statistics which are set.
+	if f.Packets != 0 {
+		s := ovsh.FlowStats{
+			Packets: f.Packets,
+			Bytes:   f.Bytes,
+		}
+
+		ae.Bytes(ovsh.FlowAttrStats, (*(*[unsafe.Sizeof(s)]byte)(unsafe.Pointer(&s)))[:])
+	}
+	if f.Used != 0 {
+		ae.Uint64(ovsh.FlowAttrUsed, f.Used)
+	}
+	if f.TCPFlags != 0 {
+		ae.Uint8(ovsh.FlowAttrTcpFlags, f.TCPFlags)
+	}
+
+	ae.Bytes(ovsh.FlowAttrActions, f.Actions)
+
+	m, err := reply(c, datapath, ae)
+	if err != nil {
+		return nil, err
+	}
+
+	return []genetlink.Message{m}, nil
+}
+
+// parseKey parses a flow key from netlink attributes.
+func parseKey(b []byte) (ovsnl.FlowKey, error) {
+	attrs, err := netlink.UnmarshalAttributes(b)
+	if err != nil {
+		return nil, err
+	}
+
+	k := make(ovsnl.FlowKey, len(attrs))
+	for _, a := range attrs {
+		k[ovsnl.KeyAttr(a.Type)] = a.Data
+	}
+
+	return k, nil
+}
+
+// marshalKey produces a canonical netlink encoding of a flow key, with
+// attributes sorted by type.
+func marshalKey(k ovsnl.FlowKey) ([]byte, error) {
+	attrs := make([]netlink.Attribute, 0, len(k))
+	for t, v := range k {
+		attrs = append(attrs, netlink.Attribute{
+			Type: uint16(t),
+			Data: v,
+		})
+	}
+
+	sort.Slice(attrs, func(i, j int) bool {
+		return attrs[i].Type < attrs[j].Type
+	})
+
+	return netlink.MarshalAttributes(attrs)
+}
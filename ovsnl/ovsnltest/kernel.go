@@ -0,0 +1,322 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build linux
+
+package ovsnltest
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/digitalocean/go-openvswitch/ovsnl"
+	"github.com/digitalocean/go-openvswitch/ovsnl/internal/ovsh"
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/genetlink/genltest"
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nlenc"
+	"golang.org/x/sys/unix"
+)
+
+// Generic netlink family IDs assigned to the fake OVS families.
+const (
+	datapathFamilyID = 0x20 + iota
+	vportFamilyID
+	flowFamilyID
+	packetFamilyID
+	meterFamilyID
+)
+
+// families are the fake OVS generic netlink families served by a Kernel.
+var families = []genetlink.Family{
+	{
+		ID:      datapathFamilyID,
+		Name:    ovsh.DatapathFamily,
+		Version: ovsh.DatapathVersion,
+		Groups: []genetlink.MulticastGroup{{
+			ID:   datapathFamilyID,
+			Name: ovsh.DatapathMcgroup,
+		}},
+	},
+	{
+		ID:      vportFamilyID,
+		Name:    ovsh.VportFamily,
+		Version: ovsh.VportVersion,
+		Groups: []genetlink.MulticastGroup{{
+			ID:   vportFamilyID,
+			Name: ovsh.VportMcgroup,
+		}},
+	},
+	{
+		ID:      flowFamilyID,
+		Name:    ovsh.FlowFamily,
+		Version: ovsh.FlowVersion,
+		Groups: []genetlink.MulticastGroup{{
+			ID:   flowFamilyID,
+			Name: ovsh.FlowMcgroup,
+		}},
+	},
+	{
+		ID:      packetFamilyID,
+		Name:    ovsh.PacketFamily,
+		Version: ovsh.PacketVersion,
+	},
+	{
+		ID:      meterFamilyID,
+		Name:    ovsh.MeterFamily,
+		Version: ovsh.MeterVersion,
+		Groups: []genetlink.MulticastGroup{{
+			ID:   meterFamilyID,
+			Name: ovsh.MeterMcgroup,
+		}},
+	},
+}
+
+// A Kernel is an in-memory fake of the Open vSwitch kernel module's generic
+// netlink families.  It is safe for concurrent use.
+type Kernel struct {
+	// MeterFeatures are the meter capabilities reported by each datapath.
+	// Set it before the Kernel is used to change the defaults.
+	MeterFeatures ovsnl.MeterFeatures
+
+	// MaxKeyAttr is the highest flow key attribute type accepted in flows,
+	// and Actions are the optional datapath actions accepted in flows.
+	// Change them before the Kernel is used to emulate older kernels.
+	MaxKeyAttr ovsnl.KeyAttr
+	Actions    ovsnl.ActionCapabilities
+
+	mu          sync.Mutex
+	nextIfindex int
+	datapaths   map[int]*datapath
+	executed    []Execution
+}
+
+// New creates a new Kernel with no datapaths.
+func New() *Kernel {
+	return &Kernel{
+		MeterFeatures: ovsnl.MeterFeatures{
+			MaxMeters: 1024,
+			MaxBands:  1,
+			BandTypes: []ovsnl.MeterBandType{ovsnl.MeterBandTypeDrop},
+		},
+		MaxKeyAttr: ovsnl.KeyAttrNSH,
+		Actions: ovsnl.ActionCapabilities{
+			CT:          true,
+			NAT:         true,
+			Meter:       true,
+			Clone:       true,
+			CheckPktLen: true,
+			DecTTL:      true,
+		},
+		nextIfindex: 1,
+		datapaths:   make(map[int]*datapath),
+	}
+}
+
+// Dial creates a generic netlink connection which is served by the Kernel.
+// It can be passed to ovsnl.NewFromConn.
+func (k *Kernel) Dial() *genetlink.Conn {
+	return genltest.Dial(k.Func())
+}
+
+// Client creates an *ovsnl.Client which is served by the Kernel.
+func (k *Kernel) Client() (*ovsnl.Client, error) {
+	return ovsnl.NewFromConn(k.Dial())
+}
+
+// Func returns a genltest.Func which serves requests using the Kernel.
+func (k *Kernel) Func() genltest.Func {
+	return func(greq genetlink.Message, nreq netlink.Message) ([]genetlink.Message, error) {
+		k.mu.Lock()
+		defer k.mu.Unlock()
+
+		dump := nreq.Header.Flags&netlink.Dump != 0
+
+		var fn func(c command) ([]genetlink.Message, error)
+		switch nreq.Header.Type {
+		case 0:
+			// No request; the connection is receiving multicast messages,
+			// which the Kernel does not send.
+			return nil, io.EOF
+		case unix.GENL_ID_CTRL:
+			return k.family(greq)
+		case datapathFamilyID:
+			fn = k.datapathCommand
+		case vportFamilyID:
+			fn = k.vportCommand
+		case flowFamilyID:
+			fn = k.flowCommand
+		case packetFamilyID:
+			fn = k.packetCommand
+		case meterFamilyID:
+			fn = k.meterCommand
+		default:
+			return nil, genltest.Error(int(unix.ENOENT))
+		}
+
+		c, err := parseCommand(greq, nreq.Header.Flags)
+		if err != nil {
+			return nil, genltest.Error(int(unix.EINVAL))
+		}
+		c.dump = dump
+
+		return fn(c)
+	}
+}
+
+// A command is a parsed request to an OVS generic netlink family.
+type command struct {
+	cmd      uint8
+	version  uint8
+	flags    netlink.HeaderFlags
+	dump     bool
+	datapath int
+	attrs    map[uint16][]byte
+}
+
+// parseCommand parses a command from a generic netlink request.
+func parseCommand(m genetlink.Message, flags netlink.HeaderFlags) (command, error) {
+	if len(m.Data) < 4 {
+		return command{}, fmt.Errorf("not enough data for OVS message header: %d bytes", len(m.Data))
+	}
+
+	attrs, err := netlink.UnmarshalAttributes(m.Data[4:])
+	if err != nil {
+		return command{}, err
+	}
+
+	c := command{
+		cmd:      m.Header.Command,
+		version:  m.Header.Version,
+		flags:    flags,
+		datapath: int(int32(nlenc.Uint32(m.Data[:4]))),
+		attrs:    make(map[uint16][]byte, len(attrs)),
+	}
+
+	for _, a := range attrs {
+		c.attrs[a.Type&^(netlink.Nested|netlink.NetByteOrder)] = a.Data
+	}
+
+	return c, nil
+}
+
+// has reports whether the command contains an attribute of type t.
+func (c command) has(t uint16) bool {
+	_, ok := c.attrs[t]
+	return ok
+}
+
+// family serves generic netlink controller requests for the OVS families.
+func (k *Kernel) family(greq genetlink.Message) ([]genetlink.Message, error) {
+	if greq.Header.Command != unix.CTRL_CMD_GETFAMILY {
+		return nil, genltest.Error(int(unix.EOPNOTSUPP))
+	}
+
+	// A request for a specific family contains its name.
+	var name string
+	if attrs, err := netlink.UnmarshalAttributes(greq.Data); err == nil {
+		for _, a := range attrs {
+			if a.Type == unix.CTRL_ATTR_FAMILY_NAME {
+				name = nlenc.String(a.Data)
+			}
+		}
+	}
+
+	var msgs []genetlink.Message
+	for _, f := range families {
+		if name != "" && name != f.Name {
+			continue
+		}
+
+		f := f
+		ae := netlink.NewAttributeEncoder()
+		ae.Uint16(unix.CTRL_ATTR_FAMILY_ID, f.ID)
+		ae.String(unix.CTRL_ATTR_FAMILY_NAME, f.Name)
+		ae.Uint32(unix.CTRL_ATTR_VERSION, uint32(f.Version))
+		if len(f.Groups) > 0 {
+			ae.Nested(unix.CTRL_ATTR_MCAST_GROUPS, func(nae *netlink.AttributeEncoder) error {
+				for i, g := range f.Groups {
+					g := g
+					nae.Nested(uint16(i+1), func(gae *netlink.AttributeEncoder) error {
+						gae.String(unix.CTRL_ATTR_MCAST_GRP_NAME, g.Name)
+						gae.Uint32(unix.CTRL_ATTR_MCAST_GRP_ID, g.ID)
+						return nil
+					})
+				}
+				return nil
+			})
+		}
+
+		b, err := ae.Encode()
+		if err != nil {
+			return nil, err
+		}
+
+		msgs = append(msgs, genetlink.Message{
+			Header: genetlink.Header{
+				Command: unix.CTRL_CMD_NEWFAMILY,
+				Version: 2,
+			},
+			Data: b,
+		})
+	}
+
+	if len(msgs) == 0 {
+		return nil, genltest.Error(int(unix.ENOENT))
+	}
+
+	return msgs, nil
+}
+
+// reply builds a reply message for command c, prepending an OVS header with
+// the specified datapath interface index to the attributes in ae.
+func reply(c command, datapath int, ae *netlink.AttributeEncoder) (genetlink.Message, error) {
+	b, err := ae.Encode()
+	if err != nil {
+		return genetlink.Message{}, err
+	}
+
+	return genetlink.Message{
+		Header: genetlink.Header{
+			Command: c.cmd,
+			Version: c.version,
+		},
+		Data: append(nlenc.Uint32Bytes(uint32(int32(datapath))), b...),
+	}, nil
+}
+
+// lookupDatapath finds the datapath referenced by a command, using either its
+// header interface index or the datapath name attribute with type nameAttr.
+func (k *Kernel) lookupDatapath(c command, nameAttr uint16) (*datapath, error) {
+	if c.datapath != 0 {
+		dp, ok := k.datapaths[c.datapath]
+		if !ok {
+			return nil, genltest.Error(int(unix.ENODEV))
+		}
+
+		return dp, nil
+	}
+
+	if b, ok := c.attrs[nameAttr]; ok {
+		name := nlenc.String(b)
+		for _, dp := range k.datapaths {
+			if dp.Name == name {
+				return dp, nil
+			}
+		}
+	}
+
+	return nil, genltest.Error(int(unix.ENODEV))
+}
@@ -0,0 +1,335 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build linux
+
+package ovsnltest_test
+
+import (
+	"testing"
+
+	"github.com/digitalocean/go-openvswitch/ovsnl"
+	"github.com/digitalocean/go-openvswitch/ovsnl/internal/ovsh"
+	"github.com/digitalocean/go-openvswitch/ovsnl/ovsnltest"
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nlenc"
+)
+
+func TestKernelDatapathVport(t *testing.T) {
+	k := ovsnltest.New()
+	dp := k.AddDatapath("ovs-system")
+
+	port, err := k.AddVport(dp, "vxlan_sys_4789", ovsnl.VportTypeVXLAN)
+	if err != nil {
+		t.Fatalf("failed to add vport: %v", err)
+	}
+
+	if _, err := k.AddVport(dp, "vxlan_sys_4789", ovsnl.VportTypeVXLAN); err == nil {
+		t.Fatalf("expected an error adding a duplicate vport, but none occurred")
+	}
+
+	c := mustClient(t, k)
+
+	dps, err := c.Datapath.List()
+	if err != nil {
+		t.Fatalf("failed to list datapaths: %v", err)
+	}
+
+	wantDPs := []ovsnl.Datapath{{
+		Index:          dp,
+		Name:           "ovs-system",
+		MasksCacheSize: 256,
+	}}
+
+	if diff := cmp.Diff(wantDPs, dps); diff != "" {
+		t.Fatalf("unexpected datapaths (-want +got):\n%s", diff)
+	}
+
+	if err := c.Vport.SetUpcallPortIDs(dp, port, 100, 200); err != nil {
+		t.Fatalf("failed to set upcall port IDs: %v", err)
+	}
+
+	// The datapath's own upcall port ID is set using its local vport.
+	if err := c.Vport.SetUpcallPortIDs(dp, 0, 300); err != nil {
+		t.Fatalf("failed to set local vport upcall port IDs: %v", err)
+	}
+
+	if err := c.Datapath.SetPerCPUUpcallPortIDs(dp, 400, 500); err != nil {
+		t.Fatalf("failed to set per-CPU upcall port IDs: %v", err)
+	}
+
+	pids, err := k.PerCPUUpcallPortIDs(dp)
+	if err != nil {
+		t.Fatalf("failed to get per-CPU upcall port IDs: %v", err)
+	}
+
+	if diff := cmp.Diff([]uint32{400, 500}, pids); diff != "" {
+		t.Fatalf("unexpected per-CPU upcall port IDs (-want +got):\n%s", diff)
+	}
+
+	dps, err = c.Datapath.List()
+	if err != nil {
+		t.Fatalf("failed to list datapaths: %v", err)
+	}
+
+	wantDPs[0].Features = ovsnl.DatapathFeaturesDispatchUpcallPerCPU
+	if diff := cmp.Diff(wantDPs, dps); diff != "" {
+		t.Fatalf("unexpected datapaths (-want +got):\n%s", diff)
+	}
+
+	stats := ovsnl.VportStats{RxPackets: 10, TxPackets: 20}
+	if err := k.SetVportStats("vxlan_sys_4789", stats); err != nil {
+		t.Fatalf("failed to set vport stats: %v", err)
+	}
+
+	vps, err := c.Vport.List(dp)
+	if err != nil {
+		t.Fatalf("failed to list vports: %v", err)
+	}
+
+	wantVPs := []ovsnl.Vport{
+		{
+			DatapathIndex: dp,
+			PortNo:        0,
+			Type:          ovsnl.VportTypeInternal,
+			Name:          "ovs-system",
+			Ifindex:       dp,
+			UpcallPortIDs: []uint32{300},
+		},
+		{
+			DatapathIndex: dp,
+			PortNo:        port,
+			Type:          ovsnl.VportTypeVXLAN,
+			Name:          "vxlan_sys_4789",
+			Ifindex:       dp + 1,
+			UpcallPortIDs: []uint32{100, 200},
+			Stats:         stats,
+		},
+	}
+
+	if diff := cmp.Diff(wantVPs, vps); diff != "" {
+		t.Fatalf("unexpected vports (-want +got):\n%s", diff)
+	}
+
+	vp, err := c.Vport.Get("vxlan_sys_4789")
+	if err != nil {
+		t.Fatalf("failed to get vport: %v", err)
+	}
+
+	if diff := cmp.Diff(wantVPs[1], vp); diff != "" {
+		t.Fatalf("unexpected vport (-want +got):\n%s", diff)
+	}
+
+	if _, err := c.Vport.Get("eth0"); err == nil {
+		t.Fatalf("expected an error getting a nonexistent vport, but none occurred")
+	}
+}
+
+func TestKernelMeter(t *testing.T) {
+	k := ovsnltest.New()
+	dp := k.AddDatapath("ovs-system")
+	c := mustClient(t, k)
+
+	f, err := c.Meter.Features(dp)
+	if err != nil {
+		t.Fatalf("failed to get meter features: %v", err)
+	}
+
+	if diff := cmp.Diff(k.MeterFeatures, f); diff != "" {
+		t.Fatalf("unexpected meter features (-want +got):\n%s", diff)
+	}
+
+	m := ovsnl.Meter{
+		ID:   1,
+		Kbps: true,
+		Bands: []ovsnl.MeterBand{{
+			Type:  ovsnl.MeterBandTypeDrop,
+			Rate:  1000,
+			Burst: 100,
+		}},
+	}
+
+	if err := c.Meter.Set(dp, m); err != nil {
+		t.Fatalf("failed to set meter: %v", err)
+	}
+
+	if diff := cmp.Diff([]ovsnl.Meter{m}, k.Meters(dp)); diff != "" {
+		t.Fatalf("unexpected meters (-want +got):\n%s", diff)
+	}
+
+	stats := ovsnl.MeterStats{
+		ID:      1,
+		Packets: 10,
+		Bytes:   100,
+		Bands: []ovsnl.MeterBandStats{{
+			Packets: 1,
+			Bytes:   10,
+		}},
+	}
+
+	if err := k.SetMeterStats(dp, stats); err != nil {
+		t.Fatalf("failed to set meter stats: %v", err)
+	}
+
+	got, err := c.Meter.Stats(dp, 1)
+	if err != nil {
+		t.Fatalf("failed to get meter stats: %v", err)
+	}
+
+	if diff := cmp.Diff(stats, got); diff != "" {
+		t.Fatalf("unexpected meter stats (-want +got):\n%s", diff)
+	}
+
+	if err := c.Meter.Delete(dp, 1); err != nil {
+		t.Fatalf("failed to delete meter: %v", err)
+	}
+
+	if _, err := c.Meter.Stats(dp, 1); err == nil {
+		t.Fatalf("expected an error getting a deleted meter, but none occurred")
+	}
+}
+
+func TestKernelFlowPacket(t *testing.T) {
+	k := ovsnltest.New()
+	dp := k.AddDatapath("ovs-system")
+
+	key := ovsnl.FlowKey{
+		ovsnl.KeyAttrInPort:    nlenc.Uint32Bytes(1),
+		ovsnl.KeyAttrEthertype: {0x08, 0x00},
+	}
+
+	if err := k.AddFlow(dp, ovsnltest.Flow{
+		Key:     key,
+		Packets: 10,
+		Bytes:   100,
+	}); err != nil {
+		t.Fatalf("failed to add flow: %v", err)
+	}
+
+	conn := k.Dial()
+	defer conn.Close()
+
+	families, err := conn.ListFamilies()
+	if err != nil {
+		t.Fatalf("failed to list families: %v", err)
+	}
+
+	ids := make(map[string]genetlink.Family)
+	for _, f := range families {
+		ids[f.Name] = f
+	}
+
+	kb := mustMarshalAttributes(t, []netlink.Attribute{
+		{Type: uint16(ovsnl.KeyAttrInPort), Data: nlenc.Uint32Bytes(2)},
+	})
+
+	actions := mustMarshalAttributes(t, []netlink.Attribute{
+		{Type: ovsh.ActionAttrOutput, Data: nlenc.Uint32Bytes(1)},
+	})
+
+	// Add a second flow and then dump both flows.
+	execute(t, conn, ids[ovsh.FlowFamily], ovsh.FlowCmdNew, dp, netlink.Request|netlink.Create|netlink.Excl, []netlink.Attribute{
+		{Type: ovsh.FlowAttrKey, Data: kb},
+		{Type: ovsh.FlowAttrActions, Data: actions},
+	})
+
+	msgs := execute(t, conn, ids[ovsh.FlowFamily], ovsh.FlowCmdGet, dp, netlink.Request|netlink.Dump, nil)
+	if diff := cmp.Diff(2, len(msgs)); diff != "" {
+		t.Fatalf("unexpected number of flows (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff(2, len(k.Flows(dp))); diff != "" {
+		t.Fatalf("unexpected number of kernel flows (-want +got):\n%s", diff)
+	}
+
+	dps, err := mustClient(t, k).Datapath.List()
+	if err != nil {
+		t.Fatalf("failed to list datapaths: %v", err)
+	}
+
+	if diff := cmp.Diff(uint64(2), dps[0].Stats.Flows); diff != "" {
+		t.Fatalf("unexpected datapath flow count (-want +got):\n%s", diff)
+	}
+
+	// Flush all flows.
+	execute(t, conn, ids[ovsh.FlowFamily], ovsh.FlowCmdDel, dp, netlink.Request, nil)
+	if diff := cmp.Diff(0, len(k.Flows(dp))); diff != "" {
+		t.Fatalf("unexpected number of kernel flows (-want +got):\n%s", diff)
+	}
+
+	// Execute a packet.
+	packet := []byte{0xde, 0xad, 0xbe, 0xef}
+	execute(t, conn, ids[ovsh.PacketFamily], ovsh.PacketCmdExecute, dp, netlink.Request, []netlink.Attribute{
+		{Type: ovsh.PacketAttrPacket, Data: packet},
+		{Type: ovsh.PacketAttrKey, Data: kb},
+		{Type: ovsh.PacketAttrActions, Data: actions},
+	})
+
+	want := []ovsnltest.Execution{{
+		DatapathIndex: dp,
+		Packet:        packet,
+		Key: ovsnl.FlowKey{
+			ovsnl.KeyAttrInPort: nlenc.Uint32Bytes(2),
+		},
+		Actions: actions,
+	}}
+
+	if diff := cmp.Diff(want, k.Executed()); diff != "" {
+		t.Fatalf("unexpected executed packets (-want +got):\n%s", diff)
+	}
+}
+
+func mustClient(t *testing.T, k *ovsnltest.Kernel) *ovsnl.Client {
+	t.Helper()
+
+	c, err := k.Client()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	return c
+}
+
+func execute(t *testing.T, c *genetlink.Conn, f genetlink.Family, cmd uint8, datapath int, flags netlink.HeaderFlags, attrs []netlink.Attribute) []genetlink.Message {
+	t.Helper()
+
+	req := genetlink.Message{
+		Header: genetlink.Header{
+			Command: cmd,
+			Version: uint8(f.Version),
+		},
+		Data: append(nlenc.Uint32Bytes(uint32(datapath)), mustMarshalAttributes(t, attrs)...),
+	}
+
+	msgs, err := c.Execute(req, f.ID, flags)
+	if err != nil {
+		t.Fatalf("failed to execute command %d: %v", cmd, err)
+	}
+
+	return msgs
+}
+
+func mustMarshalAttributes(t *testing.T, attrs []netlink.Attribute) []byte {
+	t.Helper()
+
+	b, err := netlink.MarshalAttributes(attrs)
+	if err != nil {
+		t.Fatalf("failed to marshal attributes: %v", err)
+	}
+
+	return b
+}
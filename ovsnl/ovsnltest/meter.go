@@ -0,0 +1,229 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build linux
+
+package ovsnltest
+
+import (
+	"fmt"
+	"sort"
+	"unsafe"
+
+	"github.com/digitalocean/go-openvswitch/ovsnl"
+	"github.com/digitalocean/go-openvswitch/ovsnl/internal/ovsh"
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/genetlink/genltest"
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nlenc"
+	"golang.org/x/sys/unix"
+)
+
+// A meter is the state of a fake datapath meter.
+type meter struct {
+	ovsnl.Meter
+	stats ovsnl.MeterStats
+}
+
+// Meters returns the meters in the datapath with the specified interface
+// index, sorted by ID.
+func (k *Kernel) Meters(datapath int) []ovsnl.Meter {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	dp, ok := k.datapaths[datapath]
+	if !ok {
+		return nil
+	}
+
+	ms := make([]ovsnl.Meter, 0, len(dp.meters))
+	for _, m := range dp.meters {
+		ms = append(ms, m.Meter)
+	}
+
+	sort.Slice(ms, func(i, j int) bool {
+		return ms[i].ID < ms[j].ID
+	})
+
+	return ms
+}
+
+// SetMeterStats sets the statistics reported for a meter in the datapath
+// with the specified interface index.  The meter is identified by stats.ID.
+func (k *Kernel) SetMeterStats(datapath int, stats ovsnl.MeterStats) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	dp, ok := k.datapaths[datapath]
+	if !ok {
+		return fmt.Errorf("ovsnltest: datapath %d not found", datapath)
+	}
+
+	m, ok := dp.meters[stats.ID]
+	if !ok {
+		return fmt.Errorf("ovsnltest: meter %d not found in datapath %d", stats.ID, datapath)
+	}
+
+	m.stats = stats
+	return nil
+}
+
+// meterCommand serves requests for the "ovs_meter" family.
+func (k *Kernel) meterCommand(c command) ([]genetlink.Message, error) {
+	dp, ok := k.datapaths[c.datapath]
+	if !ok {
+		return nil, genltest.Error(int(unix.ENODEV))
+	}
+
+	if c.cmd == ovsh.MeterCmdFeatures {
+		ae := netlink.NewAttributeEncoder()
+		ae.Uint32(ovsh.MeterAttrMaxMeters, k.MeterFeatures.MaxMeters)
+		ae.Uint32(ovsh.MeterAttrMaxBands, k.MeterFeatures.MaxBands)
+		ae.Nested(ovsh.MeterAttrBands, func(nae *netlink.AttributeEncoder) error {
+			for _, t := range k.MeterFeatures.BandTypes {
+				t := t
+				nae.Nested(ovsh.BandAttrUnspec, func(bae *netlink.AttributeEncoder) error {
+					bae.Uint32(ovsh.BandAttrType, uint32(t))
+					return nil
+				})
+			}
+			return nil
+		})
+
+		m, err := reply(c, dp.Index, ae)
+		if err != nil {
+			return nil, err
+		}
+
+		return []genetlink.Message{m}, nil
+	}
+
+	b, ok := c.attrs[ovsh.MeterAttrId]
+	if !ok {
+		return nil, genltest.Error(int(unix.EINVAL))
+	}
+	id := nlenc.Uint32(b)
+
+	switch c.cmd {
+	case ovsh.MeterCmdSet:
+		if id >= k.MeterFeatures.MaxMeters {
+			return nil, genltest.Error(int(unix.EFBIG))
+		}
+
+		bb, ok := c.attrs[ovsh.MeterAttrBands]
+		if !ok {
+			return nil, genltest.Error(int(unix.EINVAL))
+		}
+
+		bands, err := parseBands(bb)
+		if err != nil {
+			return nil, genltest.Error(int(unix.EINVAL))
+		}
+		if len(bands) == 0 || uint32(len(bands)) > k.MeterFeatures.MaxBands {
+			return nil, genltest.Error(int(unix.EINVAL))
+		}
+
+		m := &meter{
+			Meter: ovsnl.Meter{
+				ID:    id,
+				Kbps:  c.has(ovsh.MeterAttrKbps),
+				Bands: bands,
+			},
+			stats: ovsnl.MeterStats{
+				ID:    id,
+				Bands: make([]ovsnl.MeterBandStats, len(bands)),
+			},
+		}
+
+		dp.meters[id] = m
+		return meterReply(c, dp.Index, m)
+	case ovsh.MeterCmdDel, ovsh.MeterCmdGet:
+		m, ok := dp.meters[id]
+		if !ok {
+			return nil, genltest.Error(int(unix.ENOENT))
+		}
+
+		if c.cmd == ovsh.MeterCmdDel {
+			delete(dp.meters, id)
+		}
+
+		return meterReply(c, dp.Index, m)
+	default:
+		return nil, genltest.Error(int(unix.EOPNOTSUPP))
+	}
+}
+
+// parseBands parses meter bands from netlink attributes.
+func parseBands(b []byte) ([]ovsnl.MeterBand, error) {
+	ad, err := netlink.NewAttributeDecoder(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var bands []ovsnl.MeterBand
+	for ad.Next() {
+		ad.Nested(func(nad *netlink.AttributeDecoder) error {
+			var band ovsnl.MeterBand
+			for nad.Next() {
+				switch nad.Type() {
+				case ovsh.BandAttrType:
+					band.Type = ovsnl.MeterBandType(nad.Uint32())
+				case ovsh.BandAttrRate:
+					band.Rate = nad.Uint32()
+				case ovsh.BandAttrBurst:
+					band.Burst = nad.Uint32()
+				}
+			}
+
+			bands = append(bands, band)
+			return nil
+		})
+	}
+
+	return bands, ad.Err()
+}
+
+// meterReply builds a reply message containing a meter's statistics.
+func meterReply(c command, datapath int, m *meter) ([]genetlink.Message, error) {
+	s := ovsh.FlowStats{
+		Packets: m.stats.Packets,
+		Bytes:   m.stats.Bytes,
+	}
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Uint32(ovsh.MeterAttrId, m.ID)
+	ae.Bytes(ovsh.MeterAttrStats, (*(*[unsafe.Sizeof(s)]byte)(unsafe.Pointer(&s)))[:])
+	ae.Nested(ovsh.MeterAttrBands, func(nae *netlink.AttributeEncoder) error {
+		for _, bs := range m.stats.Bands {
+			bs := bs
+			nae.Nested(ovsh.BandAttrUnspec, func(bae *netlink.AttributeEncoder) error {
+				s := ovsh.FlowStats{
+					Packets: bs.Packets,
+					Bytes:   bs.Bytes,
+				}
+
+				bae.Bytes(ovsh.BandAttrStats, (*(*[unsafe.Sizeof(s)]byte)(unsafe.Pointer(&s)))[:])
+				return nil
+			})
+		}
+		return nil
+	})
+
+	rm, err := reply(c, datapath, ae)
+	if err != nil {
+		return nil, err
+	}
+
+	return []genetlink.Message{rm}, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build linux
+
+package ovsnltest
+
+import (
+	"io"
+
+	"github.com/digitalocean/go-openvswitch/ovsnl"
+	"github.com/digitalocean/go-openvswitch/ovsnl/internal/ovsh"
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/genetlink/genltest"
+	"golang.org/x/sys/unix"
+)
+
+// An Execution is a packet which was executed by a datapath using the
+// "ovs_packet" family's execute command.
+type Execution struct {
+	// DatapathIndex is the interface index of the datapath.
+	DatapathIndex int
+	Packet        []byte
+	Key           ovsnl.FlowKey
+	// Actions are the netlink-encoded datapath actions applied to
+	// the packet.
+	Actions []byte
+}
+
+// Executed returns the packets executed by the Kernel's datapaths, in the
+// order they were executed.
+func (k *Kernel) Executed() []Execution {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return append([]Execution(nil), k.executed...)
+}
+
+// packetCommand serves requests for the "ovs_packet" family.
+func (k *Kernel) packetCommand(c command) ([]genetlink.Message, error) {
+	if c.cmd != ovsh.PacketCmdExecute {
+		return nil, genltest.Error(int(unix.EOPNOTSUPP))
+	}
+
+	if _, ok := k.datapaths[c.datapath]; !ok {
+		return nil, genltest.Error(int(unix.ENODEV))
+	}
+
+	pb, ok := c.attrs[ovsh.PacketAttrPacket]
+	if !ok || !c.has(ovsh.PacketAttrKey) || !c.has(ovsh.PacketAttrActions) {
+		return nil, genltest.Error(int(unix.EINVAL))
+	}
+
+	key, err := parseKey(c.attrs[ovsh.PacketAttrKey])
+	if err != nil {
+		return nil, genltest.Error(int(unix.EINVAL))
+	}
+
+	k.executed = append(k.executed, Execution{
+		DatapathIndex: c.datapath,
+		Packet:        pb,
+		Key:           key,
+		Actions:       c.attrs[ovsh.PacketAttrActions],
+	})
+
+	// The kernel does not reply to executed packets.
+	return nil, io.EOF
+}
@@ -0,0 +1,285 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build linux
+
+package ovsnltest
+
+import (
+	"fmt"
+	"sort"
+	"unsafe"
+
+	"github.com/digitalocean/go-openvswitch/ovsnl"
+	"github.com/digitalocean/go-openvswitch/ovsnl/internal/ovsh"
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/genetlink/genltest"
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nlenc"
+	"golang.org/x/sys/unix"
+)
+
+// AddVport adds a vport with the specified name and type to the datapath with
+// the specified interface index, and returns the vport's port number.
+func (k *Kernel) AddVport(datapath int, name string, typ ovsnl.VportType) (uint32, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	dp, ok := k.datapaths[datapath]
+	if !ok {
+		return 0, fmt.Errorf("ovsnltest: datapath %d not found", datapath)
+	}
+	if k.vportByName(name) != nil {
+		return 0, fmt.Errorf("ovsnltest: vport %q already exists", name)
+	}
+
+	return k.addVport(dp, name, typ, nextPortNo(dp), nil), nil
+}
+
+// SetVportStats sets the statistics reported for the vport with the
+// specified name.
+func (k *Kernel) SetVportStats(name string, stats ovsnl.VportStats) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	vp := k.vportByName(name)
+	if vp == nil {
+		return fmt.Errorf("ovsnltest: vport %q not found", name)
+	}
+
+	vp.Stats = stats
+	return nil
+}
+
+// addVport adds a vport to a datapath.  The caller must hold k.mu.
+func (k *Kernel) addVport(dp *datapath, name string, typ ovsnl.VportType, port uint32, pids []uint32) uint32 {
+	dp.vports[port] = &ovsnl.Vport{
+		DatapathIndex: dp.Index,
+		PortNo:        port,
+		Type:          typ,
+		Name:          name,
+		Ifindex:       k.nextIfindex,
+		UpcallPortIDs: pids,
+	}
+	k.nextIfindex++
+
+	return port
+}
+
+// nextPortNo returns the lowest unused port number in a datapath.
+func nextPortNo(dp *datapath) uint32 {
+	var port uint32
+	for {
+		if _, ok := dp.vports[port]; !ok {
+			return port
+		}
+		port++
+	}
+}
+
+// vportByName finds a vport by name in any datapath.  The caller must
+// hold k.mu.
+func (k *Kernel) vportByName(name string) *ovsnl.Vport {
+	for _, dp := range k.datapaths {
+		for _, vp := range dp.vports {
+			if vp.Name == name {
+				return vp
+			}
+		}
+	}
+
+	return nil
+}
+
+// lookupVport finds the vport referenced by a command, using either its name
+// or its datapath and port number.
+func (k *Kernel) lookupVport(c command) (*datapath, *ovsnl.Vport, error) {
+	if b, ok := c.attrs[ovsh.VportAttrName]; ok {
+		vp := k.vportByName(nlenc.String(b))
+		if vp == nil {
+			return nil, nil, genltest.Error(int(unix.ENODEV))
+		}
+
+		return k.datapaths[vp.DatapathIndex], vp, nil
+	}
+
+	b, ok := c.attrs[ovsh.VportAttrPortNo]
+	if !ok {
+		return nil, nil, genltest.Error(int(unix.EINVAL))
+	}
+
+	dp, ok := k.datapaths[c.datapath]
+	if !ok {
+		return nil, nil, genltest.Error(int(unix.ENODEV))
+	}
+
+	vp, ok := dp.vports[nlenc.Uint32(b)]
+	if !ok {
+		return nil, nil, genltest.Error(int(unix.ENODEV))
+	}
+
+	return dp, vp, nil
+}
+
+// vportCommand serves requests for the "ovs_vport" family.
+func (k *Kernel) vportCommand(c command) ([]genetlink.Message, error) {
+	switch c.cmd {
+	case ovsh.VportCmdNew:
+		nb, ok := c.attrs[ovsh.VportAttrName]
+		if !ok || !c.has(ovsh.VportAttrType) || !c.has(ovsh.VportAttrUpcallPid) {
+			return nil, genltest.Error(int(unix.EINVAL))
+		}
+
+		dp, ok := k.datapaths[c.datapath]
+		if !ok {
+			return nil, genltest.Error(int(unix.ENODEV))
+		}
+
+		name := nlenc.String(nb)
+		if k.vportByName(name) != nil {
+			return nil, genltest.Error(int(unix.EEXIST))
+		}
+
+		port := nextPortNo(dp)
+		if b, ok := c.attrs[ovsh.VportAttrPortNo]; ok {
+			port = nlenc.Uint32(b)
+			if _, ok := dp.vports[port]; ok {
+				return nil, genltest.Error(int(unix.EBUSY))
+			}
+		}
+
+		pids, err := parsePortIDs(c.attrs[ovsh.VportAttrUpcallPid])
+		if err != nil {
+			return nil, err
+		}
+
+		typ := ovsnl.VportType(nlenc.Uint32(c.attrs[ovsh.VportAttrType]))
+		k.addVport(dp, name, typ, port, pids)
+		return vportReply(c, dp.vports[port])
+	case ovsh.VportCmdDel:
+		dp, vp, err := k.lookupVport(c)
+		if err != nil {
+			return nil, err
+		}
+		if vp.PortNo == 0 {
+			// The local port can only be removed with its datapath.
+			return nil, genltest.Error(int(unix.EINVAL))
+		}
+
+		delete(dp.vports, vp.PortNo)
+		return vportReply(c, vp)
+	case ovsh.VportCmdGet:
+		if c.dump {
+			dp, ok := k.datapaths[c.datapath]
+			if !ok {
+				return nil, genltest.Error(int(unix.ENODEV))
+			}
+
+			ports := make([]int, 0, len(dp.vports))
+			for p := range dp.vports {
+				ports = append(ports, int(p))
+			}
+			sort.Ints(ports)
+
+			var msgs []genetlink.Message
+			for _, p := range ports {
+				m, err := vportReply(c, dp.vports[uint32(p)])
+				if err != nil {
+					return nil, err
+				}
+
+				msgs = append(msgs, m...)
+			}
+
+			return msgs, nil
+		}
+
+		_, vp, err := k.lookupVport(c)
+		if err != nil {
+			return nil, err
+		}
+
+		return vportReply(c, vp)
+	case ovsh.VportCmdSet:
+		_, vp, err := k.lookupVport(c)
+		if err != nil {
+			return nil, err
+		}
+
+		if b, ok := c.attrs[ovsh.VportAttrType]; ok && ovsnl.VportType(nlenc.Uint32(b)) != vp.Type {
+			return nil, genltest.Error(int(unix.EINVAL))
+		}
+
+		if b, ok := c.attrs[ovsh.VportAttrUpcallPid]; ok {
+			pids, err := parsePortIDs(b)
+			if err != nil {
+				return nil, err
+			}
+
+			vp.UpcallPortIDs = pids
+		}
+
+		return vportReply(c, vp)
+	default:
+		return nil, genltest.Error(int(unix.EOPNOTSUPP))
+	}
+}
+
+// parsePortIDs parses an array of netlink port IDs.
+func parsePortIDs(b []byte) ([]uint32, error) {
+	if len(b) == 0 || len(b)%4 != 0 {
+		return nil, genltest.Error(int(unix.EINVAL))
+	}
+
+	pids := make([]uint32, 0, len(b)/4)
+	for i := 0; i < len(b); i += 4 {
+		pids = append(pids, nlenc.Uint32(b[i:i+4]))
+	}
+
+	return pids, nil
+}
+
+// vportReply builds a reply message containing a vport.
+func vportReply(c command, vp *ovsnl.Vport) ([]genetlink.Message, error) {
+	s := ovsh.VportStats{
+		Rx_packets: vp.Stats.RxPackets,
+		Tx_packets: vp.Stats.TxPackets,
+		Rx_bytes:   vp.Stats.RxBytes,
+		Tx_bytes:   vp.Stats.TxBytes,
+		Rx_errors:  vp.Stats.RxErrors,
+		Tx_errors:  vp.Stats.TxErrors,
+		Rx_dropped: vp.Stats.RxDropped,
+		Tx_dropped: vp.Stats.TxDropped,
+	}
+
+	pb := make([]byte, 0, 4*len(vp.UpcallPortIDs))
+	for _, pid := range vp.UpcallPortIDs {
+		pb = append(pb, nlenc.Uint32Bytes(pid)...)
+	}
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Uint32(ovsh.VportAttrPortNo, vp.PortNo)
+	ae.Uint32(ovsh.VportAttrType, uint32(vp.Type))
+	ae.String(ovsh.VportAttrName, vp.Name)
+	ae.Uint32(ovsh.VportAttrIfindex, uint32(vp.Ifindex))
+	ae.Bytes(ovsh.VportAttrUpcallPid, pb)
+	ae.Bytes(ovsh.VportAttrStats, (*(*[unsafe.Sizeof(s)]byte)(unsafe.Pointer(&s)))[:])
+
+	m, err := reply(c, vp.DatapathIndex, ae)
+	if err != nil {
+		return nil, err
+	}
+
+	return []genetlink.Message{m}, nil
+}
@@ -0,0 +1,191 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovsnl
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/digitalocean/go-openvswitch/ovsnl/internal/ovsh"
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nlenc"
+)
+
+// A PacketService provides access to methods which interact with the
+// "ovs_packet" generic netlink family.
+type PacketService struct {
+	c *Client
+	f genetlink.Family
+}
+
+// An UpcallCommand indicates why a packet was sent to userspace by
+// the datapath.
+type UpcallCommand int
+
+// Possible UpcallCommand values.
+const (
+	// UpcallMiss indicates that a packet did not match any datapath flow.
+	UpcallMiss UpcallCommand = ovsh.PacketCmdMiss
+	// UpcallAction indicates that a packet was sent to userspace by an
+	// explicit userspace action.
+	UpcallAction UpcallCommand = ovsh.PacketCmdAction
+)
+
+// String returns the string representation of an UpcallCommand.
+func (c UpcallCommand) String() string {
+	switch c {
+	case UpcallMiss:
+		return "miss"
+	case UpcallAction:
+		return "action"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(c))
+	}
+}
+
+// An Upcall is a packet which was sent to userspace by the datapath.
+type Upcall struct {
+	// Command indicates why the packet was sent to userspace.
+	Command UpcallCommand
+	// DatapathIndex is the interface index of the datapath which
+	// sent the packet.
+	DatapathIndex int
+	// Key is the flow key the datapath extracted from the packet.
+	Key FlowKey
+	// Userdata is the opaque data specified by a userspace action,
+	// if any.
+	Userdata []byte
+	// Packet contains the original packet bytes.
+	Packet []byte
+	// Length is the original length of the packet, if the packet
+	// was truncated before being sent to userspace.
+	Length int
+}
+
+// Listen opens a new generic netlink socket which receives Upcalls from the
+// kernel.  The socket's port ID must be registered as an upcall port ID
+// on a vport using VportService.SetUpcallPortIDs, or on a datapath which
+// dispatches upcalls per CPU using DatapathService.SetPerCPUUpcallPortIDs,
+// before Upcalls will be delivered to it.
+func (s *PacketService) Listen() (*UpcallListener, error) {
+	c, err := genetlink.Dial(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	pid, err := portID(c)
+	if err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+
+	return newUpcallListener(c, pid), nil
+}
+
+// An UpcallListener receives Upcalls from the kernel on a dedicated generic
+// netlink socket.
+type UpcallListener struct {
+	c   *genetlink.Conn
+	pid uint32
+}
+
+// newUpcallListener is the internal UpcallListener constructor, used in tests.
+func newUpcallListener(c *genetlink.Conn, pid uint32) *UpcallListener {
+	return &UpcallListener{
+		c:   c,
+		pid: pid,
+	}
+}
+
+// PortID returns the netlink port ID of the UpcallListener's socket.
+func (l *UpcallListener) PortID() uint32 {
+	return l.pid
+}
+
+// Close closes the UpcallListener's generic netlink socket.
+func (l *UpcallListener) Close() error {
+	return l.c.Close()
+}
+
+// SetReadDeadline sets the read deadline for calls to Receive.
+func (l *UpcallListener) SetReadDeadline(t time.Time) error {
+	return l.c.SetReadDeadline(t)
+}
+
+// Receive blocks until one or more Upcalls are received by the
+// UpcallListener.  Messages which are not upcalls are ignored.
+func (l *UpcallListener) Receive() ([]Upcall, error) {
+	msgs, _, err := l.c.Receive()
+	if err != nil {
+		return nil, err
+	}
+
+	ups := make([]Upcall, 0, len(msgs))
+	for _, m := range msgs {
+		switch m.Header.Command {
+		case ovsh.PacketCmdMiss, ovsh.PacketCmdAction:
+		default:
+			continue
+		}
+
+		up, err := parseUpcall(m)
+		if err != nil {
+			return nil, err
+		}
+
+		ups = append(ups, up)
+	}
+
+	return ups, nil
+}
+
+// parseUpcall parses an Upcall from a generic netlink message.
+func parseUpcall(m genetlink.Message) (Upcall, error) {
+	// Fetch the header at the beginning of the message.
+	h, err := parseHeader(m.Data)
+	if err != nil {
+		return Upcall{}, err
+	}
+
+	up := Upcall{
+		Command:       UpcallCommand(m.Header.Command),
+		DatapathIndex: int(h.Ifindex),
+	}
+
+	// Skip the header to parse attributes.
+	attrs, err := netlink.UnmarshalAttributes(m.Data[sizeofHeader:])
+	if err != nil {
+		return Upcall{}, err
+	}
+
+	for _, a := range attrs {
+		switch a.Type {
+		case ovsh.PacketAttrPacket:
+			up.Packet = a.Data
+		case ovsh.PacketAttrKey:
+			up.Key, err = parseFlowKey(a.Data)
+			if err != nil {
+				return Upcall{}, err
+			}
+		case ovsh.PacketAttrUserdata:
+			up.Userdata = a.Data
+		case ovsh.PacketAttrLen:
+			up.Length = int(nlenc.Uint32(a.Data))
+		}
+	}
+
+	return up, nil
+}
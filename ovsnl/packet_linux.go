@@ -0,0 +1,53 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build linux
+
+package ovsnl
+
+import (
+	"fmt"
+
+	"github.com/mdlayher/genetlink"
+	"golang.org/x/sys/unix"
+)
+
+// portID returns the netlink port ID bound to a generic netlink socket.
+func portID(c *genetlink.Conn) (uint32, error) {
+	rc, err := c.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		sa    unix.Sockaddr
+		saErr error
+	)
+
+	if err := rc.Control(func(fd uintptr) {
+		sa, saErr = unix.Getsockname(int(fd))
+	}); err != nil {
+		return 0, err
+	}
+	if saErr != nil {
+		return 0, saErr
+	}
+
+	nsa, ok := sa.(*unix.SockaddrNetlink)
+	if !ok {
+		return 0, fmt.Errorf("unexpected socket address type: %T", sa)
+	}
+
+	return nsa.Pid, nil
+}
@@ -0,0 +1,144 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build linux
+
+package ovsnl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/digitalocean/go-openvswitch/ovsnl/internal/ovsh"
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/genetlink/genltest"
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nlenc"
+)
+
+func TestUpcallListenerReceiveShortHeader(t *testing.T) {
+	conn := genltest.Dial(func(greq genetlink.Message, nreq netlink.Message) ([]genetlink.Message, error) {
+		// Not enough data for ovsh.Header.
+		return []genetlink.Message{{
+			Header: genetlink.Header{
+				Command: ovsh.PacketCmdMiss,
+			},
+			Data: []byte{0xff, 0xff},
+		}}, nil
+	})
+
+	l := newUpcallListener(conn, 1)
+	defer l.Close()
+
+	_, err := l.Receive()
+	if err == nil {
+		t.Fatalf("expected an error, but none occurred")
+	}
+
+	t.Logf("OK error: %v", err)
+}
+
+func TestUpcallListenerReceiveOK(t *testing.T) {
+	miss := Upcall{
+		Command:       UpcallMiss,
+		DatapathIndex: 1,
+		Key: FlowKey{
+			KeyAttrInPort:    nlenc.Uint32Bytes(2),
+			KeyAttrEthertype: {0x08, 0x00},
+		},
+		Packet: []byte{0xde, 0xad, 0xbe, 0xef},
+	}
+
+	action := Upcall{
+		Command:       UpcallAction,
+		DatapathIndex: 1,
+		Key: FlowKey{
+			KeyAttrInPort: nlenc.Uint32Bytes(3),
+		},
+		Userdata: []byte{0x01, 0x02, 0x03, 0x04},
+		Packet:   []byte{0xff, 0xff, 0xff, 0xff},
+		Length:   1500,
+	}
+
+	conn := genltest.Dial(func(greq genetlink.Message, nreq netlink.Message) ([]genetlink.Message, error) {
+		return []genetlink.Message{
+			mustMarshalUpcall(miss),
+			// Commands which are not upcalls should be ignored.
+			{
+				Header: genetlink.Header{
+					Command: ovsh.PacketCmdExecute,
+				},
+			},
+			mustMarshalUpcall(action),
+		}, nil
+	})
+
+	l := newUpcallListener(conn, 1)
+	defer l.Close()
+
+	if diff := cmp.Diff(uint32(1), l.PortID()); diff != "" {
+		t.Fatalf("unexpected port ID (-want +got):\n%s", diff)
+	}
+
+	ups, err := l.Receive()
+	if err != nil {
+		t.Fatalf("failed to receive upcalls: %v", err)
+	}
+
+	if diff := cmp.Diff([]Upcall{miss, action}, ups); diff != "" {
+		t.Fatalf("unexpected upcalls (-want +got):\n%s", diff)
+	}
+}
+
+func mustMarshalUpcall(up Upcall) genetlink.Message {
+	kb, err := marshalFlowKey(up.Key)
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal flow key: %v", err))
+	}
+
+	attrs := []netlink.Attribute{
+		{
+			Type: ovsh.PacketAttrPacket,
+			Data: up.Packet,
+		},
+		{
+			Type: ovsh.PacketAttrKey,
+			Data: kb,
+		},
+	}
+
+	if up.Userdata != nil {
+		attrs = append(attrs, netlink.Attribute{
+			Type: ovsh.PacketAttrUserdata,
+			Data: up.Userdata,
+		})
+	}
+
+	if up.Length != 0 {
+		attrs = append(attrs, netlink.Attribute{
+			Type: ovsh.PacketAttrLen,
+			Data: nlenc.Uint32Bytes(uint32(up.Length)),
+		})
+	}
+
+	return genetlink.Message{
+		Header: genetlink.Header{
+			Command: uint8(up.Command),
+		},
+		Data: append(headerBytes(ovsh.Header{
+			Ifindex: int32(up.DatapathIndex),
+		}), mustMarshalAttributes(attrs)...),
+	}
+}
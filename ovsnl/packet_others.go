@@ -0,0 +1,29 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build !linux
+
+package ovsnl
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/mdlayher/genetlink"
+)
+
+// portID is not implemented on non-Linux platforms.
+func portID(_ *genetlink.Conn) (uint32, error) {
+	return 0, fmt.Errorf("ovsnl: netlink port IDs not supported on %s", runtime.GOOS)
+}
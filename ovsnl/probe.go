@@ -0,0 +1,261 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovsnl
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/digitalocean/go-openvswitch/ovsnl/internal/ovsh"
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nlenc"
+	"golang.org/x/sys/unix"
+)
+
+// DatapathCapabilities describes the features of a datapath and of the
+// running openvswitch kernel module, as detected by DatapathService.Probe.
+type DatapathCapabilities struct {
+	// Features are the datapath's currently negotiated features.
+	Features DatapathFeatures
+	// PerCPUUpcalls reports whether upcalls are dispatched to per-CPU
+	// netlink port IDs rather than to per-vport port IDs.
+	PerCPUUpcalls bool
+	// MasksCacheSize is the number of entries in the per-CPU cache used to
+	// look up megaflow masks, or zero if the kernel does not support the
+	// cache.  It is not a limit on the number of masks.
+	MasksCacheSize uint32
+	// Masks is the number of megaflow masks currently in use.
+	//
+	// The kernel does not impose or report a maximum number of megaflow
+	// masks, because its mask array grows as masks are added, so no maximum
+	// is reported here.
+	Masks uint32
+	// KeyAttrs are the optional flow key attributes supported by the
+	// kernel, out of those which are probed.
+	KeyAttrs []KeyAttr
+	// Actions are the optional datapath actions supported by the kernel.
+	Actions ActionCapabilities
+}
+
+// ActionCapabilities reports which optional datapath actions are supported
+// by the kernel.
+type ActionCapabilities struct {
+	CT          bool
+	NAT         bool
+	Meter       bool
+	Clone       bool
+	CheckPktLen bool
+	DecTTL      bool
+}
+
+// probeKeyAttrs are the optional flow key attributes checked by Probe,
+// with valid values for a probe flow.
+var probeKeyAttrs = []struct {
+	attr  KeyAttr
+	value []byte
+}{
+	{attr: KeyAttrRecircID, value: nlenc.Uint32Bytes(1)},
+	{attr: KeyAttrDPHash, value: nlenc.Uint32Bytes(1)},
+	{attr: KeyAttrCTState, value: nlenc.Uint32Bytes(0)},
+	{attr: KeyAttrCTZone, value: nlenc.Uint16Bytes(0)},
+	{attr: KeyAttrCTMark, value: nlenc.Uint32Bytes(0)},
+	{attr: KeyAttrCTLabels, value: make([]byte, 16)},
+	// struct ovs_key_ct_tuple_ipv4, including padding.
+	{attr: KeyAttrCTOrigTupleIPv4, value: make([]byte, 16)},
+}
+
+// Probe detects the capabilities of the datapath with the specified
+// interface index.  Optional key attributes and actions are detected by
+// installing probe flows, which can never match a packet, and deleting
+// them immediately afterward.
+func (s *DatapathService) Probe(index int) (DatapathCapabilities, error) {
+	if s.c.Flow == nil {
+		return DatapathCapabilities{}, errors.New("flow generic netlink family is required to probe a datapath")
+	}
+
+	dps, err := s.List()
+	if err != nil {
+		return DatapathCapabilities{}, err
+	}
+
+	var (
+		dp Datapath
+		ok bool
+	)
+
+	for _, d := range dps {
+		if d.Index == index {
+			dp, ok = d, true
+			break
+		}
+	}
+	if !ok {
+		return DatapathCapabilities{}, fmt.Errorf("datapath %d not found", index)
+	}
+
+	caps := DatapathCapabilities{
+		Features:       dp.Features,
+		PerCPUUpcalls:  dp.Features&DatapathFeaturesDispatchUpcallPerCPU != 0,
+		MasksCacheSize: dp.MasksCacheSize,
+		Masks:          dp.MegaflowStats.Masks,
+	}
+
+	for _, p := range probeKeyAttrs {
+		key := probeKey()
+		key[p.attr] = p.value
+
+		ok, err := s.c.Flow.probe(index, key, nil)
+		if err != nil {
+			return DatapathCapabilities{}, err
+		}
+		if ok {
+			caps.KeyAttrs = append(caps.KeyAttrs, p.attr)
+		}
+	}
+
+	emptyNested := func(typ uint16) netlink.Attribute {
+		return netlink.Attribute{Type: typ}
+	}
+
+	for _, p := range []struct {
+		ok     *bool
+		action netlink.Attribute
+	}{
+		{
+			ok:     &caps.Actions.CT,
+			action: emptyNested(ovsh.ActionAttrCt),
+		},
+		{
+			ok: &caps.Actions.NAT,
+			action: netlink.Attribute{
+				Type: ovsh.ActionAttrCt,
+				Data: mustMarshalProbeAttributes(emptyNested(ovsh.CtAttrNat)),
+			},
+		},
+		{
+			ok: &caps.Actions.Meter,
+			action: netlink.Attribute{
+				Type: ovsh.ActionAttrMeter,
+				Data: nlenc.Uint32Bytes(0),
+			},
+		},
+		{
+			ok:     &caps.Actions.Clone,
+			action: emptyNested(ovsh.ActionAttrClone),
+		},
+		{
+			ok: &caps.Actions.CheckPktLen,
+			action: netlink.Attribute{
+				Type: ovsh.ActionAttrCheckPktLen,
+				Data: mustMarshalProbeAttributes(
+					netlink.Attribute{
+						Type: ovsh.CheckPktLenAttrPktLen,
+						Data: nlenc.Uint16Bytes(1500),
+					},
+					emptyNested(ovsh.CheckPktLenAttrActionsIfGreater),
+					emptyNested(ovsh.CheckPktLenAttrActionsIfLessEqual),
+				),
+			},
+		},
+		{
+			ok: &caps.Actions.DecTTL,
+			action: netlink.Attribute{
+				Type: ovsh.ActionAttrDecTtl,
+				Data: mustMarshalProbeAttributes(emptyNested(ovsh.DecTtlAttrAction)),
+			},
+		},
+	} {
+		ok, err := s.c.Flow.probe(index, probeKey(), []netlink.Attribute{p.action})
+		if err != nil {
+			return DatapathCapabilities{}, err
+		}
+
+		*p.ok = ok
+	}
+
+	return caps, nil
+}
+
+// probeKey returns the base FlowKey for probe flows: an IPv4 packet arriving
+// on the highest possible port number, which is never attached to a vport.
+func probeKey() FlowKey {
+	return FlowKey{
+		KeyAttrInPort:    nlenc.Uint32Bytes(0xffff),
+		KeyAttrEthernet:  make([]byte, 12),
+		KeyAttrEthertype: {0x08, 0x00},
+		// struct ovs_key_ipv4.
+		KeyAttrIPv4: make([]byte, 12),
+	}
+}
+
+// probe installs a probe flow with the specified key and actions, reporting
+// whether the kernel accepted it.  Accepted probe flows are deleted.
+func (s *FlowService) probe(datapath int, key FlowKey, actions []netlink.Attribute) (bool, error) {
+	kb, err := marshalFlowKey(key)
+	if err != nil {
+		return false, err
+	}
+
+	ab, err := netlink.MarshalAttributes(actions)
+	if err != nil {
+		return false, err
+	}
+
+	attrs, err := netlink.MarshalAttributes([]netlink.Attribute{
+		{Type: ovsh.FlowAttrKey, Data: kb},
+		{Type: ovsh.FlowAttrActions, Data: ab},
+		// Suppress kernel log messages for rejected probes.
+		{Type: ovsh.FlowAttrProbe},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	// Exclusive creation is not requested, so that a probe flow left behind
+	// by an earlier probe is replaced rather than causing an error.
+	flags := netlink.Request | netlink.Create | netlink.Echo
+	_, err = s.c.execute(s.f, ovsh.FlowCmdNew, datapath, attrs, flags)
+	switch {
+	case errors.Is(err, unix.EINVAL), errors.Is(err, unix.EOPNOTSUPP), errors.Is(err, unix.ERANGE):
+		// The kernel does not support the key or actions.
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+
+	del, err := netlink.MarshalAttributes([]netlink.Attribute{
+		{Type: ovsh.FlowAttrKey, Data: kb},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := s.c.execute(s.f, ovsh.FlowCmdDel, datapath, del, netlink.Request|netlink.Echo); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// mustMarshalProbeAttributes marshals fixed netlink attributes for probe
+// actions, which are known to be valid.
+func mustMarshalProbeAttributes(attrs ...netlink.Attribute) []byte {
+	b, err := netlink.MarshalAttributes(attrs)
+	if err != nil {
+		panic(fmt.Sprintf("ovsnl: failed to marshal probe attributes: %v", err))
+	}
+
+	return b
+}
@@ -0,0 +1,126 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build linux
+
+package ovsnl_test
+
+import (
+	"testing"
+
+	"github.com/digitalocean/go-openvswitch/ovsnl"
+	"github.com/digitalocean/go-openvswitch/ovsnl/ovsnltest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClientDatapathProbe(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(k *ovsnltest.Kernel)
+		want ovsnl.DatapathCapabilities
+	}{
+		{
+			name: "all",
+			want: ovsnl.DatapathCapabilities{
+				MasksCacheSize: 256,
+				KeyAttrs: []ovsnl.KeyAttr{
+					ovsnl.KeyAttrRecircID,
+					ovsnl.KeyAttrDPHash,
+					ovsnl.KeyAttrCTState,
+					ovsnl.KeyAttrCTZone,
+					ovsnl.KeyAttrCTMark,
+					ovsnl.KeyAttrCTLabels,
+					ovsnl.KeyAttrCTOrigTupleIPv4,
+				},
+				Actions: ovsnl.ActionCapabilities{
+					CT:          true,
+					NAT:         true,
+					Meter:       true,
+					Clone:       true,
+					CheckPktLen: true,
+					DecTTL:      true,
+				},
+			},
+		},
+		{
+			name: "old kernel",
+			fn: func(k *ovsnltest.Kernel) {
+				k.MaxKeyAttr = ovsnl.KeyAttrCTLabels
+				k.Actions = ovsnl.ActionCapabilities{
+					CT:    true,
+					Meter: true,
+				}
+			},
+			want: ovsnl.DatapathCapabilities{
+				MasksCacheSize: 256,
+				KeyAttrs: []ovsnl.KeyAttr{
+					ovsnl.KeyAttrRecircID,
+					ovsnl.KeyAttrDPHash,
+					ovsnl.KeyAttrCTState,
+					ovsnl.KeyAttrCTZone,
+					ovsnl.KeyAttrCTMark,
+					ovsnl.KeyAttrCTLabels,
+				},
+				Actions: ovsnl.ActionCapabilities{
+					CT:    true,
+					Meter: true,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := ovsnltest.New()
+			if tt.fn != nil {
+				tt.fn(k)
+			}
+			dp := k.AddDatapath("ovs-system")
+
+			c, err := k.Client()
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+			defer c.Close()
+
+			caps, err := c.Datapath.Probe(dp)
+			if err != nil {
+				t.Fatalf("failed to probe datapath: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, caps); diff != "" {
+				t.Fatalf("unexpected capabilities (-want +got):\n%s", diff)
+			}
+
+			// Probe flows must not be left behind.
+			if diff := cmp.Diff(0, len(k.Flows(dp))); diff != "" {
+				t.Fatalf("unexpected number of flows (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestClientDatapathProbeNotFound(t *testing.T) {
+	k := ovsnltest.New()
+
+	c, err := k.Client()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.Datapath.Probe(1); err == nil {
+		t.Fatalf("expected an error, but none occurred")
+	}
+}
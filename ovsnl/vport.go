@@ -0,0 +1,230 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovsnl
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/digitalocean/go-openvswitch/ovsnl/internal/ovsh"
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nlenc"
+)
+
+// A VportService provides access to methods which interact with the
+// "ovs_vport" generic netlink family.
+type VportService struct {
+	c *Client
+	f genetlink.Family
+}
+
+// A Vport is a port attached to an Open vSwitch in-kernel datapath.
+type Vport struct {
+	// DatapathIndex is the interface index of the vport's datapath.
+	DatapathIndex int
+	// PortNo is the vport's port number within its datapath.
+	PortNo uint32
+	Type   VportType
+	Name   string
+	// Ifindex is the interface index of the vport's network device, if any.
+	Ifindex int
+	// UpcallPortIDs are the netlink port IDs which receive upcalls for
+	// packets arriving on the vport.
+	UpcallPortIDs []uint32
+	Stats         VportStats
+}
+
+// A VportType is the type of a Vport.
+type VportType uint32
+
+// Possible VportType values.
+const (
+	VportTypeNetdev   VportType = ovsh.VportTypeNetdev
+	VportTypeInternal VportType = ovsh.VportTypeInternal
+	VportTypeGRE      VportType = ovsh.VportTypeGre
+	VportTypeVXLAN    VportType = ovsh.VportTypeVxlan
+	VportTypeGeneve   VportType = ovsh.VportTypeGeneve
+)
+
+// String returns the string representation of a VportType.
+func (t VportType) String() string {
+	switch t {
+	case VportTypeNetdev:
+		return "netdev"
+	case VportTypeInternal:
+		return "internal"
+	case VportTypeGRE:
+		return "gre"
+	case VportTypeVXLAN:
+		return "vxlan"
+	case VportTypeGeneve:
+		return "geneve"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint32(t))
+	}
+}
+
+// VportStats contains statistics about packets that have passed
+// through a Vport.
+type VportStats struct {
+	RxPackets uint64
+	TxPackets uint64
+	RxBytes   uint64
+	TxBytes   uint64
+	RxErrors  uint64
+	TxErrors  uint64
+	RxDropped uint64
+	TxDropped uint64
+}
+
+// List lists all Vports in the datapath with the specified interface index.
+func (s *VportService) List(datapath int) ([]Vport, error) {
+	flags := netlink.Request | netlink.Dump
+	msgs, err := s.c.execute(s.f, ovsh.VportCmdGet, datapath, nil, flags)
+	if err != nil {
+		return nil, err
+	}
+
+	vps := make([]Vport, 0, len(msgs))
+	for _, m := range msgs {
+		vp, err := parseVport(m)
+		if err != nil {
+			return nil, err
+		}
+
+		vps = append(vps, vp)
+	}
+
+	return vps, nil
+}
+
+// Get retrieves the Vport with the specified name.  Vport names are unique
+// across all datapaths.
+func (s *VportService) Get(name string) (Vport, error) {
+	ab, err := netlink.MarshalAttributes([]netlink.Attribute{{
+		Type: ovsh.VportAttrName,
+		Data: nlenc.Bytes(name),
+	}})
+	if err != nil {
+		return Vport{}, err
+	}
+
+	msgs, err := s.c.execute(s.f, ovsh.VportCmdGet, 0, ab, netlink.Request)
+	if err != nil {
+		return Vport{}, err
+	}
+
+	if l := len(msgs); l != 1 {
+		return Vport{}, fmt.Errorf("expected one vport reply, but got: %d", l)
+	}
+
+	return parseVport(msgs[0])
+}
+
+// SetUpcallPortIDs sets the netlink port IDs which receive upcalls for
+// packets arriving on the vport with the specified port number, in the
+// datapath with the specified interface index.
+func (s *VportService) SetUpcallPortIDs(datapath int, port uint32, pids ...uint32) error {
+	pb := make([]byte, 0, 4*len(pids))
+	for _, pid := range pids {
+		pb = append(pb, nlenc.Uint32Bytes(pid)...)
+	}
+
+	ab, err := netlink.MarshalAttributes([]netlink.Attribute{
+		{
+			Type: ovsh.VportAttrPortNo,
+			Data: nlenc.Uint32Bytes(port),
+		},
+		{
+			Type: ovsh.VportAttrUpcallPid,
+			Data: pb,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.c.execute(s.f, ovsh.VportCmdSet, datapath, ab, netlink.Request)
+	return err
+}
+
+// parseVport parses a Vport from a generic netlink message.
+func parseVport(m genetlink.Message) (Vport, error) {
+	// Fetch the header at the beginning of the message.
+	h, err := parseHeader(m.Data)
+	if err != nil {
+		return Vport{}, err
+	}
+
+	vp := Vport{
+		DatapathIndex: int(h.Ifindex),
+	}
+
+	// Skip the header to parse attributes.
+	attrs, err := netlink.UnmarshalAttributes(m.Data[sizeofHeader:])
+	if err != nil {
+		return Vport{}, err
+	}
+
+	for _, a := range attrs {
+		switch a.Type {
+		case ovsh.VportAttrPortNo:
+			vp.PortNo = nlenc.Uint32(a.Data)
+		case ovsh.VportAttrType:
+			vp.Type = VportType(nlenc.Uint32(a.Data))
+		case ovsh.VportAttrName:
+			vp.Name = nlenc.String(a.Data)
+		case ovsh.VportAttrIfindex:
+			vp.Ifindex = int(nlenc.Uint32(a.Data))
+		case ovsh.VportAttrUpcallPid:
+			if l := len(a.Data); l%4 != 0 {
+				return Vport{}, fmt.Errorf("unexpected vport upcall port IDs size: %d", l)
+			}
+
+			for i := 0; i < len(a.Data); i += 4 {
+				vp.UpcallPortIDs = append(vp.UpcallPortIDs, nlenc.Uint32(a.Data[i:i+4]))
+			}
+		case ovsh.VportAttrStats:
+			vp.Stats, err = parseVportStats(a.Data)
+			if err != nil {
+				return Vport{}, err
+			}
+		}
+	}
+
+	return vp, nil
+}
+
+// parseVportStats converts a byte slice into VportStats.
+func parseVportStats(b []byte) (VportStats, error) {
+	// Verify that the byte slice is the correct length before doing
+	// unsafe casts.
+	if want, got := sizeofVportStats, len(b); want != got {
+		return VportStats{}, fmt.Errorf("unexpected vport stats structure size, want %d, got %d", want, got)
+	}
+
+	s := *(*ovsh.VportStats)(unsafe.Pointer(&b[0]))
+	return VportStats{
+		RxPackets: s.Rx_packets,
+		TxPackets: s.Tx_packets,
+		RxBytes:   s.Rx_bytes,
+		TxBytes:   s.Tx_bytes,
+		RxErrors:  s.Rx_errors,
+		TxErrors:  s.Tx_errors,
+		RxDropped: s.Rx_dropped,
+		TxDropped: s.Tx_dropped,
+	}, nil
+}
@@ -0,0 +1,286 @@
+// Copyright 2021 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build linux
+
+package ovsnl
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/digitalocean/go-openvswitch/ovsnl/internal/ovsh"
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/genetlink/genltest"
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nlenc"
+)
+
+func TestClientVportSetUpcallPortIDsOK(t *testing.T) {
+	conn := genltest.Dial(ovsFamilies(func(greq genetlink.Message, nreq netlink.Message) ([]genetlink.Message, error) {
+		if diff := cmp.Diff(ovsh.VportCmdSet, int(greq.Header.Command)); diff != "" {
+			t.Fatalf("unexpected generic netlink command (-want +got):\n%s", diff)
+		}
+
+		h, err := parseHeader(greq.Data)
+		if err != nil {
+			t.Fatalf("failed to parse OvS generic netlink header: %v", err)
+		}
+
+		if diff := cmp.Diff(1, int(h.Ifindex)); diff != "" {
+			t.Fatalf("unexpected datapath ID (-want +got):\n%s", diff)
+		}
+
+		attrs, err := netlink.UnmarshalAttributes(greq.Data[sizeofHeader:])
+		if err != nil {
+			t.Fatalf("failed to unmarshal attributes: %v", err)
+		}
+
+		want := []netlink.Attribute{
+			{
+				Length: 8,
+				Type:   ovsh.VportAttrPortNo,
+				Data:   nlenc.Uint32Bytes(2),
+			},
+			{
+				Length: 12,
+				Type:   ovsh.VportAttrUpcallPid,
+				Data:   append(nlenc.Uint32Bytes(100), nlenc.Uint32Bytes(200)...),
+			},
+		}
+
+		if diff := cmp.Diff(want, attrs); diff != "" {
+			t.Fatalf("unexpected attributes (-want +got):\n%s", diff)
+		}
+
+		return []genetlink.Message{{
+			Data: greq.Data,
+		}}, nil
+	}))
+
+	c, err := newClient(conn)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	if err := c.Vport.SetUpcallPortIDs(1, 2, 100, 200); err != nil {
+		t.Fatalf("failed to set upcall port IDs: %v", err)
+	}
+}
+
+func TestClientVportListBadStats(t *testing.T) {
+	conn := genltest.Dial(ovsFamilies(func(greq genetlink.Message, nreq netlink.Message) ([]genetlink.Message, error) {
+		// Valid header; not enough data for ovsh.VportStats.
+		return []genetlink.Message{{
+			Data: append(
+				// ovsh.Header.
+				[]byte{0xff, 0xff, 0xff, 0xff},
+				// netlink attributes.
+				mustMarshalAttributes([]netlink.Attribute{{
+					Type: ovsh.VportAttrStats,
+					Data: []byte{0xff},
+				}})...,
+			),
+		}}, nil
+	}))
+
+	c, err := newClient(conn)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	_, err = c.Vport.List(1)
+	if err == nil {
+		t.Fatalf("expected an error, but none occurred")
+	}
+
+	t.Logf("OK error: %v", err)
+}
+
+func TestClientVportListOK(t *testing.T) {
+	vps := []Vport{
+		{
+			DatapathIndex: 1,
+			PortNo:        0,
+			Type:          VportTypeInternal,
+			Name:          "ovs-system",
+			Ifindex:       2,
+			UpcallPortIDs: []uint32{0},
+			Stats: VportStats{
+				RxPackets: 1,
+				TxPackets: 2,
+				RxBytes:   3,
+				TxBytes:   4,
+				RxErrors:  5,
+				TxErrors:  6,
+				RxDropped: 7,
+				TxDropped: 8,
+			},
+		},
+		{
+			DatapathIndex: 1,
+			PortNo:        1,
+			Type:          VportTypeVXLAN,
+			Name:          "vxlan_sys_4789",
+			Ifindex:       3,
+			UpcallPortIDs: []uint32{100, 200},
+		},
+	}
+
+	conn := genltest.Dial(ovsFamilies(func(greq genetlink.Message, nreq netlink.Message) ([]genetlink.Message, error) {
+		if diff := cmp.Diff(ovsh.VportCmdGet, int(greq.Header.Command)); diff != "" {
+			t.Fatalf("unexpected generic netlink command (-want +got):\n%s", diff)
+		}
+
+		if diff := cmp.Diff(netlink.Request|netlink.Dump, nreq.Header.Flags); diff != "" {
+			t.Fatalf("unexpected netlink flags (-want +got):\n%s", diff)
+		}
+
+		h, err := parseHeader(greq.Data)
+		if err != nil {
+			t.Fatalf("failed to parse OvS generic netlink header: %v", err)
+		}
+
+		if diff := cmp.Diff(1, int(h.Ifindex)); diff != "" {
+			t.Fatalf("unexpected datapath ID (-want +got):\n%s", diff)
+		}
+
+		msgs := make([]genetlink.Message, 0, len(vps))
+		for _, vp := range vps {
+			msgs = append(msgs, genetlink.Message{
+				Data: mustMarshalVport(vp),
+			})
+		}
+
+		return msgs, nil
+	}))
+
+	c, err := newClient(conn)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	got, err := c.Vport.List(1)
+	if err != nil {
+		t.Fatalf("failed to list vports: %v", err)
+	}
+
+	if diff := cmp.Diff(vps, got); diff != "" {
+		t.Fatalf("unexpected vports (-want +got):\n%s", diff)
+	}
+}
+
+func TestClientVportGetOK(t *testing.T) {
+	vp := Vport{
+		DatapathIndex: 1,
+		PortNo:        1,
+		Type:          VportTypeNetdev,
+		Name:          "eth0",
+		Ifindex:       2,
+		UpcallPortIDs: []uint32{100},
+	}
+
+	conn := genltest.Dial(ovsFamilies(func(greq genetlink.Message, nreq netlink.Message) ([]genetlink.Message, error) {
+		attrs, err := netlink.UnmarshalAttributes(greq.Data[sizeofHeader:])
+		if err != nil {
+			t.Fatalf("failed to unmarshal attributes: %v", err)
+		}
+
+		want := []netlink.Attribute{{
+			Length: 9,
+			Type:   ovsh.VportAttrName,
+			Data:   nlenc.Bytes("eth0"),
+		}}
+
+		if diff := cmp.Diff(want, attrs); diff != "" {
+			t.Fatalf("unexpected attributes (-want +got):\n%s", diff)
+		}
+
+		return []genetlink.Message{{
+			Data: mustMarshalVport(vp),
+		}}, nil
+	}))
+
+	c, err := newClient(conn)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	got, err := c.Vport.Get("eth0")
+	if err != nil {
+		t.Fatalf("failed to get vport: %v", err)
+	}
+
+	if diff := cmp.Diff(vp, got); diff != "" {
+		t.Fatalf("unexpected vport (-want +got):\n%s", diff)
+	}
+}
+
+func mustMarshalVport(vp Vport) []byte {
+	hb := headerBytes(ovsh.Header{
+		Ifindex: int32(vp.DatapathIndex),
+	})
+
+	s := ovsh.VportStats{
+		Rx_packets: vp.Stats.RxPackets,
+		Tx_packets: vp.Stats.TxPackets,
+		Rx_bytes:   vp.Stats.RxBytes,
+		Tx_bytes:   vp.Stats.TxBytes,
+		Rx_errors:  vp.Stats.RxErrors,
+		Tx_errors:  vp.Stats.TxErrors,
+		Rx_dropped: vp.Stats.RxDropped,
+		Tx_dropped: vp.Stats.TxDropped,
+	}
+
+	sb := *(*[sizeofVportStats]byte)(unsafe.Pointer(&s))
+
+	var pb []byte
+	for _, pid := range vp.UpcallPortIDs {
+		pb = append(pb, nlenc.Uint32Bytes(pid)...)
+	}
+
+	ab := mustMarshalAttributes([]netlink.Attribute{
+		{
+			Type: ovsh.VportAttrPortNo,
+			Data: nlenc.Uint32Bytes(vp.PortNo),
+		},
+		{
+			Type: ovsh.VportAttrType,
+			Data: nlenc.Uint32Bytes(uint32(vp.Type)),
+		},
+		{
+			Type: ovsh.VportAttrName,
+			Data: nlenc.Bytes(vp.Name),
+		},
+		{
+			Type: ovsh.VportAttrIfindex,
+			Data: nlenc.Uint32Bytes(uint32(vp.Ifindex)),
+		},
+		{
+			Type: ovsh.VportAttrUpcallPid,
+			Data: pb,
+		},
+		{
+			Type: ovsh.VportAttrStats,
+			Data: sb[:],
+		},
+	})
+
+	return append(hb, ab...)
+}